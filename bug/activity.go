@@ -0,0 +1,169 @@
+package bug
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ActivityPollInterval is the delay between two scans of the repository when
+// following the activity
+var ActivityPollInterval = time.Second
+
+// ActivityEntry is a single event of the activity of the repository: an
+// operation added to a bug
+type ActivityEntry struct {
+	BugId     string
+	Operation Operation
+	EditTime  util.LamportTime
+	Err       error
+}
+
+func (entry ActivityEntry) String() string {
+	if entry.Err != nil {
+		return fmt.Sprintf("%.8s error: %v", entry.BugId, entry.Err)
+	}
+
+	return fmt.Sprintf("%.8s %s %s",
+		entry.BugId,
+		entry.Operation.Time().Format(time.RFC3339),
+		entry.Operation.OpType(),
+	)
+}
+
+// TailActivity follow the activity of the local bugs and emit the operations
+// committed after the call, until the context is cancelled. The repository
+// is polled regularly, and each operation is only emitted once, even if
+// several were added between two polls or if a merge rebased it on new
+// commits. A bug that can't be read is skipped until the next poll.
+func TailActivity(repo repository.Repo, ctx context.Context) (<-chan ActivityEntry, error) {
+	state := make(map[string]*activityState)
+
+	// the current state is not part of the tail
+	_, err := pollActivity(repo, state)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ActivityEntry)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(ActivityPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			entries, err := pollActivity(repo, state)
+			if err != nil {
+				// the next poll may succeed
+				entries = append(entries, ActivityEntry{Err: err})
+			}
+
+			for _, entry := range entries {
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// activityState is what is known of a bug at the last poll: its head and the
+// identity of its operations, which don't change when a merge rebase them
+type activityState struct {
+	head util.Hash
+	ops  map[util.Hash]bool
+}
+
+// pollActivity return the operations not seen yet, for each bug that changed
+// since the last poll. Only the state of the current bugs is kept.
+func pollActivity(repo repository.Repo, state map[string]*activityState) ([]ActivityEntry, error) {
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ActivityEntry
+	current := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		current[id] = true
+
+		head, err := repo.ResolveRef(bugsRefPattern + id)
+		if err != nil {
+			// removed since it was listed, or not readable for now
+			continue
+		}
+
+		previous := state[id]
+		if previous != nil && previous.head == head {
+			continue
+		}
+
+		b, err := ReadLocalBug(repo, id)
+		if err != nil {
+			continue
+		}
+
+		bugEntries, ops, err := newActivity(b, previous)
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, bugEntries...)
+		state[id] = &activityState{head: head, ops: ops}
+	}
+
+	for id := range state {
+		if !current[id] {
+			delete(state, id)
+		}
+	}
+
+	return entries, nil
+}
+
+// newActivity return the operations of a bug missing from its previous
+// state, and the identity of all its operations
+func newActivity(b *Bug, previous *activityState) ([]ActivityEntry, map[util.Hash]bool, error) {
+	var entries []ActivityEntry
+	ops := make(map[util.Hash]bool)
+
+	it := NewOperationIterator(b)
+
+	for it.Next() {
+		op := it.Value()
+
+		hash, err := op.Hash()
+		if err != nil {
+			return nil, nil, err
+		}
+		ops[hash] = true
+
+		if previous != nil && previous.ops[hash] {
+			continue
+		}
+
+		entries = append(entries, ActivityEntry{
+			BugId:     b.id,
+			Operation: op,
+			EditTime:  it.editTime(),
+		})
+	}
+
+	return entries, ops, nil
+}
@@ -0,0 +1,209 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// When the id of a bug change, an alias is kept under this ref pattern so
+// that the old id can still be resolved. The ref point to a blob holding the
+// new id.
+const aliasRefPattern = "refs/bugs-alias/"
+
+var ErrAliasCycle = errors.New("alias cycle detected")
+
+// RecomputeId recompute the id of a local bug after its history has been
+// rewritten. The id of a bug is the hash of its first commit, so if this
+// commit changed, the bug ref is moved to the new id and an alias is written
+// for the old one. The new id is returned.
+func RecomputeId(repo repository.Repo, id string) (string, error) {
+	bug, err := ReadLocalBug(repo, id)
+	if err != nil {
+		return "", err
+	}
+
+	newId := string(bug.packs[0].commitHash)
+
+	// the first commit is the same, the id is still valid
+	if newId == id {
+		return id, nil
+	}
+
+	oldRef := bugsRefPattern + id
+	newRef := bugsRefPattern + newId
+
+	exist, err := repo.RefExist(newRef)
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		return "", fmt.Errorf("a bug with the id %s already exist", newId)
+	}
+
+	err = repo.CopyRef(oldRef, newRef)
+	if err != nil {
+		return "", err
+	}
+
+	err = repo.RemoveRef(oldRef)
+	if err != nil {
+		return "", err
+	}
+
+	err = WriteAlias(repo, id, newId)
+	if err != nil {
+		return "", err
+	}
+
+	return newId, nil
+}
+
+// ResolveAlias follow the aliases of a bug id, if any, and return the id of
+// the bug it now refer to. An id without alias, or the id of a live bug, is
+// returned as is.
+func ResolveAlias(repo repository.Repo, id string) (string, error) {
+	visited := make(map[string]bool)
+
+	for {
+		if visited[id] {
+			return "", ErrAliasCycle
+		}
+		visited[id] = true
+
+		// a live bug take precedence over an alias
+		live, err := repo.RefExist(bugsRefPattern + id)
+		if err != nil {
+			return "", err
+		}
+		if live {
+			return id, nil
+		}
+
+		exist, err := repo.RefExist(aliasRefPattern + id)
+		if err != nil {
+			return "", err
+		}
+		if !exist {
+			return id, nil
+		}
+
+		id, err = readAlias(repo, id)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// readAlias return the id an alias directly point to
+func readAlias(repo repository.Repo, id string) (string, error) {
+	hash, err := repo.ResolveRef(aliasRefPattern + id)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := repo.ReadData(hash)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// WriteAlias make an id refer to the bug with another id, for example to keep
+// resolving the id a bug had before being imported. A live bug with the same
+// id take precedence over the alias.
+func WriteAlias(repo repository.Repo, from string, to string) error {
+	hash, err := repo.StoreData([]byte(to))
+	if err != nil {
+		return err
+	}
+
+	return repo.UpdateRef(aliasRefPattern+from, hash)
+}
+
+type AliasIssueKind int
+
+const (
+	_ AliasIssueKind = iota
+	// The alias lead to a bug that doesn't exist anymore
+	AliasDangling
+	// Following the alias lead back to itself
+	AliasCycle
+	// The alias has the same id as a live bug, and is therefore ignored
+	AliasShadowing
+)
+
+func (k AliasIssueKind) String() string {
+	switch k {
+	case AliasDangling:
+		return "dangling"
+	case AliasCycle:
+		return "cycle"
+	case AliasShadowing:
+		return "shadowing"
+	default:
+		return "unknown"
+	}
+}
+
+// AliasIssue describe a problem found in the alias namespace
+type AliasIssue struct {
+	Kind AliasIssueKind
+	// The aliased id
+	Alias string
+	// The id the alias directly point to
+	Target string
+}
+
+func (i AliasIssue) String() string {
+	return fmt.Sprintf("%s alias %s -> %s", i.Kind, i.Alias, i.Target)
+}
+
+// AuditAliases check the consistency between the aliases and the live bugs
+// and report the dangling aliases, the alias cycles and the aliases
+// shadowing a live bug.
+func AuditAliases(repo repository.Repo) ([]AliasIssue, error) {
+	aliases, err := repo.ListIds(aliasRefPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []AliasIssue
+
+	for _, alias := range aliases {
+		target, err := readAlias(repo, alias)
+		if err != nil {
+			return nil, err
+		}
+
+		shadowed, err := repo.RefExist(bugsRefPattern + alias)
+		if err != nil {
+			return nil, err
+		}
+		if shadowed {
+			issues = append(issues, AliasIssue{Kind: AliasShadowing, Alias: alias, Target: target})
+			continue
+		}
+
+		resolved, err := ResolveAlias(repo, alias)
+		if err == ErrAliasCycle {
+			issues = append(issues, AliasIssue{Kind: AliasCycle, Alias: alias, Target: target})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		exist, err := repo.RefExist(bugsRefPattern + resolved)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			issues = append(issues, AliasIssue{Kind: AliasDangling, Alias: alias, Target: target})
+		}
+	}
+
+	return issues, nil
+}
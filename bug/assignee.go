@@ -0,0 +1,27 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// ListAssignedTo return the compiled local bugs currently assigned to someone,
+// matched by name or email. An empty assignee list the unassigned bugs.
+func ListAssignedTo(repo repository.Repo, assignee string) ([]*Snapshot, error) {
+	var result []*Snapshot
+
+	for streamed := range ReadAllLocalBugs(repo) {
+		if streamed.Err != nil {
+			return nil, streamed.Err
+		}
+
+		snap := streamed.Bug.Compile()
+
+		if !snap.Assignee.match(assignee) {
+			continue
+		}
+
+		result = append(result, &snap)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ErrAttachmentNotFound is returned when the blob of an attachment is not
+// available in the repository, usually because it has not been fetched
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// Attachment is a file referenced by an operation of a bug
+type Attachment struct {
+	// Hash of the blob holding the file
+	Hash util.Hash
+	// The operation that referenced the file
+	Operation Operation
+	// Size of the file in bytes, or -1 if not known yet. See
+	// ReadAttachmentSizes.
+	Size int64
+}
+
+// AttachmentOperation is implemented by the operations having files attached
+// by their author. Unlike Files, the other blobs they reference, like the
+// thumbnails, are not included.
+type AttachmentOperation interface {
+	// AttachedFiles return the files attached to the operation
+	AttachedFiles() []util.Hash
+}
+
+// Attachments return the files attached to the operations of the bug, in the
+// order of the operations. A file attached to several operations is listed
+// once for each of them.
+func (snap Snapshot) Attachments() []Attachment {
+	var result []Attachment
+
+	for _, op := range snap.Operations {
+		attaching, ok := op.(AttachmentOperation)
+		if !ok {
+			continue
+		}
+
+		for _, file := range attaching.AttachedFiles() {
+			result = append(result, Attachment{
+				Hash:      file,
+				Operation: op,
+				Size:      -1,
+			})
+		}
+	}
+
+	return result
+}
+
+// ReadAttachment read the content of an attached file. ErrAttachmentNotFound
+// is returned if the blob is not in the repository.
+func ReadAttachment(repo repository.Repo, hash util.Hash) ([]byte, error) {
+	data, err := repo.ReadData(hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not available locally, it may not have been fetched: %v",
+			ErrAttachmentNotFound, hash, err)
+	}
+
+	return data, nil
+}
+
+// ReadAttachmentSizes set the size of the attached files, without reading
+// them. ErrAttachmentNotFound is returned if a blob is not in the repository.
+func ReadAttachmentSizes(repo repository.Repo, attachments []Attachment) error {
+	sizes := make(map[util.Hash]int64)
+
+	for i := range attachments {
+		size, ok := sizes[attachments[i].Hash]
+
+		if !ok {
+			var err error
+			size, err = repo.BlobSize(attachments[i].Hash)
+			if err != nil {
+				return fmt.Errorf("%w: %s is not available locally, it may not have been fetched: %v",
+					ErrAttachmentNotFound, attachments[i].Hash, err)
+			}
+			sizes[attachments[i].Hash] = size
+		}
+
+		attachments[i].Size = size
+	}
+
+	return nil
+}
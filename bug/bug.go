@@ -3,7 +3,9 @@ package bug
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/MichaelMure/git-bug/repository"
 	"github.com/MichaelMure/git-bug/util"
@@ -18,12 +20,31 @@ const mediaEntryName = "media"
 
 const createClockEntryPrefix = "create-clock-"
 const createClockEntryPattern = "create-clock-%d"
+const createClockEntryName = "create-clock"
 const editClockEntryPrefix = "edit-clock-"
 const editClockEntryPattern = "edit-clock-%d"
+const editClockEntryName = "edit-clock"
 
 const idLength = 40
 const humanIdLength = 7
 
+// ErrDivergentRoot is the error returned when trying to merge two versions of
+// a bug that don't share the same creation
+var ErrDivergentRoot = errors.New("the two versions of the bug have a different root, they can't be merged")
+
+var ErrMalformedMedia = errors.New("malformed media entry")
+
+// ErrInvalidBug is the error returned when the data of a bug doesn't make a
+// valid bug, for example when received from a remote
+var ErrInvalidBug = errors.New("invalid bug")
+
+// ErrBugNotFound is the error returned when no bug match an id or a prefix
+var ErrBugNotFound = errors.New("No matching bug found.")
+
+// ErrMultipleBugs is the error returned when a prefix is ambiguous. The
+// returned error wrap it with the list of the matching ids.
+var ErrMultipleBugs = errors.New("Multiple matching bug found")
+
 // Bug hold the data of a bug thread, organized in a way close to
 // how it will be persisted inside Git. This is the data structure
 // used to merge two different version of the same Bug.
@@ -38,6 +59,10 @@ type Bug struct {
 	// Id used as unique identifier
 	id string
 
+	// length of the displayed id, long enough to be unambiguous in the
+	// repository the bug was read from
+	humanIdLength int
+
 	lastCommit util.Hash
 	rootPack   util.Hash
 
@@ -47,6 +72,9 @@ type Bug struct {
 	// a temporary pack of operations used for convenience to pile up new operations
 	// before a commit
 	staging OperationPack
+
+	// notified after each successful commit
+	observers []CommitObserver
 }
 
 // NewBug create a new Bug
@@ -56,38 +84,79 @@ func NewBug() *Bug {
 	return &Bug{}
 }
 
-// FindLocalBug find an existing Bug matching a prefix
+// FindLocalBug find an existing Bug matching a prefix. ErrBugNotFound is
+// returned if no bug match, and ErrMultipleBugs if several do.
 func FindLocalBug(repo repository.Repo, prefix string) (*Bug, error) {
-	ids, err := repo.ListIds(bugsRefPattern)
-
+	id, err := ResolveIdentifier(repo, prefix)
 	if err != nil {
 		return nil, err
 	}
 
+	return ReadLocalBug(repo, id)
+}
+
+// ResolveIdentifier return the full id of the local bug designated by a full
+// id, a human id or any other prefix of an id. The id of a bug that has been
+// known under another id is resolved through its alias. ErrBugNotFound is
+// returned if no bug match, and ErrMultipleBugs if several do.
+func ResolveIdentifier(repo repository.Repo, s string) (string, error) {
+	ids, err := repo.ListIds(bugsRefPattern)
+	if err != nil {
+		return "", err
+	}
+
 	// preallocate but empty
 	matching := make([]string, 0, 5)
 
 	for _, id := range ids {
-		if strings.HasPrefix(id, prefix) {
+		// an exact match can't be ambiguous
+		if id == s {
+			return id, nil
+		}
+
+		// a human id is a prefix as well
+		if strings.HasPrefix(id, s) {
 			matching = append(matching, id)
 		}
 	}
 
-	if len(matching) == 0 {
-		return nil, errors.New("No matching bug found.")
+	if len(matching) > 1 {
+		return "", fmt.Errorf("%w:\n%s", ErrMultipleBugs, strings.Join(matching, "\n"))
 	}
 
-	if len(matching) > 1 {
-		return nil, fmt.Errorf("Multiple matching bug found:\n%s", strings.Join(matching, "\n"))
+	if len(matching) == 1 {
+		return matching[0], nil
 	}
 
-	return ReadLocalBug(repo, matching[0])
+	// the bug might have been known under another id
+	if s != "" {
+		target, err := ResolveAlias(repo, s)
+		if err != nil {
+			return "", err
+		}
+		if target != s {
+			return target, nil
+		}
+	}
+
+	return "", ErrBugNotFound
 }
 
-// ReadLocalBug will read a local bug from its hash
+// LocalBugExists tell if a local bug exist with the given id, without
+// reading it. Contrary to FindLocalBug, the full id is required.
+func LocalBugExists(repo repository.Repo, id string) (bool, error) {
+	if err := checkId(id); err != nil {
+		return false, err
+	}
+
+	return repo.RefExist(bugsRefPattern + id)
+}
+
+// ReadLocalBug will read a local bug from its hash. The cache of the
+// repository is used if the bug didn't change since it was last read.
 func ReadLocalBug(repo repository.Repo, id string) (*Bug, error) {
 	ref := bugsRefPattern + id
-	return readBug(repo, ref)
+	return readCachedBug(repo, ref)
 }
 
 // ReadRemoteBug will read a remote bug from its hash
@@ -98,26 +167,117 @@ func ReadRemoteBug(repo repository.Repo, remote string, id string) (*Bug, error)
 
 // readBug will read and parse a Bug from git
 func readBug(repo repository.Repo, ref string) (*Bug, error) {
-	hashes, err := repo.ListCommits(ref)
+	// an aliased ref can be a symbolic one, the id is the one of the target
+	ref, err := repo.ResolveSymbolicRef(ref)
+	if err != nil {
+		return nil, err
+	}
 
+	id, head, parents, err := readBugRef(repo, ref)
 	if err != nil {
 		return nil, err
 	}
 
+	return readBugHistory(repo, id, head, parents)
+}
+
+// readBugRef return the id, the head and the commit graph of the bug a
+// resolved ref point to
+func readBugRef(repo repository.Repo, ref string) (string, util.Hash, map[util.Hash][]util.Hash, error) {
+	head, err := repo.ResolveRef(ref)
+
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	parents, err := repo.ListCommitParents(ref)
+
+	if err != nil {
+		return "", "", nil, err
+	}
+
 	refSplitted := strings.Split(ref, "/")
 	id := refSplitted[len(refSplitted)-1]
 
-	if len(id) != idLength {
-		return nil, fmt.Errorf("Invalid ref length")
+	return id, head, parents, nil
+}
+
+// ReadLocalBugAt will read a local bug as it was at one of its past commits.
+// The history is read up to and including this commit, which must be part of
+// the history of the bug.
+func ReadLocalBugAt(repo repository.Repo, id string, commit util.Hash) (*Bug, error) {
+	// an aliased ref can be a symbolic one, the id is the one of the target
+	ref, err := repo.ResolveSymbolicRef(bugsRefPattern + id)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _, parents, err := readBugRef(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// the graph hold all the ancestors of the tip, the tip included
+	if _, ok := parents[commit]; !ok {
+		return nil, fmt.Errorf("commit %s is not part of the history of bug %s", commit, id)
+	}
+
+	return readBugHistory(repo, id, commit, parents)
+}
+
+// ReadBugFromCommit will read a bug from the hash of one of its commit, even
+// if no ref point to it. The history is read up to this commit and the id is
+// derived from the root commit.
+func ReadBugFromCommit(repo repository.Repo, commit util.Hash) (*Bug, error) {
+	exist, err := repo.CommitExist(commit)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, fmt.Errorf("unknown commit %s", commit)
+	}
+
+	parents, err := repo.ListCommitParents(string(commit))
+	if err != nil {
+		return nil, err
+	}
+
+	// the root commit is the only one without parent
+	var roots []util.Hash
+	for hash, commitParents := range parents {
+		if len(commitParents) == 0 {
+			roots = append(roots, hash)
+		}
+	}
+
+	if len(roots) != 1 {
+		return nil, fmt.Errorf("commit %s is not part of a bug history", commit)
+	}
+
+	return readBugHistory(repo, string(roots[0]), commit, parents)
+}
+
+// readBugHistory will read and parse a Bug from its commits
+func readBugHistory(repo repository.Repo, id string, head util.Hash, parents map[util.Hash][]util.Hash) (*Bug, error) {
+	hashes := linearizeCommits(head, parents)
+
+	if err := checkId(id); err != nil {
+		return nil, err
+	}
+
+	idLength, err := HumanIdLength(repo)
+	if err != nil {
+		return nil, err
 	}
 
 	bug := Bug{
-		id: id,
+		id:            id,
+		humanIdLength: idLength,
 	}
 
 	// Load each OperationPack
 	for _, hash := range hashes {
-		entries, err := repo.ListEntries(hash)
+		tree, err := readCommitTree(repo, hash)
 
 		bug.lastCommit = hash
 
@@ -125,56 +285,32 @@ func readBug(repo repository.Repo, ref string) (*Bug, error) {
 			return nil, err
 		}
 
-		var opsEntry repository.TreeEntry
-		opsFound := false
-		var rootEntry repository.TreeEntry
-		rootFound := false
-		var createTime uint64
-		var editTime uint64
+		// merge commits only join two branches of the history and touch
+		// commits only bump the edit clock, neither carry any operation
+		isMerge := len(parents[hash]) > 1
+		isTouch := !tree.opsFound && !isMerge
 
-		for _, entry := range entries {
-			if entry.Name == opsEntryName {
-				opsEntry = entry
-				opsFound = true
-				continue
-			}
-			if entry.Name == rootEntryName {
-				rootEntry = entry
-				rootFound = true
-			}
-			if strings.HasPrefix(entry.Name, createClockEntryPrefix) {
-				n, err := fmt.Sscanf(string(entry.Name), createClockEntryPattern, &createTime)
-				if err != nil {
-					return nil, err
-				}
-				if n != 1 {
-					return nil, fmt.Errorf("could not parse create time lamport value")
-				}
-			}
-			if strings.HasPrefix(entry.Name, editClockEntryPrefix) {
-				n, err := fmt.Sscanf(string(entry.Name), editClockEntryPattern, &editTime)
-				if err != nil {
-					return nil, err
-				}
-				if n != 1 {
-					return nil, fmt.Errorf("could not parse edit time lamport value")
-				}
-			}
-		}
-
-		if !opsFound {
+		if !tree.opsFound && bug.rootPack == "" {
 			return nil, errors.New("Invalid tree, missing the ops entry")
 		}
-		if !rootFound {
+		if !tree.rootFound {
 			return nil, errors.New("Invalid tree, missing the root entry")
 		}
 
 		if bug.rootPack == "" {
-			bug.rootPack = rootEntry.Hash
-			bug.createTime = util.LamportTime(createTime)
+			// the root entry of the first commit is the first ops pack itself
+			if tree.rootEntry.Hash != tree.opsEntry.Hash {
+				return nil, errors.New("Invalid tree, the root entry doesn't match the first ops entry")
+			}
+			bug.rootPack = tree.rootEntry.Hash
+			bug.createTime = util.LamportTime(tree.createTime)
+		}
+
+		if tree.rootEntry.Hash != bug.rootPack {
+			return nil, fmt.Errorf("Invalid tree, the root entry of commit %s doesn't match the first ops entry", hash)
 		}
 
-		bug.editTime = util.LamportTime(editTime)
+		bug.editTime = util.LamportTime(tree.editTime)
 
 		// Update the clocks
 		if err := repo.CreateWitness(bug.createTime); err != nil {
@@ -184,24 +320,18 @@ func readBug(repo repository.Repo, ref string) (*Bug, error) {
 			return nil, err
 		}
 
-		data, err := repo.ReadData(opsEntry.Hash)
-
-		if err != nil {
-			return nil, err
+		if isMerge || isTouch {
+			continue
 		}
 
-		op, err := ParseOperationPack(data)
-
+		op, err := readPack(repo, tree.opsEntry.Hash)
 		if err != nil {
 			return nil, err
 		}
 
-		// tag the pack with the commit hash
+		// tag the pack with the commit hash and its logical time
 		op.commitHash = hash
-
-		if err != nil {
-			return nil, err
-		}
+		op.editTime = bug.editTime
 
 		bug.packs = append(bug.packs, *op)
 	}
@@ -209,24 +339,177 @@ func readBug(repo repository.Repo, ref string) (*Bug, error) {
 	return &bug, nil
 }
 
+func checkId(id string) error {
+	if len(id) != idLength {
+		return fmt.Errorf("Invalid ref length")
+	}
+
+	if hash := util.Hash(id); !hash.IsValid() {
+		return fmt.Errorf("Invalid ref, %s is not an hexadecimal id", id)
+	}
+
+	return nil
+}
+
+// commitTree is what a commit of a bug hold in its tree
+type commitTree struct {
+	opsEntry   repository.TreeEntry
+	opsFound   bool
+	rootEntry  repository.TreeEntry
+	rootFound  bool
+	createTime uint64
+	editTime   uint64
+}
+
+// readCommitTree read and check the tree entries of a commit of a bug
+func readCommitTree(repo repository.Repo, hash util.Hash) (commitTree, error) {
+	var tree commitTree
+
+	entries, err := repo.ListEntries(hash)
+	if err != nil {
+		return commitTree{}, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == opsEntryName {
+			tree.opsEntry = entry
+			tree.opsFound = true
+			continue
+		}
+		if entry.Name == rootEntryName {
+			tree.rootEntry = entry
+			tree.rootFound = true
+		}
+		if entry.Name == mediaEntryName {
+			err := checkMediaTree(repo, entry)
+			if err != nil {
+				return commitTree{}, err
+			}
+		}
+		if strings.HasPrefix(entry.Name, createClockEntryPrefix) {
+			n, err := fmt.Sscanf(string(entry.Name), createClockEntryPattern, &tree.createTime)
+			if err != nil {
+				return commitTree{}, err
+			}
+			if n != 1 {
+				return commitTree{}, fmt.Errorf("could not parse create time lamport value")
+			}
+		}
+		if strings.HasPrefix(entry.Name, editClockEntryPrefix) {
+			n, err := fmt.Sscanf(string(entry.Name), editClockEntryPattern, &tree.editTime)
+			if err != nil {
+				return commitTree{}, err
+			}
+			if n != 1 {
+				return commitTree{}, fmt.Errorf("could not parse edit time lamport value")
+			}
+		}
+		if entry.Name == createClockEntryName {
+			tree.createTime, err = readClockBlob(repo, entry.Hash)
+			if err != nil {
+				return commitTree{}, err
+			}
+		}
+		if entry.Name == editClockEntryName {
+			tree.editTime, err = readClockBlob(repo, entry.Hash)
+			if err != nil {
+				return commitTree{}, err
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// readPack read and parse an OperationPack, its external payloads being read
+// only when needed. A pack in a
+// newer format is returned empty and marked as unsupported.
+func readPack(repo repository.Repo, hash util.Hash) (*OperationPack, error) {
+	data, err := repo.ReadData(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := ParseOperationPack(data)
+
+	// keep what we can understand for a read-only inspection
+	if errors.Is(err, ErrUnsupportedFormatVersion) {
+		op = &OperationPack{unsupported: true}
+		err = nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	op.bindPayloads(repo)
+
+	return op, nil
+}
+
 type StreamedBug struct {
 	Bug *Bug
 	Err error
+	// The remote the bug was read from, if any
+	Remote string
+	// The repository the bug was read from, when reading a MultiRepo
+	Repo string
 }
 
 // ReadAllLocalBugs read and parse all local bugs
 func ReadAllLocalBugs(repo repository.Repo) <-chan StreamedBug {
-	return readAllBugs(repo, bugsRefPattern)
+	return readAllBugs(repo, bugsRefPattern, readCachedBug)
+}
+
+// ReadAllLocalBugsParallel read and parse all local bugs like
+// ReadAllLocalBugs, with the given number of workers reading the bugs
+// concurrently. The bugs are streamed in no particular order. As with
+// ReadAllLocalBugs, the first error is sent last before the channel is closed.
+func ReadAllLocalBugsParallel(repo repository.Repo, workers int) <-chan StreamedBug {
+	return readAllBugsParallel(repo, bugsRefPattern, readCachedBug, workers)
 }
 
 // ReadAllRemoteBugs read and parse all remote bugs for a given remote
 func ReadAllRemoteBugs(repo repository.Repo, remote string) <-chan StreamedBug {
 	refPrefix := fmt.Sprintf(bugsRemoteRefPattern, remote)
-	return readAllBugs(repo, refPrefix)
+	return readAllBugs(repo, refPrefix, readBug)
+}
+
+// ReadAllRemoteBugsAllRemotes read and parse the remote bugs of every remote,
+// one remote after the other. An error reading a remote is sent on the
+// channel and the next remotes are still read.
+func ReadAllRemoteBugsAllRemotes(repo repository.Repo) <-chan StreamedBug {
+	out := make(chan StreamedBug)
+
+	go func() {
+		defer close(out)
+
+		remotes, err := repo.Remotes()
+		if err != nil {
+			out <- StreamedBug{Err: err}
+			return
+		}
+
+		names := make([]string, 0, len(remotes))
+		for name := range remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, remote := range names {
+			for streamed := range ReadAllRemoteBugs(repo, remote) {
+				streamed.Remote = remote
+				out <- streamed
+			}
+		}
+	}()
+
+	return out
 }
 
 // Read and parse all available bug with a given ref prefix
-func readAllBugs(repo repository.Repo, refPrefix string) <-chan StreamedBug {
+func readAllBugs(repo repository.Repo, refPrefix string, read func(repository.Repo, string) (*Bug, error)) <-chan StreamedBug {
 	out := make(chan StreamedBug)
 
 	go func() {
@@ -239,7 +522,7 @@ func readAllBugs(repo repository.Repo, refPrefix string) <-chan StreamedBug {
 		}
 
 		for _, ref := range refs {
-			b, err := readBug(repo, ref)
+			b, err := read(repo, ref)
 
 			if err != nil {
 				out <- StreamedBug{Err: err}
@@ -253,57 +536,184 @@ func readAllBugs(repo repository.Repo, refPrefix string) <-chan StreamedBug {
 	return out
 }
 
+func readAllBugsParallel(repo repository.Repo, refPrefix string, read func(repository.Repo, string) (*Bug, error), workers int) <-chan StreamedBug {
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan StreamedBug)
+
+	go func() {
+		defer close(out)
+
+		refs, err := repo.ListRefs(refPrefix)
+		if err != nil {
+			out <- StreamedBug{Err: err}
+			return
+		}
+
+		toRead := make(chan string)
+		done := make(chan struct{})
+
+		// the results are sent one at a time so that nothing follow an error
+		var sendMutex sync.Mutex
+		failed := false
+
+		send := func(streamed StreamedBug) bool {
+			sendMutex.Lock()
+			defer sendMutex.Unlock()
+
+			if failed {
+				return false
+			}
+
+			out <- streamed
+
+			if streamed.Err != nil {
+				failed = true
+				close(done)
+				return false
+			}
+
+			return true
+		}
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for ref := range toRead {
+					b, err := read(repo, ref)
+					if !send(StreamedBug{Bug: b, Err: err}) {
+						return
+					}
+				}
+			}()
+		}
+
+	feed:
+		for _, ref := range refs {
+			select {
+			case toRead <- ref:
+			case <-done:
+				break feed
+			}
+		}
+
+		close(toRead)
+		wg.Wait()
+	}()
+
+	return out
+}
+
 // ListLocalIds list all the available local bug ids
 func ListLocalIds(repo repository.Repo) ([]string, error) {
 	return repo.ListIds(bugsRefPattern)
 }
 
+// ReadOnly tell if some parts of the history use a newer format and couldn't
+// be read. Such a bug can be inspected but not merged.
+func (bug *Bug) ReadOnly() bool {
+	for _, pack := range bug.packs {
+		if pack.unsupported {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsValid check if the Bug data is valid
 func (bug *Bug) IsValid() bool {
+	return bug.Validate() == nil
+}
+
+// Validate check if the Bug data is valid and return an ErrInvalidBug
+// describing the problem if not
+func (bug *Bug) Validate() error {
 	// non-empty
 	if len(bug.packs) == 0 && bug.staging.IsEmpty() {
-		return false
+		return fmt.Errorf("%w: no operation", ErrInvalidBug)
 	}
 
 	// check if each pack is valid
-	for _, pack := range bug.packs {
+	for i, pack := range bug.packs {
 		if !pack.IsValid() {
-			return false
+			return fmt.Errorf("%w: pack %d (commit %s) is empty", ErrInvalidBug, i, pack.commitHash)
 		}
 	}
 
 	// check if staging is valid if needed
 	if !bug.staging.IsEmpty() {
 		if !bug.staging.IsValid() {
-			return false
+			return fmt.Errorf("%w: the staging area is invalid", ErrInvalidBug)
 		}
 	}
 
 	// The very first Op should be a CreateOp
 	firstOp := bug.FirstOp()
-	if firstOp == nil || firstOp.OpType() != CreateOp {
-		return false
+	if firstOp == nil {
+		return fmt.Errorf("%w: missing the create operation", ErrInvalidBug)
+	}
+	if firstOp.OpType() != CreateOp {
+		return fmt.Errorf("%w: the first operation is a %s instead of a create", ErrInvalidBug, firstOp.OpType())
 	}
 
 	// Check that there is no more CreateOp op
 	it := NewOperationIterator(bug)
-	createCount := 0
+	index := 0
 	for it.Next() {
-		if it.Value().OpType() == CreateOp {
-			createCount++
+		if index > 0 && it.Value().OpType() == CreateOp {
+			return fmt.Errorf("%w: operation %d is a second create", ErrInvalidBug, index)
 		}
+		index++
 	}
 
-	if createCount != 1 {
-		return false
+	return nil
+}
+
+// Clone return a deep copy of the bug, with its packs, staging area, clocks,
+// id and commit hashes. The clone share no mutable state with the original, so
+// operations can be tried on it without affecting the original. The operations
+// themselves are shared, as they are never modified once created. The
+// observers are not copied.
+func (bug *Bug) Clone() *Bug {
+	clone := &Bug{
+		createTime:    bug.createTime,
+		editTime:      bug.editTime,
+		id:            bug.id,
+		humanIdLength: bug.humanIdLength,
+		lastCommit:    bug.lastCommit,
+		rootPack:      bug.rootPack,
+		packs:         make([]OperationPack, len(bug.packs)),
+		staging:       bug.staging.Clone(),
 	}
 
-	return true
+	for i, pack := range bug.packs {
+		clone.packs[i] = pack.Clone()
+	}
+
+	return clone
 }
 
-// Append an operation into the staging area, to be committed later
-func (bug *Bug) Append(op Operation) {
+// Append an operation into the staging area, to be committed later. A comment
+// over the length limit is rejected if the limit is strict.
+func (bug *Bug) Append(op Operation) error {
+	if maxLength, strict := getCommentLengthLimit(); strict {
+		err := checkCommentLength(op, maxLength)
+		if err != nil {
+			return err
+		}
+	}
+
 	bug.staging.Append(op)
+
+	return nil
 }
 
 // HasPendingOp tell if the bug need to be committed
@@ -311,14 +721,61 @@ func (bug *Bug) HasPendingOp() bool {
 	return !bug.staging.IsEmpty()
 }
 
-// Commit write the staging area in Git and move the operations to the packs
+// Commit write the staging area in Git and move the operations to the packs.
+// The registered observers are notified once the commit succeeded.
 func (bug *Bug) Commit(repo repository.Repo) error {
+	err := bug.commit(repo)
+	if err != nil {
+		return err
+	}
+
+	bug.notifyObservers(bug.packs[len(bug.packs)-1].Operations)
+
+	return nil
+}
+
+func (bug *Bug) commit(repo repository.Repo) error {
 	if bug.staging.IsEmpty() {
 		return fmt.Errorf("can't commit a bug with no pending operation")
 	}
 
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return bug.commitLocked(repo)
+}
+
+// commitLocked write the staging area, the repository lock being already held
+func (bug *Bug) commitLocked(repo repository.Repo) error {
+	// Check the staged operations before writing anything
+	err := bug.ValidateStaging()
+	if err != nil {
+		return err
+	}
+
+	err = checkMediaLimits(repo, bug.staging)
+	if err != nil {
+		return err
+	}
+
+	// Like git commit, default to the configured identity for the operations
+	// without author
+	err = bug.staging.defaultAuthor(repo)
+	if err != nil {
+		return err
+	}
+
+	// Large payloads are stored as separate blobs
+	toWrite, err := bug.staging.externalizePayloads(repo)
+	if err != nil {
+		return err
+	}
+
 	// Write the Ops as a Git blob containing the serialized array
-	hash, err := bug.staging.Write(repo)
+	hash, err := toWrite.Write(repo)
 	if err != nil {
 		return err
 	}
@@ -338,7 +795,7 @@ func (bug *Bug) Commit(repo repository.Repo) error {
 	// Reference, if any, all the files required by the ops
 	// Git will check that they actually exist in the storage and will make sure
 	// to push/pull them as needed.
-	mediaTree := makeMediaTree(bug.staging)
+	mediaTree := makeMediaTree(toWrite)
 	if len(mediaTree) > 0 {
 		mediaTreeHash, err := repo.StoreTree(mediaTree)
 		if err != nil {
@@ -354,35 +811,45 @@ func (bug *Bug) Commit(repo repository.Repo) error {
 	// Store the logical clocks as well
 	// --> edit clock for each OperationPack/commits
 	// --> create clock only for the first OperationPack/commits
-	//
-	// To avoid having one blob for each clock value, clocks are serialized
-	// directly into the entry name
-	emptyBlobHash, err := repo.StoreData([]byte{})
+	editTime, err := repo.EditTimeIncrement()
 	if err != nil {
 		return err
 	}
 
-	editTime, err := repo.EditTimeIncrement()
+	editClockEntry, err := makeClockEntry(repo, editClockEntryPattern, editClockEntryName, editTime)
+	if err != nil {
+		return err
+	}
+
+	tree = append(tree, editClockEntry)
+
+	// Store the resulting status, to be read without the operations
+	status, err := bug.commitStatus(repo)
+	if err != nil {
+		return err
+	}
+
+	statusEntry, err := makeStatusEntry(repo, status)
 	if err != nil {
 		return err
 	}
 
-	tree = append(tree, repository.TreeEntry{
-		ObjectType: repository.Blob,
-		Hash:       emptyBlobHash,
-		Name:       fmt.Sprintf(editClockEntryPattern, editTime),
-	})
+	tree = append(tree, statusEntry)
+
+	createTime := bug.createTime
+
 	if bug.lastCommit == "" {
-		createTime, err := repo.CreateTimeIncrement()
+		createTime, err = repo.CreateTimeIncrement()
 		if err != nil {
 			return err
 		}
 
-		tree = append(tree, repository.TreeEntry{
-			ObjectType: repository.Blob,
-			Hash:       emptyBlobHash,
-			Name:       fmt.Sprintf(createClockEntryPattern, createTime),
-		})
+		createClockEntry, err := makeClockEntry(repo, createClockEntryPattern, createClockEntryName, createTime)
+		if err != nil {
+			return err
+		}
+
+		tree = append(tree, createClockEntry)
 	}
 
 	// Store the tree
@@ -402,23 +869,40 @@ func (bug *Bug) Commit(repo repository.Repo) error {
 		return err
 	}
 
-	bug.lastCommit = hash
-
 	// if it was the first commit, use the commit hash as bug id
 	if bug.id == "" {
 		bug.id = string(hash)
 	}
 
-	// Create or update the Git reference for this bug
+	// Create or update the Git reference for this bug, if it hasn't been
+	// changed meanwhile.
 	// When pushing later, the remote will ensure that this ref update
 	// is fast-forward, that is no data has been overwritten
-	ref := fmt.Sprintf("%s%s", bugsRefPattern, bug.id)
-	err = repo.UpdateRef(ref, hash)
+	err = bug.updateRef(repo, bug.lastCommit, hash)
+
+	if err != nil {
+		return err
+	}
+
+	bug.lastCommit = hash
 
+	// the displayed id stay the default one if the length can't be computed
+	if idLength, err := HumanIdLength(repo); err == nil {
+		bug.humanIdLength = idLength
+	}
+
+	// The staging area might have been persisted, it's not needed anymore
+	err = clearStaging(repo, bug.id)
 	if err != nil {
 		return err
 	}
 
+	bug.createTime = createTime
+	bug.editTime = editTime
+
+	bug.staging.commitHash = hash
+	bug.staging.editTime = editTime
+
 	bug.packs = append(bug.packs, bug.staging)
 	bug.staging = OperationPack{}
 
@@ -449,76 +933,111 @@ func makeMediaTree(pack OperationPack) []repository.TreeEntry {
 	return tree
 }
 
+// checkMediaTree verify that the media entry of a commit is a tree holding
+// only existing blobs
+func checkMediaTree(repo repository.Repo, media repository.TreeEntry) error {
+	if media.ObjectType != repository.Tree {
+		return fmt.Errorf("%w: %s is not a tree", ErrMalformedMedia, media.Name)
+	}
+
+	entries, err := repo.ListEntries(media.Hash)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformedMedia, media.Name, err)
+	}
+
+	for _, entry := range entries {
+		if entry.ObjectType != repository.Blob {
+			return fmt.Errorf("%w: %s/%s is not a blob", ErrMalformedMedia, media.Name, entry.Name)
+		}
+
+		exist, err := repo.BlobExist(entry.Hash)
+		if err != nil {
+			return fmt.Errorf("%w: %s/%s: %v", ErrMalformedMedia, media.Name, entry.Name, err)
+		}
+		if !exist {
+			return fmt.Errorf("%w: %s/%s is missing", ErrMalformedMedia, media.Name, entry.Name)
+		}
+	}
+
+	return nil
+}
+
 // Merge a different version of the same bug by rebasing operations of this bug
 // that are not present in the other on top of the chain of operations of the
 // other version.
 func (bug *Bug) Merge(repo repository.Repo, other *Bug) (bool, error) {
-	// Note: a faster merge should be possible without actually reading and parsing
-	// all operations pack of our side.
+	updated, _, err := bug.MergeWithConflicts(repo, other)
+	return updated, err
+}
+
+// MergeWithConflicts merge a different version of the same bug like Merge
+// does, and also return the conflicts. Operations made on both sides and
+// setting the same value differently, like two new titles, are conflicts.
+// They are resolved by the order of the operations, ours winning.
+func (bug *Bug) MergeWithConflicts(repo repository.Repo, other *Bug) (bool, []OperationConflict, error) {
+	// Note: MergeIncremental is a faster merge that doesn't read and parse all
+	// the operations pack of our side.
 	// Reading the other side is still necessary to validate remote data, at least
 	// for new operations
 
-	if bug.id != other.id {
-		return false, errors.New("merging unrelated bugs is not supported")
-	}
-
-	if len(other.staging.Operations) > 0 {
-		return false, errors.New("merging a bug with a non-empty staging is not supported")
+	err := bug.checkMergeable(other)
+	if err != nil {
+		return false, nil, err
 	}
 
-	if bug.lastCommit == "" || other.lastCommit == "" {
-		return false, errors.New("can't merge a bug that has never been stored")
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return false, nil, err
 	}
+	defer unlock()
 
-	ancestor, err := repo.FindCommonAncestor(bug.lastCommit, other.lastCommit)
-
+	plan, err := bug.planMerge(repo, other)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
 
-	ancestorIndex := 0
 	newPacks := make([]OperationPack, 0, len(bug.packs))
+	newPacks = append(newPacks, bug.packs[:plan.ancestorIndex+1]...)
 
-	// Find the root of the rebase
-	for i, pack := range bug.packs {
-		newPacks = append(newPacks, pack)
-
-		if pack.commitHash == ancestor {
-			ancestorIndex = i
-			break
-		}
-	}
+	head := bug.lastCommit
 
-	if len(other.packs) == ancestorIndex+1 {
+	if len(plan.theirs) == 0 {
 		// Nothing to rebase, return early
-		return false, nil
+		return false, nil, nil
 	}
 
 	// get other bug's extra packs
-	for i := ancestorIndex + 1; i < len(other.packs); i++ {
+	for _, pack := range plan.theirs {
 		// clone is probably not necessary
-		newPack := other.packs[i].Clone()
+		newPack := pack.Clone()
 
 		newPacks = append(newPacks, newPack)
 		bug.lastCommit = newPack.commitHash
 	}
 
 	// rebase our extra packs
-	for i := ancestorIndex + 1; i < len(bug.packs); i++ {
-		pack := bug.packs[i]
-
+	for i, pack := range plan.ours {
 		// get the referenced git tree
-		treeHash, err := repo.GetTreeHash(pack.commitHash)
+		treeHash, err := rebasedTree(repo, pack, plan.changed[i])
 
 		if err != nil {
-			return false, err
+			return false, nil, err
+		}
+
+		// the new head need the merged status
+		if i == len(plan.ours)-1 {
+			treeHash, err = replaceStatusEntry(repo, treeHash, plan.status)
+
+			if err != nil {
+				return false, nil, err
+			}
 		}
 
 		// create a new commit with the correct ancestor
 		hash, err := repo.StoreCommitWithParent(treeHash, bug.lastCommit)
 
 		if err != nil {
-			return false, err
+			return false, nil, err
 		}
 
 		// replace the pack
@@ -531,12 +1050,57 @@ func (bug *Bug) Merge(repo repository.Repo, other *Bug) (bool, error) {
 	}
 
 	// Update the git ref
-	err = repo.UpdateRef(bugsRefPattern+bug.id, bug.lastCommit)
+	err = bug.updateRef(repo, head, bug.lastCommit)
 	if err != nil {
-		return false, err
+		return false, nil, err
+	}
+
+	return true, plan.conflicts, nil
+}
+
+// checkMergeable check that another version of the bug can be merged
+func (bug *Bug) checkMergeable(other *Bug) error {
+	if bug.id != other.id {
+		return errors.New("merging unrelated bugs is not supported")
+	}
+
+	if len(other.staging.Operations) > 0 {
+		return errors.New("merging a bug with a non-empty staging is not supported")
+	}
+
+	if bug.lastCommit == "" || other.lastCommit == "" {
+		return errors.New("can't merge a bug that has never been stored")
+	}
+
+	// Both version should start with the same create operation, otherwise
+	// rebasing one on top of the other would make no sense
+	if bug.rootPack != other.rootPack {
+		return ErrDivergentRoot
+	}
+
+	// rebasing data we don't understand could corrupt it
+	if bug.ReadOnly() || other.ReadOnly() {
+		return ErrUnsupportedFormatVersion
+	}
+
+	return nil
+}
+
+// mergeAncestorIndex return the index of the pack of the last common
+// ancestor of both versions, the root of the rebase
+func (bug *Bug) mergeAncestorIndex(repo repository.Repo, other *Bug) (int, error) {
+	ancestor, err := repo.FindCommonAncestor(bug.lastCommit, other.lastCommit)
+	if err != nil {
+		return 0, err
 	}
 
-	return true, nil
+	for i, pack := range bug.packs {
+		if pack.commitHash == ancestor {
+			return i, nil
+		}
+	}
+
+	return 0, nil
 }
 
 // Id return the Bug identifier
@@ -549,14 +1113,23 @@ func (bug *Bug) Id() string {
 	return bug.id
 }
 
-// HumanId return the Bug identifier truncated for human consumption
+// HumanId return the Bug identifier truncated for human consumption, long
+// enough to be unambiguous in the repository the bug was read from
 func (bug *Bug) HumanId() string {
-	return formatHumanId(bug.Id())
+	return formatHumanId(bug.Id(), bug.humanIdLength)
+}
+
+// CreateLamportTime return the logical time of the creation of the bug, zero
+// if it has never been committed. Unlike the wall-clock time, it gives an
+// order of creation consistent across machines.
+func (bug *Bug) CreateLamportTime() util.LamportTime {
+	return bug.createTime
 }
 
-func formatHumanId(id string) string {
-	format := fmt.Sprintf("%%.%ds", humanIdLength)
-	return fmt.Sprintf(format, id)
+// EditLamportTime return the logical time of the last commit of the bug, zero
+// if it has never been committed
+func (bug *Bug) EditLamportTime() util.LamportTime {
+	return bug.editTime
 }
 
 // Lookup for the very first operation of the bug.
@@ -595,20 +1168,135 @@ func (bug *Bug) LastOp() Operation {
 	return lastPack.Operations[len(lastPack.Operations)-1]
 }
 
+// OpTypeCounts return the number of operations of each type, committed or not
+func (bug *Bug) OpTypeCounts() map[OperationType]int {
+	counts := make(map[OperationType]int)
+
+	it := NewOperationIterator(bug)
+
+	for it.Next() {
+		counts[it.Value().OpType()]++
+	}
+
+	return counts
+}
+
 // Compile a bug in a easily usable snapshot
 func (bug *Bug) Compile() Snapshot {
+	// the initial status is established by the create operation
+	snap := Snapshot{
+		id:            bug.id,
+		humanIdLength: bug.humanIdLength,
+		opCounts:      make(map[OperationType]int),
+	}
+
+	it := NewOperationIterator(bug)
+
+	for it.Next() {
+		snap = snap.apply(it.Value(), it.editTime(), it.operationId())
+	}
+
+	tree, warnings := buildCommentTree(snap.Comments)
+	snap.CommentTree = tree
+	snap.Warnings = append(snap.Warnings, warnings...)
+
+	return snap
+}
+
+// CompileUntil replay the operations of a bug only until the predicate is
+// satisfied by the snapshot. The partial snapshot is returned with true at the
+// first match, or the complete one with false if the predicate never matched.
+func CompileUntil(bug *Bug, predicate func(Snapshot) bool) (Snapshot, bool) {
 	snap := Snapshot{
-		id:     bug.id,
-		Status: OpenStatus,
+		id:            bug.id,
+		humanIdLength: bug.humanIdLength,
+		opCounts:      make(map[OperationType]int),
 	}
 
+	matched := false
 	it := NewOperationIterator(bug)
 
 	for it.Next() {
-		op := it.Value()
-		snap = op.Apply(snap)
-		snap.Operations = append(snap.Operations, op)
+		snap = snap.apply(it.Value(), it.editTime(), it.operationId())
+
+		if predicate(snap) {
+			matched = true
+			break
+		}
+	}
+
+	tree, warnings := buildCommentTree(snap.Comments)
+	snap.CommentTree = tree
+	snap.Warnings = append(snap.Warnings, warnings...)
+
+	return snap, matched
+}
+
+// apply an operation to the snapshot, along with the bookkeeping of Compile
+func (snap Snapshot) apply(op Operation, editTime util.LamportTime, id string) Snapshot {
+	commentCount := len(snap.Comments)
+	title := snap.Title
+
+	snap = op.Apply(snap)
+
+	if op.OpType() == SetTitleOp {
+		snap.TitleHistory = append(snap.TitleHistory, TitleEdit{
+			Old:      title,
+			New:      snap.Title,
+			Author:   op.GetAuthor(),
+			EditTime: editTime,
+			UnixTime: op.Time().Unix(),
+		})
+	}
+	snap.Operations = append(snap.Operations, op)
+
+	maxLength, _ := getCommentLengthLimit()
+	if err := checkCommentLength(op, maxLength); err != nil {
+		snap.Warnings = append(snap.Warnings, err.Error())
+	}
+	snap.editTimes = append(snap.editTimes, editTime)
+	snap.operationIds = append(snap.operationIds, id)
+	snap.opCounts[op.OpType()]++
+
+	// tag the new comment with the hash of the operation that created it
+	if len(snap.Comments) == commentCount+1 {
+		hash, err := HashOperation(op)
+		if err != nil {
+			snap.Warnings = append(snap.Warnings, err.Error())
+		}
+
+		comment := &snap.Comments[commentCount]
+		comment.Id = hash
+		comment.InReplyTo = op.Parent()
 	}
 
 	return snap
 }
+
+// ValidateStaging check that the references of the staged operations, like
+// the target of a comment edition, resolve in the bug as it would be when
+// the operation is applied.
+func (bug *Bug) ValidateStaging() error {
+	committed := &Bug{id: bug.id, packs: bug.packs}
+	snap := committed.Compile()
+
+	for i, op := range bug.staging.Operations {
+		if validatingOp, ok := op.(ValidatingOperation); ok {
+			err := validatingOp.Validate()
+			if err != nil {
+				return fmt.Errorf("staged operation %d (%s): %w", i, op.OpType(), err)
+			}
+		}
+
+		if refOp, ok := op.(ReferencingOperation); ok {
+			err := refOp.CheckReferences(snap)
+			if err != nil {
+				return fmt.Errorf("staged operation %d (%s): %w", i, op.OpType(), err)
+			}
+		}
+
+		snap = snap.apply(op, 0, "")
+	}
+
+	return nil
+}
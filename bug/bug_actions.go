@@ -24,6 +24,79 @@ func Push(repo repository.Repo, remote string) (string, error) {
 	return repo.PushRefs(remote, bugsRefPattern+"*")
 }
 
+// NeedsPush tell if the local version of the bug has commits that the remote
+// doesn't have, as far as we know from the last fetch. A bug never pushed to
+// this remote needs to be. A bug that diverged from the remote needs it too,
+// once the remote changes are pulled.
+func (bug *Bug) NeedsPush(repo repository.Repo, remote string) (bool, error) {
+	localHead, err := repo.ResolveRef(bugsRefPattern + bug.Id())
+	if err != nil {
+		return false, err
+	}
+
+	remoteRef := fmt.Sprintf(bugsRemoteRefPattern, remote) + bug.Id()
+
+	exist, err := repo.RefExist(remoteRef)
+	if err != nil {
+		return false, err
+	}
+	if !exist {
+		return true, nil
+	}
+
+	remoteHead, err := repo.ResolveRef(remoteRef)
+	if err != nil {
+		return false, err
+	}
+
+	if localHead == remoteHead {
+		return false, nil
+	}
+
+	// behind the remote, nothing of ours to push
+	ancestor, err := repo.FindCommonAncestor(localHead, remoteHead)
+	if err != nil {
+		return false, err
+	}
+
+	return ancestor != localHead, nil
+}
+
+// RenameRemoteBugs move the remote bug refs of a remote to the namespace of
+// another one, for when a remote has been renamed. The rename is refused if
+// the new namespace already hold some bugs.
+func RenameRemoteBugs(repo repository.Repo, oldRemote, newRemote string) error {
+	oldPrefix := fmt.Sprintf(bugsRemoteRefPattern, oldRemote)
+	newPrefix := fmt.Sprintf(bugsRemoteRefPattern, newRemote)
+
+	existing, err := repo.ListIds(newPrefix)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("the remote %s already has some bugs", newRemote)
+	}
+
+	ids, err := repo.ListIds(oldPrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := repo.CopyRef(oldPrefix+id, newPrefix+id)
+		if err != nil {
+			return err
+		}
+
+		err = repo.RemoveRef(oldPrefix + id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func Pull(repo repository.Repo, out io.Writer, remote string) error {
 	fmt.Fprintf(out, "Fetching remote ...\n")
 
@@ -44,6 +117,10 @@ func Pull(repo repository.Repo, out io.Writer, remote string) error {
 		if merge.Status != MsgMergeNothing {
 			fmt.Fprintf(out, "%s: %s\n", merge.HumanId, merge.Status)
 		}
+
+		for _, conflict := range merge.Conflicts {
+			fmt.Fprintf(out, "%s: %s\n", merge.HumanId, conflict)
+		}
 	}
 
 	return nil
@@ -55,20 +132,23 @@ type MergeResult struct {
 	Id      string
 	HumanId string
 	Status  string
+
+	// Concurrent operations of both sides that conflicted
+	Conflicts []OperationConflict
 }
 
-func newMergeError(id string, err error) MergeResult {
+func newMergeError(id string, idLength int, err error) MergeResult {
 	return MergeResult{
 		Id:      id,
-		HumanId: formatHumanId(id),
+		HumanId: formatHumanId(id, idLength),
 		Status:  err.Error(),
 	}
 }
 
-func newMergeStatus(id string, status string) MergeResult {
+func newMergeStatus(id string, idLength int, status string) MergeResult {
 	return MergeResult{
 		Id:      id,
-		HumanId: formatHumanId(id),
+		HumanId: formatHumanId(id, idLength),
 		Status:  status,
 	}
 }
@@ -87,6 +167,13 @@ func MergeAll(repo repository.Repo, remote string) <-chan MergeResult {
 			return
 		}
 
+		idLength, err := HumanIdLength(repo)
+
+		if err != nil {
+			out <- MergeResult{Err: err}
+			return
+		}
+
 		for _, remoteRef := range remoteRefs {
 			refSplitted := strings.Split(remoteRef, "/")
 			id := refSplitted[len(refSplitted)-1]
@@ -94,13 +181,13 @@ func MergeAll(repo repository.Repo, remote string) <-chan MergeResult {
 			remoteBug, err := readBug(repo, remoteRef)
 
 			if err != nil {
-				out <- newMergeError(id, err)
+				out <- newMergeError(id, idLength, err)
 				continue
 			}
 
 			// Check for error in remote data
-			if !remoteBug.IsValid() {
-				out <- newMergeStatus(id, MsgMergeInvalid)
+			if err := remoteBug.Validate(); err != nil {
+				out <- newMergeStatus(id, idLength, fmt.Sprintf("%s: %v", MsgMergeInvalid, err))
 				continue
 			}
 
@@ -108,7 +195,7 @@ func MergeAll(repo repository.Repo, remote string) <-chan MergeResult {
 			localExist, err := repo.RefExist(localRef)
 
 			if err != nil {
-				out <- newMergeError(id, err)
+				out <- newMergeError(id, idLength, err)
 				continue
 			}
 
@@ -117,32 +204,27 @@ func MergeAll(repo repository.Repo, remote string) <-chan MergeResult {
 				err := repo.CopyRef(remoteRef, localRef)
 
 				if err != nil {
-					out <- newMergeError(id, err)
+					out <- newMergeError(id, idLength, err)
 					return
 				}
 
-				out <- newMergeStatus(id, MsgMergeNew)
+				out <- newMergeStatus(id, idLength, MsgMergeNew)
 				continue
 			}
 
-			localBug, err := readBug(repo, localRef)
-
-			if err != nil {
-				out <- newMergeError(id, err)
-				return
-			}
-
-			updated, err := localBug.Merge(repo, remoteBug)
+			updated, conflicts, err := MergeIncrementalWithConflicts(repo, id, remoteBug)
 
 			if err != nil {
-				out <- newMergeError(id, err)
+				out <- newMergeError(id, idLength, err)
 				return
 			}
 
 			if updated {
-				out <- newMergeStatus(id, MsgMergeUpdated)
+				result := newMergeStatus(id, idLength, MsgMergeUpdated)
+				result.Conflicts = conflicts
+				out <- result
 			} else {
-				out <- newMergeStatus(id, MsgMergeNothing)
+				out <- newMergeStatus(id, idLength, MsgMergeNothing)
 			}
 		}
 	}()
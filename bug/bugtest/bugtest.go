@@ -0,0 +1,165 @@
+// Package bugtest provides helpers to build fixture bugs directly in a
+// repository and to verify the compiled result, to keep feature tests concise.
+package bugtest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// Commit describe a commit of a fixture bug
+type Commit struct {
+	// The operations stored in the commit
+	Operations []bug.Operation
+	// The logical edit time of the commit
+	EditTime util.LamportTime
+	// Arbitrary data stored as blobs and referenced in the media tree
+	Media [][]byte
+}
+
+// Bug describe a fixture bug, as a chain of commits
+type Bug struct {
+	CreateTime util.LamportTime
+	Commits    []Commit
+}
+
+// Build write a fixture bug in the repository and return its id. Commits are
+// written directly, in the same format as bug.Commit but without touching the
+// clocks of the repository, so that the logical times are fully controlled.
+func Build(repo repository.Repo, fixture Bug) (string, error) {
+	if len(fixture.Commits) == 0 {
+		return "", fmt.Errorf("a fixture bug need at least one commit")
+	}
+
+	emptyBlobHash, err := repo.StoreData([]byte{})
+	if err != nil {
+		return "", err
+	}
+
+	var rootPack util.Hash
+	var id string
+	var lastCommit util.Hash
+
+	for i, c := range fixture.Commits {
+		pack := bug.OperationPack{}
+		for _, op := range c.Operations {
+			pack.Append(op)
+		}
+
+		opsHash, err := pack.Write(repo)
+		if err != nil {
+			return "", err
+		}
+
+		if i == 0 {
+			rootPack = opsHash
+		}
+
+		tree := []repository.TreeEntry{
+			{ObjectType: repository.Blob, Hash: opsHash, Name: "ops"},
+			{ObjectType: repository.Blob, Hash: rootPack, Name: "root"},
+			{ObjectType: repository.Blob, Hash: emptyBlobHash, Name: fmt.Sprintf("edit-clock-%d", c.EditTime)},
+		}
+
+		if i == 0 {
+			tree = append(tree, repository.TreeEntry{
+				ObjectType: repository.Blob,
+				Hash:       emptyBlobHash,
+				Name:       fmt.Sprintf("create-clock-%d", fixture.CreateTime),
+			})
+		}
+
+		if len(c.Media) > 0 {
+			var mediaTree []repository.TreeEntry
+			for j, data := range c.Media {
+				hash, err := repo.StoreData(data)
+				if err != nil {
+					return "", err
+				}
+				mediaTree = append(mediaTree, repository.TreeEntry{
+					ObjectType: repository.Blob,
+					Hash:       hash,
+					Name:       fmt.Sprintf("file%d", j),
+				})
+			}
+
+			mediaTreeHash, err := repo.StoreTree(mediaTree)
+			if err != nil {
+				return "", err
+			}
+
+			tree = append(tree, repository.TreeEntry{
+				ObjectType: repository.Tree,
+				Hash:       mediaTreeHash,
+				Name:       "media",
+			})
+		}
+
+		treeHash, err := repo.StoreTree(tree)
+		if err != nil {
+			return "", err
+		}
+
+		if lastCommit == "" {
+			lastCommit, err = repo.StoreCommit(treeHash)
+		} else {
+			lastCommit, err = repo.StoreCommitWithParent(treeHash, lastCommit)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if i == 0 {
+			id = string(lastCommit)
+		}
+	}
+
+	err = repo.UpdateRef("refs/bugs/"+id, lastCommit)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Expected describe the expected state of a compiled bug
+type Expected struct {
+	Title    string
+	Status   bug.Status
+	Labels   []bug.Label
+	Comments []string
+}
+
+// Compare check a compiled bug against its expected state and describe the
+// first difference found, if any
+func Compare(snap bug.Snapshot, expected Expected) error {
+	if snap.Title != expected.Title {
+		return fmt.Errorf("title: got %q, expected %q", snap.Title, expected.Title)
+	}
+
+	if snap.Status != expected.Status {
+		return fmt.Errorf("status: got %s, expected %s", snap.Status, expected.Status)
+	}
+
+	if len(snap.Labels) != 0 || len(expected.Labels) != 0 {
+		if !reflect.DeepEqual(snap.Labels, expected.Labels) {
+			return fmt.Errorf("labels: got %v, expected %v", snap.Labels, expected.Labels)
+		}
+	}
+
+	if len(snap.Comments) != len(expected.Comments) {
+		return fmt.Errorf("comments: got %d, expected %d", len(snap.Comments), len(expected.Comments))
+	}
+
+	for i, comment := range snap.Comments {
+		if comment.Message != expected.Comments[i] {
+			return fmt.Errorf("comment %d: got %q, expected %q", i, comment.Message, expected.Comments[i])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package bugtest
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+var rene = bug.Person{
+	Name:  "René Descartes",
+	Email: "rene@descartes.fr",
+}
+
+func TestBuild(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	id, err := Build(repo, Bug{
+		CreateTime: 3,
+		Commits: []Commit{
+			{
+				Operations: []bug.Operation{
+					operations.NewCreateOp(rene, "title", "message", nil),
+					operations.NewAddCommentOp(rene, "comment", nil),
+				},
+				EditTime: 5,
+				Media:    [][]byte{[]byte("screenshot")},
+			},
+			{
+				Operations: []bug.Operation{
+					operations.NewSetTitleOp(rene, "title2", "title"),
+					operations.NewLabelChangeOperation(rene, []bug.Label{"bug"}, nil),
+				},
+				EditTime: 7,
+			},
+			{
+				Operations: []bug.Operation{
+					operations.NewSetStatusOp(rene, bug.ClosedStatus),
+				},
+				EditTime: 9,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := bug.ReadLocalBug(repo, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap := b.Compile()
+
+	err = Compare(snap, Expected{
+		Title:    "title2",
+		Status:   bug.ClosedStatus,
+		Labels:   []bug.Label{"bug"},
+		Comments: []string{"message", "comment"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snap.OperationEditTime(0) != 5 || snap.OperationEditTime(4) != 9 {
+		t.Fatal("unexpected edit time")
+	}
+
+	err = Compare(snap, Expected{Title: "title"})
+	if err == nil {
+		t.Fatal("a different snapshot should be reported")
+	}
+}
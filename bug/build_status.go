@@ -0,0 +1,31 @@
+package bug
+
+// BuildState is the state of a CI build
+type BuildState string
+
+const (
+	BuildPending BuildState = "pending"
+	BuildSuccess BuildState = "success"
+	BuildFailure BuildState = "failure"
+)
+
+// IsValid tell if the state is a known one
+func (s BuildState) IsValid() bool {
+	switch s {
+	case BuildPending, BuildSuccess, BuildFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// BuildStatus is the result of a CI build associated with a bug
+type BuildStatus struct {
+	// Name of the build, like "ci/lint"
+	Context string
+	State   BuildState
+	// Where to see the details of the build, if any
+	TargetURL string
+	Author    Person
+	UnixTime  int64
+}
@@ -0,0 +1,213 @@
+package bug
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The bugs are cached in this directory of the local storage of the repository
+const cacheDirName = "cache"
+
+// The version of the format of the cache entries, the entries of another
+// version are ignored
+const cacheVersion = 2
+
+// Cache store the bugs read from a repository in files, to avoid reading and
+// parsing again every operation pack of a bug that didn't change. The
+// operations are stored decoded, so that a cached bug is read with a single
+// decoding. An entry is keyed by the bug id and the hash of its last commit,
+// so it's ignored and removed as soon as the ref of the bug move.
+type Cache struct {
+	repo repository.Repo
+	dir  string
+}
+
+type cacheEntry struct {
+	Version    int
+	LastCommit util.Hash
+	RootPack   util.Hash
+	CreateTime util.LamportTime
+	EditTime   util.LamportTime
+	Packs      []cachedPack
+}
+
+type cachedPack struct {
+	Operations  []Operation
+	EditTimes   []util.LamportTime
+	CommitHash  util.Hash
+	EditTime    util.LamportTime
+	Unsupported bool
+}
+
+// NewCache return the cache of a repository. Only the repositories with a
+// local storage on disk can have one.
+func NewCache(repo repository.Repo) (*Cache, error) {
+	storage, ok := repo.(repository.LocalStorage)
+	if !ok {
+		return nil, errors.New("the repository doesn't have a local storage for a cache")
+	}
+
+	return &Cache{
+		repo: repo,
+		dir:  path.Join(storage.LocalStoragePath(), cacheDirName),
+	}, nil
+}
+
+// Get return the cached bug with the given id, if its last commit is the
+// given one. A stale entry is removed.
+func (c *Cache) Get(id string, lastCommit util.Hash) (*Bug, bool) {
+	data, err := ioutil.ReadFile(path.Join(c.dir, id))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&entry)
+	if err != nil || entry.Version != cacheVersion || entry.LastCommit != lastCommit {
+		_ = c.Remove(id)
+		return nil, false
+	}
+
+	idLength, err := HumanIdLength(c.repo)
+	if err != nil {
+		return nil, false
+	}
+
+	bug := &Bug{
+		id:            id,
+		humanIdLength: idLength,
+		lastCommit:    entry.LastCommit,
+		rootPack:      entry.RootPack,
+		createTime:    entry.CreateTime,
+		editTime:      entry.EditTime,
+	}
+
+	for _, cached := range entry.Packs {
+		pack := OperationPack{
+			Operations:  cached.Operations,
+			EditTimes:   cached.EditTimes,
+			commitHash:  cached.CommitHash,
+			editTime:    cached.EditTime,
+			unsupported: cached.Unsupported,
+		}
+		pack.bindPayloads(c.repo)
+		bug.packs = append(bug.packs, pack)
+	}
+
+	// as if the bug was read from the repository
+	if err := c.repo.CreateWitness(bug.createTime); err != nil {
+		return nil, false
+	}
+	if err := c.repo.EditWitness(bug.editTime); err != nil {
+		return nil, false
+	}
+
+	return bug, true
+}
+
+// Put store a committed bug in the cache, replacing the previous entry
+func (c *Cache) Put(bug *Bug) error {
+	if bug.id == "" || bug.lastCommit == "" {
+		return errors.New("can't cache a bug that has never been stored")
+	}
+
+	entry := cacheEntry{
+		Version:    cacheVersion,
+		LastCommit: bug.lastCommit,
+		RootPack:   bug.rootPack,
+		CreateTime: bug.createTime,
+		EditTime:   bug.editTime,
+	}
+
+	for _, pack := range bug.packs {
+		entry.Packs = append(entry.Packs, cachedPack{
+			Operations:  pack.Operations,
+			EditTimes:   pack.EditTimes,
+			CommitHash:  pack.commitHash,
+			EditTime:    pack.editTime,
+			Unsupported: pack.unsupported,
+		})
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(c.dir, 0777)
+	if err != nil {
+		return err
+	}
+
+	// write then rename so that a concurrent read never see a partial entry
+	tmp, err := ioutil.TempFile(c.dir, bug.id+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path.Join(c.dir, bug.id))
+}
+
+// Remove remove the entry of a bug, if any
+func (c *Cache) Remove(id string) error {
+	err := os.Remove(path.Join(c.dir, id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Clear remove every entry of the cache
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// readCachedBug read a local bug through the cache of the repository, if it
+// has one
+func readCachedBug(repo repository.Repo, ref string) (*Bug, error) {
+	cache, err := NewCache(repo)
+	if err != nil {
+		return readBug(repo, ref)
+	}
+
+	ref, err = repo.ResolveSymbolicRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if bug, ok := cache.Get(path.Base(ref), head); ok {
+		return bug, nil
+	}
+
+	bug, err := readBug(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is only an optimization, failing to write it is harmless
+	_ = cache.Put(bug)
+
+	return bug, nil
+}
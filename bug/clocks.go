@@ -1,9 +1,49 @@
 package bug
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ClockStorage define how the logical clocks are stored in the git trees
+type ClockStorage int
+
+const (
+	// The clock value is serialized in the name of an entry pointing to an
+	// empty blob. This avoid having one blob for each clock value.
+	ClockInEntryName ClockStorage = iota
+	// The clock value is the content of a blob with a fixed entry name. This
+	// is more robust than parsing the entry name, but create one blob for
+	// each clock value.
+	ClockInBlob
 )
 
+// The storage used when writing new commits. Both are supported when reading.
+var (
+	clockStorageMutex sync.RWMutex
+	clockStorage      = ClockInEntryName
+)
+
+// SetClockStorage define how the logical clocks are stored in new commits. It
+// apply to every repository of the process and can be called concurrently
+// with the commits.
+func SetClockStorage(storage ClockStorage) {
+	clockStorageMutex.Lock()
+	defer clockStorageMutex.Unlock()
+
+	clockStorage = storage
+}
+
+func getClockStorage() ClockStorage {
+	clockStorageMutex.RLock()
+	defer clockStorageMutex.RUnlock()
+
+	return clockStorage
+}
+
 // Witnesser will read all the available Bug to recreate the different logical
 // clocks
 func Witnesser(repo *repository.GitRepo) error {
@@ -18,3 +58,61 @@ func Witnesser(repo *repository.GitRepo) error {
 
 	return nil
 }
+
+// ReadBugClocks read the logical clocks of a local bug from the tree entries
+// of its first and last commits only, to sort the bugs without reading them
+func ReadBugClocks(repo repository.Repo, id string) (create, edit util.LamportTime, err error) {
+	head, err := ReadBugHead(repo, id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return head.CreateTime, head.EditTime, nil
+}
+
+// makeClockEntry create the tree entry storing a clock value, according to the
+// configured ClockStorage
+func makeClockEntry(repo repository.Repo, pattern string, name string, time util.LamportTime) (repository.TreeEntry, error) {
+	if getClockStorage() == ClockInBlob {
+		hash, err := repo.StoreData([]byte(fmt.Sprintf("%d", time)))
+		if err != nil {
+			return repository.TreeEntry{}, err
+		}
+
+		return repository.TreeEntry{
+			ObjectType: repository.Blob,
+			Hash:       hash,
+			Name:       name,
+		}, nil
+	}
+
+	emptyBlobHash, err := repo.StoreData([]byte{})
+	if err != nil {
+		return repository.TreeEntry{}, err
+	}
+
+	return repository.TreeEntry{
+		ObjectType: repository.Blob,
+		Hash:       emptyBlobHash,
+		Name:       fmt.Sprintf(pattern, time),
+	}, nil
+}
+
+// readClockBlob read a clock value stored as the content of a blob
+func readClockBlob(repo repository.Repo, hash util.Hash) (uint64, error) {
+	data, err := repo.ReadData(hash)
+	if err != nil {
+		return 0, err
+	}
+
+	var value uint64
+	n, err := fmt.Sscanf(string(data), "%d", &value)
+	if err != nil {
+		return 0, err
+	}
+	if n != 1 {
+		return 0, fmt.Errorf("could not parse lamport value")
+	}
+
+	return value, nil
+}
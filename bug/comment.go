@@ -1,20 +1,47 @@
 package bug
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/MichaelMure/git-bug/util"
 	"github.com/dustin/go-humanize"
-	"time"
 )
 
 // Comment represent a comment in a Bug
 type Comment struct {
-	Author  Person
-	Message string
-	Files   []util.Hash
+	// Hash of the operation that created the comment, set during Compile
+	Id util.Hash
+	// Optional id of the comment this one is a response to
+	InReplyTo util.Hash
+
+	Author     Person
+	Message    string
+	Files      []util.Hash
+	CodeBlocks []CodeBlock
+	// Thumbnail of the image files, keyed by the hash of the file
+	Thumbnails map[util.Hash]util.Hash
 
 	// Creation time of the comment.
 	// Should be used only for human display, never for ordering as we can't rely on it in a distributed system.
 	UnixTime int64
+
+	// Every version of the message, from the original to the current one.
+	// Empty if the comment has never been edited.
+	EditHistory []CommentVersion
+}
+
+// CommentVersion is one version of the message of an edited comment
+type CommentVersion struct {
+	Message  string
+	UnixTime int64
+}
+
+// CodeBlock is a snippet of code or a diff attached to a comment. The
+// language is free-form and only used as a hint for syntax highlighting.
+type CodeBlock struct {
+	Language string
+	Content  string
 }
 
 // FormatTime format the UnixTime of the comment for human consumption
@@ -22,3 +49,39 @@ func (c Comment) FormatTime() string {
 	t := time.Unix(c.UnixTime, 0)
 	return humanize.Time(t)
 }
+
+// CommentNode is a comment and its replies, as part of a tree of comments
+type CommentNode struct {
+	Comment Comment
+	Replies []*CommentNode
+}
+
+// buildCommentTree arrange a flat list of comments into a tree according to
+// their InReplyTo. A reply to an unknown comment is kept at the top-level and
+// reported as a warning.
+func buildCommentTree(comments []Comment) ([]*CommentNode, []string) {
+	var roots []*CommentNode
+	var warnings []string
+	nodes := make(map[util.Hash]*CommentNode)
+
+	for _, comment := range comments {
+		node := &CommentNode{Comment: comment}
+
+		if comment.InReplyTo != "" {
+			if parent, ok := nodes[comment.InReplyTo]; ok {
+				parent.Replies = append(parent.Replies, node)
+			} else {
+				warnings = append(warnings, fmt.Sprintf("comment %s is a reply to an unknown comment %s", comment.Id, comment.InReplyTo))
+				roots = append(roots, node)
+			}
+		} else {
+			roots = append(roots, node)
+		}
+
+		if comment.Id != "" {
+			nodes[comment.Id] = node
+		}
+	}
+
+	return roots, warnings
+}
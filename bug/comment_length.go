@@ -0,0 +1,64 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrCommentTooLong = errors.New("comment too long")
+
+// The limit on the length in bytes of a comment. Zero means no limit.
+var (
+	commentLengthMutex  sync.RWMutex
+	maxCommentLength    = 0
+	strictCommentLength = false
+)
+
+// CommentOperation is implemented by the operations carrying the body of a
+// comment
+type CommentOperation interface {
+	// CommentBody return the body of the comment
+	CommentBody() string
+}
+
+// SetCommentLengthLimit define the maximum length in bytes of a comment. If
+// strict, a longer comment is rejected when appended to a bug, otherwise it's
+// only reported in the warnings of the snapshot. Zero means no limit. It apply
+// to every repository of the process and can be called concurrently with the
+// other functions of this package.
+func SetCommentLengthLimit(maxLength int, strict bool) {
+	commentLengthMutex.Lock()
+	defer commentLengthMutex.Unlock()
+
+	maxCommentLength = maxLength
+	strictCommentLength = strict
+}
+
+func getCommentLengthLimit() (int, bool) {
+	commentLengthMutex.RLock()
+	defer commentLengthMutex.RUnlock()
+
+	return maxCommentLength, strictCommentLength
+}
+
+// checkCommentLength return an error if the operation carry a comment longer
+// than the given limit
+func checkCommentLength(op Operation, maxLength int) error {
+	if maxLength == 0 {
+		return nil
+	}
+
+	commentOp, ok := op.(CommentOperation)
+	if !ok {
+		return nil
+	}
+
+	length := len(commentOp.CommentBody())
+	if length > maxLength {
+		return fmt.Errorf("%w: %d bytes, the maximum is %d, consider attaching a file instead",
+			ErrCommentTooLong, length, maxLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package bug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// CommitAll commit the staging area of several bugs at once, for example for
+// a bulk import. The repository lock is only taken once, and the identical
+// objects written for each bug, like the empty blob of the clock and status
+// entries or a shared media tree, are only written once. The bugs without
+// pending operation are skipped.
+//
+// The bugs are committed in order. On failure, the bugs already committed
+// stay so and the returned error tell which bug failed.
+func CommitAll(repo repository.Repo, bugs []*Bug) error {
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	batch := newBatchRepo(repo)
+
+	var committed []*Bug
+
+	for i, b := range bugs {
+		if !b.HasPendingOp() {
+			continue
+		}
+
+		err := b.commitLocked(batch)
+		if err != nil {
+			unlock()
+			notifyAll(committed)
+
+			// a new bug has no id yet
+			name := b.id
+			if name == "" {
+				name = "new bug"
+			}
+			return fmt.Errorf("commit of bug %d (%s) failed: %w", i, name, err)
+		}
+
+		committed = append(committed, b)
+	}
+
+	unlock()
+	notifyAll(committed)
+
+	return nil
+}
+
+func notifyAll(bugs []*Bug) {
+	for _, b := range bugs {
+		b.notifyObservers(b.packs[len(b.packs)-1].Operations)
+	}
+}
+
+// batchRepoMaxBlobSize is the size up to which a blob written by a batch is
+// remembered. This covers the clock and status entries, not the operations.
+const batchRepoMaxBlobSize = 64
+
+// batchRepo remember the small blobs and the trees written through it, to
+// not write them again
+type batchRepo struct {
+	repository.Repo
+	blobs map[string]util.Hash
+	trees map[string]util.Hash
+}
+
+func newBatchRepo(repo repository.Repo) *batchRepo {
+	return &batchRepo{
+		Repo:  repo,
+		blobs: make(map[string]util.Hash),
+		trees: make(map[string]util.Hash),
+	}
+}
+
+func (r *batchRepo) StoreData(data []byte) (util.Hash, error) {
+	if len(data) > batchRepoMaxBlobSize {
+		return r.Repo.StoreData(data)
+	}
+
+	if hash, ok := r.blobs[string(data)]; ok {
+		return hash, nil
+	}
+
+	hash, err := r.Repo.StoreData(data)
+	if err != nil {
+		return "", err
+	}
+
+	r.blobs[string(data)] = hash
+
+	return hash, nil
+}
+
+func (r *batchRepo) StoreTree(entries []repository.TreeEntry) (util.Hash, error) {
+	var key strings.Builder
+	for _, entry := range entries {
+		key.WriteString(entry.Format())
+	}
+
+	if hash, ok := r.trees[key.String()]; ok {
+		return hash, nil
+	}
+
+	hash, err := r.Repo.StoreTree(entries)
+	if err != nil {
+		return "", err
+	}
+
+	r.trees[key.String()] = hash
+
+	return hash, nil
+}
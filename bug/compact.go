@@ -0,0 +1,141 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// Compact rewrite the history of a bug into a single commit on top of the
+// first one, so that reading it doesn't require to go through a long chain of
+// small commits. The first commit, holding the CreateOp, is kept as is so the
+// id of the bug doesn't change. The create and edit clocks, as well as the edit
+// time of each operation, are preserved so the compiled bug is the same, at
+// the exception of the ids of the operations as they depend on the commits.
+//
+// As the history is rewritten, only a bug that has never been shared with a
+// remote and without pending operation can be compacted. The previous commits
+// become unreachable, see ListPrunableBugObjects.
+func (bug *Bug) Compact(repo repository.Repo) error {
+	if bug.lastCommit == "" {
+		return errors.New("can't compact a bug that has never been stored")
+	}
+
+	if bug.HasPendingOp() {
+		return errors.New("can't compact a bug with pending operations")
+	}
+
+	// rewriting data we don't understand could corrupt it
+	if bug.ReadOnly() {
+		return ErrUnsupportedFormatVersion
+	}
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	exist, err := repo.RefExist(stagingRefPattern + bug.id)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return errors.New("can't compact a bug with a persisted staging area")
+	}
+
+	err = checkNotOnRemote(repo, bug.id)
+	if err != nil {
+		return err
+	}
+
+	ref := bugsRefPattern + bug.id
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		return err
+	}
+	if head != bug.lastCommit {
+		return fmt.Errorf("%w: %s", ErrStaleBug, bug.HumanId())
+	}
+
+	if len(bug.packs) <= 2 {
+		// nothing to gain
+		return nil
+	}
+
+	compacted := OperationPack{}
+	for _, pack := range bug.packs[1:] {
+		for i, op := range pack.Operations {
+			compacted.Operations = append(compacted.Operations, op)
+			compacted.EditTimes = append(compacted.EditTimes, pack.opEditTime(i))
+		}
+	}
+
+	toWrite, err := compacted.externalizePayloads(repo)
+	if err != nil {
+		return err
+	}
+
+	hash, err := toWrite.Write(repo)
+	if err != nil {
+		return err
+	}
+
+	tree := []repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: hash, Name: opsEntryName},
+		{ObjectType: repository.Blob, Hash: bug.rootPack, Name: rootEntryName},
+	}
+
+	mediaTree := makeMediaTree(toWrite)
+	if len(mediaTree) > 0 {
+		mediaTreeHash, err := repo.StoreTree(mediaTree)
+		if err != nil {
+			return err
+		}
+		tree = append(tree, repository.TreeEntry{
+			ObjectType: repository.Tree,
+			Hash:       mediaTreeHash,
+			Name:       mediaEntryName,
+		})
+	}
+
+	// the edit clock of the head is kept, not incremented
+	editClockEntry, err := makeClockEntry(repo, editClockEntryPattern, editClockEntryName, bug.editTime)
+	if err != nil {
+		return err
+	}
+	tree = append(tree, editClockEntry)
+
+	statusEntry, err := makeStatusEntry(repo, bug.Compile().Status)
+	if err != nil {
+		return err
+	}
+	tree = append(tree, statusEntry)
+
+	treeHash, err := repo.StoreTree(tree)
+	if err != nil {
+		return err
+	}
+
+	root := bug.packs[0]
+
+	hash, err = repo.StoreCommitWithParent(treeHash, root.commitHash)
+	if err != nil {
+		return err
+	}
+
+	err = bug.updateRef(repo, bug.lastCommit, hash)
+	if err != nil {
+		return err
+	}
+
+	compacted.commitHash = hash
+	compacted.editTime = bug.editTime
+
+	bug.lastCommit = hash
+	bug.packs = []OperationPack{root, compacted}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package bug
+
+import (
+	"sort"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// RepoDiff hold the differences of bug data between two repositories
+type RepoDiff struct {
+	// Ids of the bugs only present in the first repository
+	OnlyInA []string
+	// Ids of the bugs only present in the second repository
+	OnlyInB []string
+	// Ids of the bugs present in both repositories but with a different history
+	Divergent []string
+}
+
+// InSync tell if both repositories hold the exact same bug data
+func (diff RepoDiff) InSync() bool {
+	return len(diff.OnlyInA) == 0 && len(diff.OnlyInB) == 0 && len(diff.Divergent) == 0
+}
+
+// CompareRepos compare the local bugs of two repositories, for example to
+// verify that a mirror or a backup is in sync. As the history of a bug is a
+// chain of git commits, two bugs are identical if they have the same head
+// commit.
+func CompareRepos(a, b repository.Repo) (RepoDiff, error) {
+	var diff RepoDiff
+
+	headsA, err := listHeads(a)
+	if err != nil {
+		return RepoDiff{}, err
+	}
+
+	headsB, err := listHeads(b)
+	if err != nil {
+		return RepoDiff{}, err
+	}
+
+	for id, headA := range headsA {
+		headB, ok := headsB[id]
+
+		switch {
+		case !ok:
+			diff.OnlyInA = append(diff.OnlyInA, id)
+		case headA != headB:
+			diff.Divergent = append(diff.Divergent, id)
+		}
+	}
+
+	for id := range headsB {
+		if _, ok := headsA[id]; !ok {
+			diff.OnlyInB = append(diff.OnlyInB, id)
+		}
+	}
+
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+	sort.Strings(diff.Divergent)
+
+	return diff, nil
+}
+
+// listHeads return the head commit of each local bug, by id
+func listHeads(repo repository.Repo) (map[string]string, error) {
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(map[string]string, len(ids))
+
+	for _, id := range ids {
+		head, err := repo.ResolveRef(bugsRefPattern + id)
+		if err != nil {
+			return nil, err
+		}
+
+		heads[id] = string(head)
+	}
+
+	return heads, nil
+}
@@ -0,0 +1,79 @@
+package bug
+
+import (
+	"context"
+)
+
+// SnapshotDelta is one step of CompileStream
+type SnapshotDelta struct {
+	// Index of the operation applied at this step, in the order of Compile
+	Index int
+	// The operation applied at this step, nil for the final step
+	Operation Operation
+	// The snapshot once the operation is applied
+	Snapshot Snapshot
+	// Final is set on the last step only, after all the operations. Its
+	// snapshot is identical to what Compile produce.
+	Final bool
+}
+
+// CompileStream apply the operations of a bug one by one and send the
+// evolving snapshot after each of them, so that a consumer can render a long
+// history progressively or stop early by cancelling the context. A last step
+// with the complete snapshot is sent once all the operations are applied.
+//
+// The channel is closed after the last step, or as soon as the context is
+// cancelled.
+func (bug *Bug) CompileStream(ctx context.Context) <-chan SnapshotDelta {
+	out := make(chan SnapshotDelta)
+
+	go func() {
+		defer close(out)
+
+		snap := Snapshot{
+			id:            bug.id,
+			humanIdLength: bug.humanIdLength,
+			opCounts:      make(map[OperationType]int),
+		}
+
+		it := NewOperationIterator(bug)
+
+		for index := 0; it.Next(); index++ {
+			if ctx.Err() != nil {
+				return
+			}
+
+			op := it.Value()
+
+			// the counts are updated in place, each step get its own copy to
+			// not be altered by the following ones
+			snap.opCounts = copyOpCounts(snap.opCounts)
+			snap = snap.apply(op, it.editTime(), it.operationId())
+
+			select {
+			case out <- SnapshotDelta{Index: index, Operation: op, Snapshot: snap}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		tree, warnings := buildCommentTree(snap.Comments)
+		snap.CommentTree = tree
+		snap.Warnings = append(snap.Warnings, warnings...)
+
+		select {
+		case out <- SnapshotDelta{Index: len(snap.Operations), Snapshot: snap, Final: true}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+func copyOpCounts(counts map[OperationType]int) map[OperationType]int {
+	result := make(map[OperationType]int, len(counts)+1)
+	for opType, count := range counts {
+		result[opType] = count
+	}
+	return result
+}
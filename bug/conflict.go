@@ -0,0 +1,96 @@
+package bug
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The operations setting a single value of a bug. Two of them of the same type
+// made concurrently on both sides of a merge conflict if they set a different
+// value.
+var conflictingOpTypes = map[OperationType]bool{
+	SetTitleOp:    true,
+	SetStatusOp:   true,
+	SetAssigneeOp: true,
+}
+
+// OperationConflict is a pair of operations made concurrently on both sides of
+// a merge, that set the same value of a bug differently
+type OperationConflict struct {
+	// The operation of the local version
+	Ours Operation
+	// The operation of the merged version
+	Theirs Operation
+	// The conflict has been resolved by the order of the operations. Our
+	// operations being rebased on top of theirs, ours win.
+	AutoResolved bool
+}
+
+func (c OperationConflict) String() string {
+	status := "unresolved"
+	if c.AutoResolved {
+		status = "resolved by keeping ours"
+	}
+	return fmt.Sprintf("concurrent %s, %s", c.Ours.OpType(), status)
+}
+
+// findConflicts return the conflicting operations made on both sides after the
+// common ancestor, compiled as base
+func findConflicts(base Snapshot, ours []Operation, theirs []Operation) []OperationConflict {
+	var conflicts []OperationConflict
+
+	for _, our := range ours {
+		if !conflictingOpTypes[our.OpType()] {
+			continue
+		}
+
+		for _, their := range theirs {
+			if their.OpType() != our.OpType() {
+				continue
+			}
+
+			// setting the same value is not a conflict
+			if reflect.DeepEqual(our.Apply(base), their.Apply(base)) {
+				continue
+			}
+
+			conflicts = append(conflicts, OperationConflict{
+				Ours:         our,
+				Theirs:       their,
+				AutoResolved: true,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+func packsOperations(packs []OperationPack) []Operation {
+	var ops []Operation
+	for _, pack := range packs {
+		ops = append(ops, pack.Operations...)
+	}
+	return ops
+}
+
+// splitPacks split the packs of a bug between the ones reachable from the
+// given commit and the ones after it
+func splitPacks(repo repository.Repo, packs []OperationPack, ancestor util.Hash) (before []OperationPack, after []OperationPack, err error) {
+	reachable, err := repo.ListCommitParents(string(ancestor))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pack := range packs {
+		if _, ok := reachable[pack.commitHash]; ok {
+			before = append(before, pack)
+		} else {
+			after = append(after, pack)
+		}
+	}
+
+	return before, after, nil
+}
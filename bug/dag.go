@@ -0,0 +1,224 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The formats of the history of the bugs. A linear history is a chain of
+// commits that every client can read. A DAG history can have merge commits,
+// see MergeDAG, that the clients older than it reject.
+const (
+	HistoryFormatLinear = 1
+	HistoryFormatDAG    = 2
+)
+
+var (
+	historyFormatMutex   sync.RWMutex
+	historyFormatVersion = HistoryFormatLinear
+)
+
+// ErrLinearHistory is returned by MergeDAG when a merge commit is needed but
+// the DAG history format is not enabled
+var ErrLinearHistory = errors.New("merge commits need the DAG history format")
+
+// SetHistoryFormatVersion define the format of the history written. As a
+// pushed merge commit make the bug unreadable for the clients not aware of
+// them, HistoryFormatDAG should only be enabled once every client sharing
+// the bugs support it. It apply to every repository of the process and can be
+// called concurrently with the merges.
+func SetHistoryFormatVersion(version int) error {
+	if version != HistoryFormatLinear && version != HistoryFormatDAG {
+		return fmt.Errorf("unknown history format version %d", version)
+	}
+
+	historyFormatMutex.Lock()
+	defer historyFormatMutex.Unlock()
+
+	historyFormatVersion = version
+
+	return nil
+}
+
+func getHistoryFormatVersion() int {
+	historyFormatMutex.RLock()
+	defer historyFormatMutex.RUnlock()
+
+	return historyFormatVersion
+}
+
+// linearizeCommits order the commits of a bug history from the oldest to the
+// newest. For a linear chain of commits, this is simply the chronological
+// order. When the history contains merge commits, the first parent's history
+// comes first, then the commits only reachable from the second parent, then
+// the merge commit itself. This way the resulting order is the same for every
+// reader of the same history.
+func linearizeCommits(head util.Hash, parents map[util.Hash][]util.Hash) []util.Hash {
+	type frame struct {
+		hash util.Hash
+		next int
+	}
+
+	var result []util.Hash
+	visited := map[util.Hash]bool{head: true}
+	stack := []frame{{hash: head}}
+
+	// iterative post-order traversal, to not blow the stack on long histories
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		commitParents := parents[top.hash]
+
+		if top.next < len(commitParents) {
+			parent := commitParents[top.next]
+			top.next++
+
+			if !visited[parent] {
+				visited[parent] = true
+				stack = append(stack, frame{hash: parent})
+			}
+			continue
+		}
+
+		result = append(result, top.hash)
+		stack = stack[:len(stack)-1]
+	}
+
+	return result
+}
+
+// MergeDAG merge a different version of the same bug, like Merge, but instead
+// of rebasing our extra operations on top of the other version, it create a
+// merge commit with both heads as parents. This way, the commits of both side
+// are preserved as is.
+//
+// This is an optional format: the history of the bug become a DAG instead of
+// a linear chain of commits, and can only be read by a client aware of merge
+// commits. Unless enabled with SetHistoryFormatVersion, ErrLinearHistory is
+// returned instead of creating a merge commit. Merge should not be used on the
+// same bug afterward.
+func (bug *Bug) MergeDAG(repo repository.Repo, other *Bug) (bool, error) {
+	if bug.id != other.id {
+		return false, errors.New("merging unrelated bugs is not supported")
+	}
+
+	if len(other.staging.Operations) > 0 {
+		return false, errors.New("merging a bug with a non-empty staging is not supported")
+	}
+
+	if bug.lastCommit == "" || other.lastCommit == "" {
+		return false, errors.New("can't merge a bug that has never been stored")
+	}
+
+	if bug.rootPack != other.rootPack {
+		return false, ErrDivergentRoot
+	}
+
+	if bug.ReadOnly() || other.ReadOnly() {
+		return false, ErrUnsupportedFormatVersion
+	}
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	ancestor, err := repo.FindCommonAncestor(bug.lastCommit, other.lastCommit)
+	if err != nil {
+		return false, err
+	}
+
+	// the other version is behind or identical, nothing to do
+	if ancestor == other.lastCommit {
+		return false, nil
+	}
+
+	ours := make(map[util.Hash]bool)
+	for _, pack := range bug.packs {
+		ours[pack.commitHash] = true
+	}
+
+	// we are behind, simply fast-forward
+	if ancestor == bug.lastCommit {
+		err = bug.updateRef(repo, bug.lastCommit, other.lastCommit)
+		if err != nil {
+			return false, err
+		}
+
+		for _, pack := range other.packs {
+			if !ours[pack.commitHash] {
+				bug.packs = append(bug.packs, pack.Clone())
+			}
+		}
+
+		bug.lastCommit = other.lastCommit
+		bug.editTime = other.editTime
+
+		return true, nil
+	}
+
+	if getHistoryFormatVersion() < HistoryFormatDAG {
+		return false, ErrLinearHistory
+	}
+
+	// Both side have diverged, create a merge commit. The other version is the
+	// first parent so that its operations come first, like with a rebase.
+	theirs := make(map[util.Hash]bool)
+	newPacks := make([]OperationPack, 0, len(bug.packs)+len(other.packs))
+
+	for _, pack := range other.packs {
+		theirs[pack.commitHash] = true
+		newPacks = append(newPacks, pack.Clone())
+	}
+
+	for _, pack := range bug.packs {
+		if !theirs[pack.commitHash] {
+			newPacks = append(newPacks, pack)
+		}
+	}
+
+	editTime, err := repo.EditTimeIncrement()
+	if err != nil {
+		return false, err
+	}
+
+	editClockEntry, err := makeClockEntry(repo, editClockEntryPattern, editClockEntryName, editTime)
+	if err != nil {
+		return false, err
+	}
+
+	merged := Bug{packs: newPacks}
+	statusEntry, err := makeStatusEntry(repo, merged.Compile().Status)
+	if err != nil {
+		return false, err
+	}
+
+	treeHash, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: bug.rootPack, Name: rootEntryName},
+		editClockEntry,
+		statusEntry,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := repo.StoreMergeCommit(treeHash, other.lastCommit, bug.lastCommit)
+	if err != nil {
+		return false, err
+	}
+
+	err = bug.updateRef(repo, bug.lastCommit, hash)
+	if err != nil {
+		return false, err
+	}
+
+	bug.packs = newPacks
+	bug.lastCommit = hash
+	bug.editTime = editTime
+
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package bug
+
+import (
+	"sort"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// Graph is the directed graph of the dependencies between bugs
+type Graph struct {
+	// for each bug id, the ids of the bugs it depends on
+	dependsOn map[string][]string
+	// for each bug id, the ids of the bugs it blocks
+	blocks map[string][]string
+}
+
+// DependencyGraph compile all the local bugs and build the graph of their
+// dependencies
+func DependencyGraph(repo repository.Repo) (Graph, error) {
+	graph := Graph{
+		dependsOn: make(map[string][]string),
+		blocks:    make(map[string][]string),
+	}
+
+	for streamed := range ReadAllLocalBugs(repo) {
+		if streamed.Err != nil {
+			return Graph{}, streamed.Err
+		}
+
+		snap := streamed.Bug.Compile()
+
+		for _, target := range snap.DependsOn {
+			graph.dependsOn[snap.id] = append(graph.dependsOn[snap.id], target)
+			graph.blocks[target] = append(graph.blocks[target], snap.id)
+		}
+	}
+
+	return graph, nil
+}
+
+// DependsOn return the ids of the bugs a bug depends on, that is the bugs
+// blocking it
+func (g Graph) DependsOn(id string) []string {
+	return g.dependsOn[id]
+}
+
+// Blocks return the ids of the bugs depending on a bug
+func (g Graph) Blocks(id string) []string {
+	return g.blocks[id]
+}
+
+// Cycles return the circular dependencies of the graph, each as the list of
+// the bug ids involved
+func (g Graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = inProgress
+		stack = append(stack, id)
+
+		for _, next := range g.dependsOn[id] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case inProgress:
+				// the cycle is the part of the stack starting at next
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := make([]string, len(stack)-i)
+						copy(cycle, stack[i:])
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	// visit in a stable order for a deterministic result
+	ids := make([]string, 0, len(g.dependsOn))
+	for id := range g.dependsOn {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	return cycles
+}
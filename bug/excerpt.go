@@ -0,0 +1,131 @@
+package bug
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// BugExcerpt is a lightweight summary of a bug, enough for a list view
+type BugExcerpt struct {
+	Id         string
+	CreateTime util.LamportTime
+	EditTime   util.LamportTime
+	Author     Person
+	CreatedAt  time.Time
+	Title      string
+	Status     Status
+}
+
+// ReadLocalBugHeader read a local bug only as much as needed to build its
+// excerpt: the first pack for the create operation, then the packs from the
+// most recent one until the latest title and status are found. As the status
+// is recorded with each commit, in most cases only the packs after the last
+// title change are read.
+func ReadLocalBugHeader(repo repository.Repo, id string) (*BugExcerpt, error) {
+	ref, err := repo.ResolveSymbolicRef(bugsRefPattern + id)
+	if err != nil {
+		return nil, err
+	}
+
+	id, head, parents, err := readBugRef(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkId(id); err != nil {
+		return nil, err
+	}
+
+	hashes := linearizeCommits(head, parents)
+
+	rootTree, err := readCommitTree(repo, hashes[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !rootTree.opsFound {
+		return nil, errors.New("Invalid tree, missing the ops entry")
+	}
+
+	rootPack, err := readPack(repo, rootTree.opsEntry.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootPack.Operations) == 0 || rootPack.Operations[0].OpType() != CreateOp {
+		return nil, errors.New("the first operation is not a create operation")
+	}
+
+	// the create operation establish the initial title and status
+	snap := rootPack.Operations[0].Apply(Snapshot{id: id})
+
+	headEntries, err := repo.ListEntries(head)
+	if err != nil {
+		return nil, err
+	}
+
+	// the status entry is missing from the commits predating it
+	_, editTime, status, err := readHeadEntries(repo, headEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	excerpt := &BugExcerpt{
+		Id:         id,
+		CreateTime: util.LamportTime(rootTree.createTime),
+		EditTime:   editTime,
+		Author:     snap.Author,
+		CreatedAt:  snap.CreatedAt,
+		Title:      snap.Title,
+		Status:     snap.Status,
+	}
+
+	titleFound := false
+	statusFound := status != 0
+	if statusFound {
+		excerpt.Status = status
+	}
+
+	// walk the history backward, stopping as soon as we know enough
+	for i := len(hashes) - 1; i > 0 && !(titleFound && statusFound); i-- {
+		tree, err := readCommitTree(repo, hashes[i])
+		if err != nil {
+			return nil, err
+		}
+
+		// merge and touch commits don't carry any operation
+		if !tree.opsFound {
+			continue
+		}
+
+		pack, err := readPack(repo, tree.opsEntry.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := len(pack.Operations) - 1; j >= 0; j-- {
+			op := pack.Operations[j]
+
+			switch {
+			case !titleFound && op.OpType() == SetTitleOp:
+				excerpt.Title = op.Apply(Snapshot{}).Title
+				titleFound = true
+			case !statusFound && op.OpType() == SetStatusOp:
+				excerpt.Status = op.Apply(Snapshot{}).Status
+				statusFound = true
+			}
+		}
+	}
+
+	if err := repo.CreateWitness(excerpt.CreateTime); err != nil {
+		return nil, err
+	}
+	if err := repo.EditWitness(excerpt.EditTime); err != nil {
+		return nil, err
+	}
+
+	return excerpt, nil
+}
@@ -0,0 +1,71 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+type ExternalRefKind string
+
+const (
+	// A git commit of the repository
+	ExternalRefCommit ExternalRefKind = "commit"
+	// Any URL
+	ExternalRefURL ExternalRefKind = "url"
+)
+
+type ExternalRefStatus int
+
+const (
+	// The reference has not been verified
+	ExternalRefUnverified ExternalRefStatus = iota
+	// The reference point to something that exist
+	ExternalRefResolved
+	// The reference point to something that doesn't exist
+	ExternalRefUnresolved
+)
+
+func (s ExternalRefStatus) String() string {
+	switch s {
+	case ExternalRefUnverified:
+		return "unverified"
+	case ExternalRefResolved:
+		return "resolved"
+	case ExternalRefUnresolved:
+		return "unresolved"
+	default:
+		return "unknown status"
+	}
+}
+
+// ExternalRef is a soft reference from a bug to something outside of it
+type ExternalRef struct {
+	Kind   ExternalRefKind
+	Target string
+	Status ExternalRefStatus
+}
+
+// VerifyExternalRefs check that the commits referenced by the bug exist in
+// the repository and mark them as resolved or unresolved accordingly, so
+// that a UI can link the valid ones and flag the missing ones. Other kinds of
+// reference are left unverified.
+func (snap *Snapshot) VerifyExternalRefs(repo repository.Repo) error {
+	for i, ref := range snap.ExternalRefs {
+		if ref.Kind != ExternalRefCommit {
+			continue
+		}
+
+		exist, err := repo.CommitExist(util.Hash(ref.Target))
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			snap.ExternalRefs[i].Status = ExternalRefResolved
+		} else {
+			snap.ExternalRefs[i].Status = ExternalRefUnresolved
+		}
+	}
+
+	return nil
+}
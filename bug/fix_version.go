@@ -0,0 +1,29 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// ListFixedInVersion return the ids of the local bugs fixed in a version, in
+// the canonical order
+func ListFixedInVersion(repo repository.Repo, version string) ([]string, error) {
+	ids, err := SortedLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, id := range ids {
+		b, err := ReadLocalBug(repo, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if b.Compile().FixVersion == version {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package bug
+
+import (
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// FsckProblem is a problem found in a bug by Fsck
+type FsckProblem struct {
+	// Id of the bug
+	Id          string
+	Description string
+}
+
+func (p FsckProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Id, p.Description)
+}
+
+// Fsck check the consistency of all the local bugs: that they can be read,
+// that they are valid and that the media their operations reference are in
+// the repository. The bugs are read from the repository, never from the
+// cache. Every problem found is reported, a broken bug doesn't stop the check
+// of the others. An error is returned only if the bugs can't be listed.
+func Fsck(repo repository.Repo) ([]FsckProblem, error) {
+	// ReadAllLocalBugs stop at the first bug that can't be read, so the bugs
+	// are read one by one instead
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []FsckProblem
+
+	for _, id := range ids {
+		problems = append(problems, fsckBug(repo, id)...)
+	}
+
+	return problems, nil
+}
+
+func fsckBug(repo repository.Repo, id string) []FsckProblem {
+	// reading the bug also check the media of each commit
+	b, err := readBug(repo, bugsRefPattern+id)
+	if err != nil {
+		return []FsckProblem{{Id: id, Description: err.Error()}}
+	}
+
+	if err := b.Validate(); err != nil {
+		return []FsckProblem{{Id: id, Description: err.Error()}}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package bug
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The status of the bug after each commit is stored in the name of an entry
+// pointing to an empty blob, so that it can be known without reading the
+// operations. The status is stored by name, the first commits having it
+// stored by value are still read.
+const statusEntryPrefix = "status-"
+const statusEntryPattern = "status-%d"
+
+var statusEntryNames = map[Status]string{
+	OpenStatus:       "open",
+	ClosedStatus:     "closed",
+	InProgressStatus: "in-progress",
+}
+
+// BugHead is the metadata of a bug that can be read without reading and
+// parsing its operations
+type BugHead struct {
+	Id         string
+	CreateTime util.LamportTime
+	EditTime   util.LamportTime
+	// The status as recorded by the last commit. It's zero if the last commit
+	// predate the status entry.
+	Status Status
+}
+
+// ReadBugHead read the metadata of a local bug from the tree entries of its
+// first and last commits only. This is the cheapest possible read of a bug,
+// for example for a list view.
+func ReadBugHead(repo repository.Repo, id string) (BugHead, error) {
+	// an aliased ref can be a symbolic one, the id is the one of the target
+	ref, err := repo.ResolveSymbolicRef(bugsRefPattern + id)
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	id = path.Base(ref)
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	// the id is the hash of the first commit
+	rootEntries, err := repo.ListEntries(util.Hash(id))
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	createTime, _, _, err := readHeadEntries(repo, rootEntries)
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	headEntries, err := repo.ListEntries(head)
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	_, editTime, status, err := readHeadEntries(repo, headEntries)
+	if err != nil {
+		return BugHead{}, err
+	}
+
+	return BugHead{
+		Id:         id,
+		CreateTime: createTime,
+		EditTime:   editTime,
+		Status:     status,
+	}, nil
+}
+
+// readHeadEntries extract the clocks and the status from the entries of a
+// commit tree
+func readHeadEntries(repo repository.Repo, entries []repository.TreeEntry) (util.LamportTime, util.LamportTime, Status, error) {
+	var createTime, editTime uint64
+	var status Status
+	var err error
+
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry.Name, createClockEntryPrefix):
+			_, err = fmt.Sscanf(entry.Name, createClockEntryPattern, &createTime)
+		case strings.HasPrefix(entry.Name, editClockEntryPrefix):
+			_, err = fmt.Sscanf(entry.Name, editClockEntryPattern, &editTime)
+		case entry.Name == createClockEntryName:
+			createTime, err = readClockBlob(repo, entry.Hash)
+		case entry.Name == editClockEntryName:
+			editTime, err = readClockBlob(repo, entry.Hash)
+		case strings.HasPrefix(entry.Name, statusEntryPrefix):
+			status, err = parseStatusEntry(entry.Name)
+		}
+
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	return util.LamportTime(createTime), util.LamportTime(editTime), status, nil
+}
+
+// makeStatusEntry create the tree entry storing the status of the bug
+func makeStatusEntry(repo repository.Repo, status Status) (repository.TreeEntry, error) {
+	emptyBlobHash, err := repo.StoreData([]byte{})
+	if err != nil {
+		return repository.TreeEntry{}, err
+	}
+
+	return repository.TreeEntry{
+		ObjectType: repository.Blob,
+		Hash:       emptyBlobHash,
+		Name:       statusEntryPrefix + statusEntryNames[status],
+	}, nil
+}
+
+// parseStatusEntry return the status stored in the name of a status entry
+func parseStatusEntry(name string) (Status, error) {
+	for status, statusName := range statusEntryNames {
+		if name == statusEntryPrefix+statusName {
+			return status, nil
+		}
+	}
+
+	var status Status
+	_, err := fmt.Sscanf(name, statusEntryPattern, &status)
+	if err != nil {
+		return 0, fmt.Errorf("invalid status entry %s", name)
+	}
+
+	return status, nil
+}
+
+// commitStatus return the status of the bug once its staged operations are
+// committed. Only the staged operations are applied, on top of the status
+// of the last commit.
+func (bug *Bug) commitStatus(repo repository.Repo) (Status, error) {
+	status, err := bug.headStatus(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	snap := Snapshot{id: bug.id, Status: status}
+	for _, op := range bug.staging.Operations {
+		snap = op.Apply(snap)
+	}
+
+	return snap.Status, nil
+}
+
+// headStatus return the status of the bug at its last commit, read from its
+// status entry unless the commit predate it
+func (bug *Bug) headStatus(repo repository.Repo) (Status, error) {
+	if bug.lastCommit == "" {
+		return 0, nil
+	}
+
+	entries, err := repo.ListEntries(bug.lastCommit)
+	if err != nil {
+		return 0, err
+	}
+
+	_, _, status, err := readHeadEntries(repo, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	if status == 0 {
+		committed := Bug{id: bug.id, packs: bug.packs}
+		status = committed.Compile().Status
+	}
+
+	return status, nil
+}
+
+// replaceStatusEntry store a copy of a commit tree with a different status
+func replaceStatusEntry(repo repository.Repo, commit util.Hash, status Status) (util.Hash, error) {
+	entries, err := repo.ListEntries(commit)
+	if err != nil {
+		return "", err
+	}
+
+	statusEntry, err := makeStatusEntry(repo, status)
+	if err != nil {
+		return "", err
+	}
+
+	tree := []repository.TreeEntry{statusEntry}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name, statusEntryPrefix) {
+			tree = append(tree, entry)
+		}
+	}
+
+	return repo.StoreTree(tree)
+}
@@ -0,0 +1,272 @@
+package bug
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The maximum size of a bug pushed over HTTP, media included
+const maxHTTPPushSize = 100 * 1000 * 1000
+
+// HTTPPushTimeout is how long PushHTTP wait for the server, transfer included
+var HTTPPushTimeout = 5 * time.Minute
+
+// httpBundle is the payload of a push over HTTP: the git objects of a bug,
+// ordered so that an object only reference objects before it
+type httpBundle struct {
+	Id      string       `json:"id"`
+	Head    util.Hash    `json:"head"`
+	Objects []httpObject `json:"objects"`
+}
+
+type httpObject struct {
+	Type string    `json:"type"`
+	Hash util.Hash `json:"hash"`
+	Data []byte    `json:"data"`
+}
+
+// PushHTTP push a local bug to a git-bug server over HTTP, without the need of
+// a git remote. The url is the one of the endpoint served by NewHTTPReceiver.
+// The commits, trees and media of the bug are all transferred.
+func PushHTTP(repo repository.Repo, url string, id string) error {
+	bundle, err := bundleBug(repo, id)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: HTTPPushTimeout}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("push refused: %s", strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// bundleBug collect the git objects of a local bug
+func bundleBug(repo repository.Repo, id string) (*httpBundle, error) {
+	ref := bugsRefPattern + id
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := repo.ListCommitParents(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &httpBundle{Id: id, Head: head}
+	added := make(map[util.Hash]bool)
+
+	addObject := func(hash util.Hash) error {
+		if added[hash] {
+			return nil
+		}
+		added[hash] = true
+
+		objType, data, err := repo.ReadRawObject(hash)
+		if err != nil {
+			return err
+		}
+
+		bundle.Objects = append(bundle.Objects, httpObject{Type: objType, Hash: hash, Data: data})
+		return nil
+	}
+
+	var addTree func(hash util.Hash) error
+	addTree = func(hash util.Hash) error {
+		if added[hash] {
+			return nil
+		}
+
+		entries, err := repo.ListEntries(hash)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.ObjectType == repository.Tree {
+				err = addTree(entry.Hash)
+			} else {
+				err = addObject(entry.Hash)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return addObject(hash)
+	}
+
+	var addCommit func(hash util.Hash) error
+	addCommit = func(hash util.Hash) error {
+		if added[hash] {
+			return nil
+		}
+
+		for _, parent := range parents[hash] {
+			err := addCommit(parent)
+			if err != nil {
+				return err
+			}
+		}
+
+		tree, err := repo.GetTreeHash(hash)
+		if err != nil {
+			return err
+		}
+
+		err = addTree(tree)
+		if err != nil {
+			return err
+		}
+
+		return addObject(hash)
+	}
+
+	err = addCommit(head)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+type httpReceiver struct {
+	repo repository.Repo
+}
+
+// NewHTTPReceiver return the HTTP handler receiving the bugs pushed with
+// PushHTTP. A new bug is created, a known one is only fast-forwarded.
+//
+// The handler doesn't authenticate the requests. It must not be served where
+// a web browser can reach it, like next to the web UI, or any visited page
+// could push bugs.
+func NewHTTPReceiver(repo repository.Repo) http.Handler {
+	return &httpReceiver{repo: repo}
+}
+
+func (hr *httpReceiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// a form can't be posted as JSON by a browser without a CORS preflight
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		http.Error(rw, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxHTTPPushSize)
+
+	var bundle httpBundle
+	err = json.NewDecoder(r.Body).Decode(&bundle)
+	if err != nil {
+		http.Error(rw, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	status, err := hr.receive(bundle)
+	if err != nil {
+		http.Error(rw, err.Error(), status)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// receive store a pushed bug and return the HTTP status to answer
+func (hr *httpReceiver) receive(bundle httpBundle) (int, error) {
+	if hash := util.Hash(bundle.Id); len(bundle.Id) != idLength || !hash.IsValid() {
+		return http.StatusBadRequest, fmt.Errorf("invalid bug id %s", bundle.Id)
+	}
+
+	for _, obj := range bundle.Objects {
+		hash, err := hr.repo.StoreRawObject(obj.Type, obj.Data)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		if hash != obj.Hash {
+			return http.StatusBadRequest, fmt.Errorf("object %s doesn't match its hash", obj.Hash)
+		}
+	}
+
+	// validate the history before exposing it under a ref
+	b, err := ReadBugFromCommit(hr.repo, bundle.Head)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if b.Id() != bundle.Id {
+		return http.StatusBadRequest, errors.New("the pushed history doesn't match the bug id")
+	}
+	if err := b.Validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	unlock, err := lockRepo(hr.repo)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	defer unlock()
+
+	ref := bugsRefPattern + bundle.Id
+
+	exist, err := hr.repo.RefExist(ref)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if exist {
+		local, err := hr.repo.ResolveRef(ref)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		ancestor, err := hr.repo.FindCommonAncestor(local, bundle.Head)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		// nothing new
+		if ancestor == bundle.Head {
+			return http.StatusOK, nil
+		}
+
+		if ancestor != local {
+			return http.StatusConflict, errors.New("the bug has diverged, it need to be pulled and merged first")
+		}
+	}
+
+	err = hr.repo.UpdateRef(ref, bundle.Head)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,75 @@
+package bug
+
+import (
+	"sort"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// HumanIdLength return the length of the shortest prefix telling apart all the
+// local bugs, to display ids without collision in a large repository. It's
+// never less than the default length of the human ids.
+func HumanIdLength(repo repository.Repo) (int, error) {
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	return uniquePrefixLength(ids), nil
+}
+
+// FormatHumanId truncate a bug id to the given length, as returned by
+// HumanIdLength
+func FormatHumanId(id string, length int) string {
+	if length >= len(id) {
+		return id
+	}
+	return id[:length]
+}
+
+// formatHumanId truncate a bug id to the given length, or to the default one
+// if the length is unknown
+func formatHumanId(id string, length int) string {
+	if length < humanIdLength {
+		length = humanIdLength
+	}
+	return FormatHumanId(id, length)
+}
+
+// repoHumanId truncate a bug id to be unambiguous in the repository, or to
+// the default length if it can't be computed
+func repoHumanId(repo repository.Repo, id string) string {
+	length, _ := HumanIdLength(repo)
+	return formatHumanId(id, length)
+}
+
+// uniquePrefixLength return the length of the shortest prefix telling apart
+// the given ids, bounded by the default human id length and the full length
+func uniquePrefixLength(ids []string) int {
+	sorted := make([]string, len(ids))
+	copy(sorted, ids)
+	sort.Strings(sorted)
+
+	length := humanIdLength
+
+	// once sorted, the longest common prefix is between two neighbors
+	for i := 1; i < len(sorted); i++ {
+		a, b := sorted[i-1], sorted[i]
+
+		common := 0
+		for common < len(a) && common < len(b) && a[common] == b[common] {
+			common++
+		}
+
+		if common+1 > length {
+			length = common + 1
+		}
+	}
+
+	// identical ids can't be told apart anyway
+	if length > idLength {
+		length = idLength
+	}
+
+	return length
+}
@@ -0,0 +1,53 @@
+package bug
+
+import (
+	"errors"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// LocalOnlyOps return the committed operations of the local version of a bug
+// that are not present in the remote version, that is what a push would
+// send. Operations not committed yet are not included.
+func LocalOnlyOps(repo repository.Repo, local, remote *Bug) ([]Operation, error) {
+	if local.id != remote.id {
+		return nil, errors.New("comparing unrelated bugs is not supported")
+	}
+
+	if local.lastCommit == "" || remote.lastCommit == "" {
+		return nil, errors.New("can't compare a bug that has never been stored")
+	}
+
+	ancestor, err := repo.FindCommonAncestor(local.lastCommit, remote.lastCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	theirs := make(map[util.Hash]bool)
+	for _, pack := range remote.packs {
+		theirs[pack.commitHash] = true
+	}
+
+	// everything up to the common ancestor is shared
+	start := 0
+	for i, pack := range local.packs {
+		if pack.commitHash == ancestor {
+			start = i + 1
+			break
+		}
+	}
+
+	var result []Operation
+
+	for _, pack := range local.packs[start:] {
+		// with a merge commit, the remote packs can come after the ancestor
+		if theirs[pack.commitHash] {
+			continue
+		}
+
+		result = append(result, pack.Operations...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ErrStaleBug is the error returned when writing a bug that changed in the
+// repository since it was read, for example by another process. The bug need
+// to be read again.
+var ErrStaleBug = errors.New("the bug changed since it was read")
+
+// lockRepo acquire the lock of the repository to serialize the mutating
+// operations. The returned function release the lock.
+func lockRepo(repo repository.Repo) (func(), error) {
+	err := repo.Lock()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { repo.Unlock() }, nil
+}
+
+// updateRef move the ref of the bug from the commit it was read at to a new
+// one, see updateBugRef
+func (bug *Bug) updateRef(repo repository.Repo, from util.Hash, to util.Hash) error {
+	return updateBugRef(repo, bug.id, from, to)
+}
+
+// updateBugRef move the ref of a bug from a known commit to a new one. If
+// another process moved the ref meanwhile, nothing is changed and ErrStaleBug
+// is returned, so that its changes are not overwritten.
+func updateBugRef(repo repository.Repo, id string, from util.Hash, to util.Hash) error {
+	err := repo.UpdateRefFrom(bugsRefPattern+id, to, from)
+	if errors.Is(err, repository.ErrRefChanged) {
+		return fmt.Errorf("%w: %s", ErrStaleBug, repoHumanId(repo, id))
+	}
+
+	return err
+}
@@ -0,0 +1,80 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+var ErrMediaLimitExceeded = errors.New("media limit exceeded")
+
+// The limits on the media referenced by a single commit. Zero means no limit.
+var (
+	mediaLimitsMutex sync.RWMutex
+	maxMediaCount    = 100
+	maxMediaSize     = int64(50 * 1024 * 1024)
+)
+
+// SetMediaLimits define the maximum number of media and their maximum total
+// size in bytes that a single commit can reference. Zero means no limit. It
+// apply to every repository of the process and can be called concurrently
+// with the commits.
+func SetMediaLimits(maxCount int, maxSize int64) {
+	mediaLimitsMutex.Lock()
+	defer mediaLimitsMutex.Unlock()
+
+	maxMediaCount = maxCount
+	maxMediaSize = maxSize
+}
+
+func getMediaLimits() (int, int64) {
+	mediaLimitsMutex.RLock()
+	defer mediaLimitsMutex.RUnlock()
+
+	return maxMediaCount, maxMediaSize
+}
+
+// checkMediaLimits verify that the media referenced by the operations of a
+// pack are within the limits
+func checkMediaLimits(repo repository.Repo, pack OperationPack) error {
+	maxCount, maxSize := getMediaLimits()
+
+	if maxCount == 0 && maxSize == 0 {
+		return nil
+	}
+
+	files := make(map[util.Hash]bool)
+	for _, op := range pack.Operations {
+		for _, file := range op.Files() {
+			files[file] = true
+		}
+	}
+
+	if maxCount > 0 && len(files) > maxCount {
+		return fmt.Errorf("%w: %d media referenced, the maximum is %d",
+			ErrMediaLimitExceeded, len(files), maxCount)
+	}
+
+	if maxSize == 0 {
+		return nil
+	}
+
+	var size int64
+	for file := range files {
+		fileSize, err := repo.BlobSize(file)
+		if err != nil {
+			return err
+		}
+		size += fileSize
+	}
+
+	if size > maxSize {
+		return fmt.Errorf("%w: %d bytes of media referenced, the maximum is %d",
+			ErrMediaLimitExceeded, size, maxSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package bug
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// dedupPacks remove from our packs the operations that the other side made
+// identically, like the same comment added independently on two clones, so
+// that they are only kept once after the merge. Each operation of the other
+// side cancel at most one of ours. The packs left empty are dropped, and for
+// each remaining pack is returned whether it lost operations.
+//
+// Two operations are identical when they have the same type, author and
+// content, see contentHash. Their wall-clock time is not compared, as the
+// same edit made independently on two clones is rarely made in the same
+// second.
+func dedupPacks(ours []OperationPack, theirs []OperationPack) ([]OperationPack, []bool, error) {
+	theirHashes := make(map[util.Hash]int)
+
+	for _, op := range packsOperations(theirs) {
+		hash, err := contentHash(op)
+		if err != nil {
+			return nil, nil, err
+		}
+		theirHashes[hash]++
+	}
+
+	result := make([]OperationPack, 0, len(ours))
+	changed := make([]bool, 0, len(ours))
+
+	for _, pack := range ours {
+		deduped := pack.Clone()
+		deduped.Operations = deduped.Operations[:0]
+		deduped.EditTimes = deduped.EditTimes[:0]
+
+		for i, op := range pack.Operations {
+			hash, err := contentHash(op)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if theirHashes[hash] > 0 {
+				theirHashes[hash]--
+				continue
+			}
+
+			deduped.Operations = append(deduped.Operations, op)
+			if len(pack.EditTimes) > 0 {
+				deduped.EditTimes = append(deduped.EditTimes, pack.EditTimes[i])
+			}
+		}
+
+		if len(deduped.Operations) == 0 {
+			continue
+		}
+
+		result = append(result, deduped)
+		changed = append(changed, len(deduped.Operations) != len(pack.Operations))
+	}
+
+	return result, changed, nil
+}
+
+// contentHash compute a hash of the type, author and content of an operation,
+// that is its Hash without the time it was made
+func contentHash(op Operation) (util.Hash, error) {
+	if external, ok := op.(ExternalPayloadOperation); ok {
+		loaded, err := external.LoadPayload()
+		if err != nil {
+			return "", err
+		}
+		op = loaded
+	}
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return "", err
+	}
+
+	delete(fields, "UnixTime")
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	return util.Hash(fmt.Sprintf("%x", sha1.Sum(data))), nil
+}
+
+// rebasedTree return the tree of a pack to rebase: the one of its commit as
+// is, or with a new ops entry if the pack lost operations
+func rebasedTree(repo repository.Repo, pack OperationPack, changed bool) (util.Hash, error) {
+	if !changed {
+		return repo.GetTreeHash(pack.commitHash)
+	}
+
+	toWrite, err := pack.externalizePayloads(repo)
+	if err != nil {
+		return "", err
+	}
+
+	opsHash, err := toWrite.Write(repo)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := repo.ListEntries(pack.commitHash)
+	if err != nil {
+		return "", err
+	}
+
+	tree := make([]repository.TreeEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name == opsEntryName {
+			entry.Hash = opsHash
+		}
+		tree = append(tree, entry)
+	}
+
+	return repo.StoreTree(tree)
+}
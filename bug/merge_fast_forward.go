@@ -0,0 +1,71 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// ErrNotFastForward is returned by MergeFastForwardOnly when our version of
+// the bug has operations that the other doesn't have
+var ErrNotFastForward = errors.New("the bug has diverged, it can't be fast-forwarded")
+
+// MergeFastForwardOnly merge a different version of the same bug only if ours
+// is an ancestor of it, like `git merge --ff-only`. Contrary to Merge, our
+// commits are never rewritten: if we have operations the other version
+// doesn't have, ErrNotFastForward is returned and nothing is written.
+// Return true if the bug has been updated.
+func (bug *Bug) MergeFastForwardOnly(repo repository.Repo, other *Bug) (bool, error) {
+	err := bug.checkMergeable(other)
+	if err != nil {
+		return false, err
+	}
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// another process might have changed the bug since it was read
+	head, err := repo.ResolveRef(bugsRefPattern + bug.id)
+	if err != nil {
+		return false, err
+	}
+	if head != bug.lastCommit {
+		return false, fmt.Errorf("%w: %s", ErrStaleBug, bug.HumanId())
+	}
+
+	ancestor, err := repo.FindCommonAncestor(bug.lastCommit, other.lastCommit)
+	if err != nil {
+		return false, err
+	}
+
+	if ancestor != bug.lastCommit {
+		return false, ErrNotFastForward
+	}
+
+	if other.lastCommit == bug.lastCommit {
+		// Nothing new, return early
+		return false, nil
+	}
+
+	newPacks := make([]OperationPack, 0, len(other.packs))
+	newPacks = append(newPacks, bug.packs...)
+
+	for i := len(bug.packs); i < len(other.packs); i++ {
+		newPacks = append(newPacks, other.packs[i].Clone())
+	}
+
+	err = bug.updateRef(repo, bug.lastCommit, other.lastCommit)
+	if err != nil {
+		return false, err
+	}
+
+	bug.packs = newPacks
+	bug.lastCommit = other.lastCommit
+	bug.editTime = other.editTime
+
+	return true, nil
+}
@@ -0,0 +1,212 @@
+package bug
+
+import (
+	"errors"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// MergeIncremental merge a different version of a local bug like Merge does,
+// but without reading the local bug first. Our side is already valid, so the
+// rebase only use the commit hashes and trees. Only our commits that need to
+// be rebased are parsed, to compute the status of the new head. The other
+// version still need to be fully read, to validate it.
+func MergeIncremental(repo repository.Repo, id string, other *Bug) (bool, error) {
+	updated, _, err := MergeIncrementalWithConflicts(repo, id, other)
+	return updated, err
+}
+
+// MergeIncrementalWithConflicts merge a different version of a local bug like
+// MergeIncremental does, and also return the conflicts, like
+// MergeWithConflicts.
+func MergeIncrementalWithConflicts(repo repository.Repo, id string, other *Bug) (bool, []OperationConflict, error) {
+	if id != other.id {
+		return false, nil, errors.New("merging unrelated bugs is not supported")
+	}
+
+	if len(other.staging.Operations) > 0 {
+		return false, nil, errors.New("merging a bug with a non-empty staging is not supported")
+	}
+
+	if other.lastCommit == "" {
+		return false, nil, errors.New("can't merge a bug that has never been stored")
+	}
+
+	if other.ReadOnly() {
+		return false, nil, ErrUnsupportedFormatVersion
+	}
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return false, nil, err
+	}
+	defer unlock()
+
+	localRef := bugsRefPattern + id
+
+	head, err := repo.ResolveRef(localRef)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// the root pack is referenced by every commit
+	entries, err := repo.ListEntries(head)
+	if err != nil {
+		return false, nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == rootEntryName && entry.Hash != other.rootPack {
+			return false, nil, ErrDivergentRoot
+		}
+	}
+
+	ancestor, err := repo.FindCommonAncestor(head, other.lastCommit)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// the other version is behind or identical, nothing to do
+	if ancestor == other.lastCommit {
+		return false, nil, nil
+	}
+
+	// the history can be a DAG, see MergeDAG, so the commits of every branch
+	// are walked like when reading the bug
+	parents, err := repo.ListCommitParents(string(head))
+	if err != nil {
+		return false, nil, err
+	}
+
+	known := reachableCommits(ancestor, parents)
+
+	// our commits after the common ancestor, touch and merge commits are
+	// dropped like with Merge
+	var extra []util.Hash
+
+	for _, commit := range linearizeCommits(head, parents) {
+		if known[commit] {
+			continue
+		}
+
+		touch, err := isTouchCommit(repo, commit)
+		if err != nil {
+			return false, nil, err
+		}
+		if !touch {
+			extra = append(extra, commit)
+		}
+	}
+
+	lastCommit := other.lastCommit
+	var conflicts []OperationConflict
+
+	if len(extra) > 0 {
+		ours := make([]OperationPack, 0, len(extra))
+		for _, commit := range extra {
+			pack, err := readCommitPack(repo, commit)
+			if err != nil {
+				return false, nil, err
+			}
+			ours = append(ours, *pack)
+		}
+
+		before, theirs, err := splitPacks(repo, other.packs, ancestor)
+		if err != nil {
+			return false, nil, err
+		}
+		base := Bug{packs: before}
+		conflicts = findConflicts(base.Compile(), packsOperations(ours), packsOperations(theirs))
+
+		// the operations made identically on both sides are only kept once
+		ours, changed, err := dedupPacks(ours, theirs)
+		if err != nil {
+			return false, nil, err
+		}
+
+		merged := Bug{packs: make([]OperationPack, 0, len(other.packs)+len(ours))}
+		for _, pack := range other.packs {
+			merged.packs = append(merged.packs, pack.Clone())
+		}
+		merged.packs = append(merged.packs, ours...)
+
+		mergedStatus := merged.Compile().Status
+
+		for i, pack := range ours {
+			treeHash, err := rebasedTree(repo, pack, changed[i])
+			if err != nil {
+				return false, nil, err
+			}
+
+			// the new head need the merged status
+			if i == len(ours)-1 {
+				treeHash, err = replaceStatusEntry(repo, treeHash, mergedStatus)
+				if err != nil {
+					return false, nil, err
+				}
+			}
+
+			lastCommit, err = repo.StoreCommitWithParent(treeHash, lastCommit)
+			if err != nil {
+				return false, nil, err
+			}
+		}
+	}
+
+	err = updateBugRef(repo, id, head, lastCommit)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return true, conflicts, nil
+}
+
+// readCommitPack read and parse the operation pack of a single commit
+func readCommitPack(repo repository.Repo, commit util.Hash) (*OperationPack, error) {
+	tree, err := readCommitTree(repo, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tree.opsFound {
+		return nil, errors.New("Invalid tree, missing the ops entry")
+	}
+
+	data, err := repo.ReadData(tree.opsEntry.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := ParseOperationPack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pack.bindPayloads(repo)
+
+	pack.commitHash = commit
+	pack.editTime = util.LamportTime(tree.editTime)
+
+	return pack, nil
+}
+
+// reachableCommits return the commits reachable from a commit, itself included
+func reachableCommits(from util.Hash, parents map[util.Hash][]util.Hash) map[util.Hash]bool {
+	result := map[util.Hash]bool{from: true}
+	queue := []util.Hash{from}
+
+	for len(queue) > 0 {
+		commit := queue[0]
+		queue = queue[1:]
+
+		for _, parent := range parents[commit] {
+			if !result[parent] {
+				result[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return result
+}
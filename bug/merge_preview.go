@@ -0,0 +1,112 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// MergeStats describe what a merge would do
+type MergeStats struct {
+	// Id of the merged bug
+	Id string
+	// Number of operations that would be pulled in from the other version
+	Pulled int
+	// Number of our operations that would be rebased on top of them
+	Rebased int
+	// Number of our operations that the other version has made identically,
+	// that would be dropped
+	Deduplicated int
+	// Our commits that would be rewritten by the rebase
+	RebasedCommits []util.Hash
+	// The status of the bug after the merge
+	Status Status
+	// Concurrent operations of both sides that would conflict
+	Conflicts []OperationConflict
+}
+
+// MergePreview compute what Merge would do with another version of the bug,
+// without writing anything
+func (bug *Bug) MergePreview(repo repository.Repo, other *Bug) (MergeStats, error) {
+	err := bug.checkMergeable(other)
+	if err != nil {
+		return MergeStats{}, err
+	}
+
+	plan, err := bug.planMerge(repo, other)
+	if err != nil {
+		return MergeStats{}, err
+	}
+
+	stats := MergeStats{Id: bug.id}
+
+	if len(plan.theirs) == 0 {
+		// nothing to merge
+		stats.Status = bug.Compile().Status
+		return stats, nil
+	}
+
+	stats.Pulled = len(packsOperations(plan.theirs))
+	stats.Rebased = len(packsOperations(plan.ours))
+	stats.Deduplicated = len(packsOperations(bug.packs[plan.ancestorIndex+1:])) - stats.Rebased
+
+	for _, pack := range plan.ours {
+		stats.RebasedCommits = append(stats.RebasedCommits, pack.commitHash)
+	}
+
+	stats.Status = plan.status
+	stats.Conflicts = plan.conflicts
+
+	return stats, nil
+}
+
+// mergePlan is what merging another version of the bug do, as computed
+// without writing anything by planMerge
+type mergePlan struct {
+	// index of the last pack both versions have
+	ancestorIndex int
+	// the packs of the other version to pull
+	theirs []OperationPack
+	// our packs to rebase on top of them, without the operations the other
+	// version has made identically
+	ours []OperationPack
+	// for each of our packs, whether it lost operations
+	changed []bool
+	// the concurrent operations of both sides that conflict
+	conflicts []OperationConflict
+	// the status of the bug after the merge
+	status Status
+}
+
+// planMerge compute what merging another version of the bug do, for Merge to
+// apply it and MergePreview to describe it
+func (bug *Bug) planMerge(repo repository.Repo, other *Bug) (*mergePlan, error) {
+	ancestorIndex, err := bug.mergeAncestorIndex(repo, other)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &mergePlan{ancestorIndex: ancestorIndex}
+
+	if len(other.packs) == ancestorIndex+1 {
+		return plan, nil
+	}
+
+	base := bug.packs[:ancestorIndex+1]
+	ours := bug.packs[ancestorIndex+1:]
+	plan.theirs = other.packs[ancestorIndex+1:]
+
+	// the operations made on both sides can conflict
+	baseBug := Bug{packs: base}
+	plan.conflicts = findConflicts(baseBug.Compile(), packsOperations(ours), packsOperations(plan.theirs))
+
+	// the operations made identically on both sides are only kept once
+	plan.ours, plan.changed, err = dedupPacks(ours, plan.theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := Bug{packs: append(append(append([]OperationPack{}, base...), plan.theirs...), plan.ours...)}
+	plan.status = merged.Compile().Status
+
+	return plan, nil
+}
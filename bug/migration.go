@@ -0,0 +1,43 @@
+package bug
+
+import "fmt"
+
+// Migration rewrite an operation of a deprecated type into its current
+// equivalent
+type Migration func(op Operation) (Operation, error)
+
+var migrations = make(map[OperationType]Migration)
+
+// A migration can produce another deprecated operation, up to this limit to
+// guard against a cycle
+const maxMigrationSteps = 16
+
+// RegisterMigration register a migration of the operations of a deprecated
+// type, applied when reading an OperationPack so that Compile never see them.
+// The deprecated type must still be registered with gob to be decoded.
+//
+// Note that the hash of a migrated operation, and so the id of the comment it
+// might create, is the one of the new operation. A migration should keep the
+// author and the time of the operation.
+func RegisterMigration(opType OperationType, migration Migration) {
+	migrations[opType] = migration
+}
+
+// migrateOperation rewrite an operation until it's of a current type
+func migrateOperation(op Operation) (Operation, error) {
+	for i := 0; i < maxMigrationSteps; i++ {
+		migration, ok := migrations[op.OpType()]
+		if !ok {
+			return op, nil
+		}
+
+		migrated, err := migration(op)
+		if err != nil {
+			return nil, fmt.Errorf("migrating a %s operation: %w", op.OpType(), err)
+		}
+
+		op = migrated
+	}
+
+	return nil, fmt.Errorf("too many migrations for a %s operation", op.OpType())
+}
@@ -0,0 +1,143 @@
+package bug
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// namespaceSeparator separate the name of a repository from the id of a bug
+// in a namespaced id, like "issues/1a2b3c4"
+const namespaceSeparator = "/"
+
+// MultiRepo aggregate the bugs of several repositories, for example when the
+// bugs of a project are kept in a separate repository from its code. As the
+// same bug can exist in more than one repository, a bug is designated across
+// them by a namespaced id "<repo>/<id>".
+type MultiRepo struct {
+	names []string
+	repos map[string]repository.Repo
+}
+
+// NewMultiRepo create an empty MultiRepo
+func NewMultiRepo() *MultiRepo {
+	return &MultiRepo{
+		repos: make(map[string]repository.Repo),
+	}
+}
+
+// Add register a repository under a name. The name must be unique and can't
+// contain the namespace separator.
+func (m *MultiRepo) Add(name string, repo repository.Repo) error {
+	if name == "" || strings.Contains(name, namespaceSeparator) {
+		return fmt.Errorf("invalid repository name \"%s\"", name)
+	}
+
+	if _, ok := m.repos[name]; ok {
+		return fmt.Errorf("repository \"%s\" already registered", name)
+	}
+
+	m.names = append(m.names, name)
+	m.repos[name] = repo
+
+	return nil
+}
+
+// Names return the names of the repositories, in the order they were added
+func (m *MultiRepo) Names() []string {
+	return append([]string(nil), m.names...)
+}
+
+// Repo return the repository registered under a name
+func (m *MultiRepo) Repo(name string) (repository.Repo, bool) {
+	repo, ok := m.repos[name]
+	return repo, ok
+}
+
+// NamespacedId return the id of a bug qualified by the name of its repository
+func NamespacedId(repoName string, id string) string {
+	return repoName + namespaceSeparator + id
+}
+
+// SplitNamespacedId split a namespaced id in the name of the repository and
+// the id, or return false if the id is not namespaced
+func SplitNamespacedId(s string) (string, string, bool) {
+	i := strings.Index(s, namespaceSeparator)
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+len(namespaceSeparator):], true
+}
+
+// ReadAllLocalBugs read and parse the local bugs of every repository, one
+// repository after the other. The Repo field of the streamed bugs is set to
+// the name of the repository they come from. An error reading a repository is
+// sent on the channel and the next repositories are still read.
+func (m *MultiRepo) ReadAllLocalBugs() <-chan StreamedBug {
+	out := make(chan StreamedBug)
+
+	go func() {
+		defer close(out)
+
+		for _, name := range m.names {
+			for streamed := range ReadAllLocalBugs(m.repos[name]) {
+				streamed.Repo = name
+				out <- streamed
+			}
+		}
+	}()
+
+	return out
+}
+
+// FindLocalBug find the bug designated by a namespaced id, or by an id or a
+// prefix of an id that is unique across all the repositories. The name of the
+// repository the bug was read from is returned as well.
+func (m *MultiRepo) FindLocalBug(s string) (*Bug, string, error) {
+	if name, prefix, ok := SplitNamespacedId(s); ok {
+		repo, ok := m.repos[name]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown repository \"%s\"", name)
+		}
+
+		b, err := FindLocalBug(repo, prefix)
+		if err != nil {
+			return nil, "", err
+		}
+		return b, name, nil
+	}
+
+	// preallocate but empty
+	matching := make([]string, 0, 5)
+
+	for _, name := range m.names {
+		ids, err := ListLocalIds(m.repos[name])
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, id := range ids {
+			if id == s || strings.HasPrefix(id, s) {
+				matching = append(matching, NamespacedId(name, id))
+			}
+		}
+	}
+
+	if len(matching) > 1 {
+		return nil, "", fmt.Errorf("%w:\n%s", ErrMultipleBugs, strings.Join(matching, "\n"))
+	}
+
+	if len(matching) == 0 {
+		return nil, "", ErrBugNotFound
+	}
+
+	name, id, _ := SplitNamespacedId(matching[0])
+
+	b, err := ReadLocalBug(m.repos[name], id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b, name, nil
+}
@@ -0,0 +1,205 @@
+package bug
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// defaultNamespaceName is the namespace the bugs have always been stored in
+const defaultNamespaceName = "bugs"
+
+// the suffixes of the local-only refs of a namespace
+var namespaceSuffixes = []string{"-read", "-staging", "-alias"}
+
+var namespaceNameRegexp = regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+
+// the namespaces of refs that git itself, or common git tooling, use
+var reservedNamespaceNames = map[string]bool{
+	"heads":     true,
+	"tags":      true,
+	"remotes":   true,
+	"notes":     true,
+	"stash":     true,
+	"replace":   true,
+	"bisect":    true,
+	"original":  true,
+	"rewritten": true,
+	"worktree":  true,
+	"prefetch":  true,
+}
+
+// Namespace is a set of bugs stored under their own git refs, "refs/<name>/",
+// so that separate trackers, like "bugs" and "tasks", can live side by side
+// in the same repository.
+type Namespace struct {
+	name string
+}
+
+// DefaultNamespace is the namespace used when none is given, "refs/bugs/"
+var DefaultNamespace = Namespace{name: defaultNamespaceName}
+
+// NewNamespace create a namespace with the given name. The name must be made
+// of lowercase letters, digits, '-' and '_', and can't be one of the ref
+// namespaces used by git, like "heads" or "tags".
+func NewNamespace(name string) (Namespace, error) {
+	if !namespaceNameRegexp.MatchString(name) {
+		return Namespace{}, fmt.Errorf("invalid namespace name \"%s\"", name)
+	}
+
+	if reservedNamespaceNames[name] {
+		return Namespace{}, fmt.Errorf("namespace name \"%s\" is reserved", name)
+	}
+
+	// would collide with the local-only refs of another namespace
+	for _, suffix := range namespaceSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return Namespace{}, fmt.Errorf("namespace name \"%s\" can't end with \"%s\"", name, suffix)
+		}
+	}
+
+	return Namespace{name: name}, nil
+}
+
+// Name return the name of the namespace
+func (ns Namespace) Name() string {
+	if ns.name == "" {
+		return defaultNamespaceName
+	}
+	return ns.name
+}
+
+// Repo return a view of the repository where the bugs are read and written
+// in the namespace. Every function of this package can be used with it, for
+// example ReadLocalBug(ns.Repo(repo), id) or b.Commit(ns.Repo(repo)).
+//
+// The view doesn't have a local storage, so the bugs of a namespace other
+// than the default one are not cached.
+func (ns Namespace) Repo(repo repository.Repo) repository.Repo {
+	if ns.Name() == defaultNamespaceName {
+		return repo
+	}
+
+	return &namespacedRepo{Repo: repo, name: ns.Name()}
+}
+
+// namespacedRepo move the refs of the default namespace to another one
+type namespacedRepo struct {
+	repository.Repo
+	name string
+}
+
+// toNamespace translate a ref of the default namespace to the same ref in
+// the namespace. Other refs are returned as is.
+func (r *namespacedRepo) toNamespace(ref string) string {
+	return translateNamespace(ref, defaultNamespaceName, r.name)
+}
+
+// fromNamespace translate a ref of the namespace back to the default one
+func (r *namespacedRepo) fromNamespace(ref string) string {
+	return translateNamespace(ref, r.name, defaultNamespaceName)
+}
+
+func translateNamespace(ref string, from string, to string) string {
+	// a refspec like "refs/bugs/*:refs/remotes/origin/bugs/*"
+	if i := strings.Index(ref, ":"); i >= 0 {
+		return translateNamespace(ref[:i], from, to) + ":" + translateNamespace(ref[i+1:], from, to)
+	}
+
+	if strings.HasPrefix(ref, "+") {
+		return "+" + translateNamespace(ref[1:], from, to)
+	}
+
+	suffixes := append([]string{""}, namespaceSuffixes...)
+	for _, suffix := range suffixes {
+		prefix := "refs/" + from + suffix + "/"
+		if strings.HasPrefix(ref, prefix) {
+			return "refs/" + to + suffix + "/" + ref[len(prefix):]
+		}
+	}
+
+	// a remote ref like "refs/remotes/origin/bugs/"
+	const remotesPrefix = "refs/remotes/"
+	if strings.HasPrefix(ref, remotesPrefix) {
+		rest := ref[len(remotesPrefix):]
+		i := strings.Index(rest, "/")
+		if i >= 0 && strings.HasPrefix(rest[i+1:], from+"/") {
+			return remotesPrefix + rest[:i+1] + to + "/" + rest[i+1+len(from)+1:]
+		}
+	}
+
+	return ref
+}
+
+func (r *namespacedRepo) FetchRefs(remote string, refSpec string) (string, error) {
+	return r.Repo.FetchRefs(remote, r.toNamespace(refSpec))
+}
+
+func (r *namespacedRepo) PushRefs(remote string, refSpec string) (string, error) {
+	return r.Repo.PushRefs(remote, r.toNamespace(refSpec))
+}
+
+func (r *namespacedRepo) UpdateRef(ref string, hash util.Hash) error {
+	return r.Repo.UpdateRef(r.toNamespace(ref), hash)
+}
+
+func (r *namespacedRepo) UpdateRefFrom(ref string, hash util.Hash, expected util.Hash) error {
+	return r.Repo.UpdateRefFrom(r.toNamespace(ref), hash, expected)
+}
+
+func (r *namespacedRepo) ListRefs(refspec string) ([]string, error) {
+	refs, err := r.Repo.ListRefs(r.toNamespace(refspec))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, ref := range refs {
+		refs[i] = r.fromNamespace(ref)
+	}
+
+	return refs, nil
+}
+
+func (r *namespacedRepo) ListIds(refspec string) ([]string, error) {
+	return r.Repo.ListIds(r.toNamespace(refspec))
+}
+
+func (r *namespacedRepo) RefExist(ref string) (bool, error) {
+	return r.Repo.RefExist(r.toNamespace(ref))
+}
+
+func (r *namespacedRepo) CopyRef(source string, dest string) error {
+	return r.Repo.CopyRef(r.toNamespace(source), r.toNamespace(dest))
+}
+
+func (r *namespacedRepo) ResolveRef(ref string) (util.Hash, error) {
+	return r.Repo.ResolveRef(r.toNamespace(ref))
+}
+
+func (r *namespacedRepo) UpdateSymbolicRef(ref string, target string) error {
+	return r.Repo.UpdateSymbolicRef(r.toNamespace(ref), r.toNamespace(target))
+}
+
+func (r *namespacedRepo) ResolveSymbolicRef(ref string) (string, error) {
+	resolved, err := r.Repo.ResolveSymbolicRef(r.toNamespace(ref))
+	if err != nil {
+		return "", err
+	}
+
+	return r.fromNamespace(resolved), nil
+}
+
+func (r *namespacedRepo) RemoveRef(ref string) error {
+	return r.Repo.RemoveRef(r.toNamespace(ref))
+}
+
+func (r *namespacedRepo) ListCommits(ref string) ([]util.Hash, error) {
+	return r.Repo.ListCommits(r.toNamespace(ref))
+}
+
+func (r *namespacedRepo) ListCommitParents(rev string) (map[util.Hash][]util.Hash, error) {
+	return r.Repo.ListCommitParents(r.toNamespace(rev))
+}
@@ -0,0 +1,42 @@
+package bug
+
+import (
+	"log"
+)
+
+// CommitObserver is notified when operations of a bug are committed, for
+// example to trigger an external automation
+type CommitObserver interface {
+	// OnCommit is called after a successful commit with the operations that
+	// were just committed
+	OnCommit(bug *Bug, newOps []Operation)
+}
+
+// RegisterObserver register an observer to notify after each commit of this
+// bug
+func (bug *Bug) RegisterObserver(o CommitObserver) {
+	bug.observers = append(bug.observers, o)
+}
+
+// notifyObservers call each observer in turn. An observer can't make the
+// commit fail: a panic is recovered and logged, and the next observers are
+// still notified.
+func (bug *Bug) notifyObservers(newOps []Operation) {
+	for _, o := range bug.observers {
+		notifyObserver(bug, o, newOps)
+	}
+}
+
+func notifyObserver(bug *Bug, o CommitObserver, newOps []Operation) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("commit observer of bug %s panicked: %v", bug.HumanId(), r)
+		}
+	}()
+
+	// the observer get its own copy, the packs are not to be altered
+	ops := make([]Operation, len(newOps))
+	copy(ops, newOps)
+
+	o.OnCommit(bug, ops)
+}
@@ -1,8 +1,15 @@
 package bug
 
 import (
-	"github.com/MichaelMure/git-bug/util"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
 )
 
 // OperationType is an identifier
@@ -15,28 +22,149 @@ const (
 	AddCommentOp
 	SetStatusOp
 	LabelChangeOp
+	SetAssigneeOp
+	LinkOp
+	ExternalRefOp
+	EditCommentOp
+	AddSignoffOp
+	DependencyOp
+	TriageOp
+	SetBuildStatusOp
+	SetFixVersionOp
 )
 
+func (t OperationType) String() string {
+	switch t {
+	case CreateOp:
+		return "create"
+	case SetTitleOp:
+		return "set_title"
+	case AddCommentOp:
+		return "add_comment"
+	case SetStatusOp:
+		return "set_status"
+	case LabelChangeOp:
+		return "label_change"
+	case SetAssigneeOp:
+		return "set_assignee"
+	case LinkOp:
+		return "link"
+	case ExternalRefOp:
+		return "external_ref"
+	case EditCommentOp:
+		return "edit_comment"
+	case AddSignoffOp:
+		return "add_signoff"
+	case DependencyOp:
+		return "dependency"
+	case TriageOp:
+		return "triage"
+	case SetBuildStatusOp:
+		return "set_build_status"
+	case SetFixVersionOp:
+		return "set_fix_version"
+	default:
+		return "unknown operation"
+	}
+}
+
 // Operation define the interface to fulfill for an edit operation of a Bug
 type Operation interface {
 	// OpType return the type of operation
 	OpType() OperationType
 	// Time return the time when the operation was added
 	Time() time.Time
+	// GetAuthor return the author of the operation
+	GetAuthor() Person
 	// Apply the operation to a Snapshot to create the final state
 	Apply(snapshot Snapshot) Snapshot
 	// Files return the files needed by this operation
 	Files() []util.Hash
+	// Parent return the hash of the operation this one is a response to, if any
+	Parent() util.Hash
+	// Hash return a stable hash of the content of the operation, see
+	// HashOperation
+	Hash() (util.Hash, error)
 
 	// TODO: data validation (ex: a title is a single line)
 	// Validate() bool
 }
 
+// ExternalPayloadOperation is implemented by the operations able to store a
+// large payload as a separate blob, with only its hash kept in the
+// OperationPack. This keeps the ops blob small and the history fast to scan.
+type ExternalPayloadOperation interface {
+	// ExternalizePayload return a copy of the operation with its payload
+	// stored as a separate blob if it's bigger than the given threshold
+	ExternalizePayload(repo repository.Repo, threshold int) (Operation, error)
+	// BindPayload return a copy of the operation reading its external
+	// payload, if any, from the repository only when first needed
+	BindPayload(repo repository.Repo) Operation
+	// LoadPayload return a copy of the operation with its bound external
+	// payload loaded back, if any
+	LoadPayload() (Operation, error)
+}
+
+// LazyBlob is a blob of the repository read only when first needed. The data
+// is then kept, so that the copies of an operation sharing it read it once.
+type LazyBlob struct {
+	repo repository.Repo
+	hash util.Hash
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// NewLazyBlob return a LazyBlob reading the given blob
+func NewLazyBlob(repo repository.Repo, hash util.Hash) *LazyBlob {
+	return &LazyBlob{repo: repo, hash: hash}
+}
+
+// Read return the data of the blob, read from the repository on the first
+// call only
+func (b *LazyBlob) Read() ([]byte, error) {
+	b.once.Do(func() {
+		b.data, b.err = b.repo.ReadData(b.hash)
+	})
+
+	return b.data, b.err
+}
+
+// ValidatingOperation is implemented by the operations able to check their
+// own data, so that an invalid one is refused before a commit
+type ValidatingOperation interface {
+	// Validate return an error describing the problem if the data of the
+	// operation is invalid
+	Validate() error
+}
+
+// AuthoredOperation is implemented by the operations able to return a copy of
+// themselves with another author, as operations are stored by value
+type AuthoredOperation interface {
+	// WithAuthor return a copy of the operation with the given author
+	WithAuthor(author Person) Operation
+}
+
+// ErrUnresolvedReference is returned when an operation reference something
+// that doesn't exist in the bug
+var ErrUnresolvedReference = errors.New("unresolved reference")
+
+// ReferencingOperation is implemented by the operations referencing a part of
+// the bug, like a comment, so that they can be checked before a commit
+type ReferencingOperation interface {
+	// CheckReferences return an ErrUnresolvedReference if a reference of the
+	// operation doesn't resolve in the snapshot it's about to be applied to
+	CheckReferences(snapshot Snapshot) error
+}
+
 // OpBase implement the common code for all operations
 type OpBase struct {
 	OperationType OperationType
 	Author        Person
 	UnixTime      int64
+	// Optional hash of the operation this one is a response to.
+	// This is only informative and doesn't affect the ordering.
+	ParentHash util.Hash
 }
 
 // NewOpBase is the constructor for an OpBase
@@ -62,3 +190,29 @@ func (op OpBase) Time() time.Time {
 func (op OpBase) Files() []util.Hash {
 	return nil
 }
+
+// Parent return the hash of the operation this one is a response to, if any
+func (op OpBase) Parent() util.Hash {
+	return op.ParentHash
+}
+
+// GetAuthor return the author of the operation
+func (op OpBase) GetAuthor() Person {
+	return op.Author
+}
+
+// SetAuthor change the author of the operation
+func (op *OpBase) SetAuthor(author Person) {
+	op.Author = author
+}
+
+// HashOperation compute a hash of the content of an operation, that can be
+// used to reference it from another operation
+func HashOperation(op Operation) (util.Hash, error) {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return "", err
+	}
+
+	return util.Hash(fmt.Sprintf("%x", sha1.Sum(data))), nil
+}
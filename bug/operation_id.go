@@ -0,0 +1,64 @@
+package bug
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// operationIdSeparator separate the commit hash from the index in an
+// operation id
+const operationIdSeparator = "-"
+
+// OperationId return the id of the operation at the given index in the pack
+// stored by the given commit. The id stay the same as long as the history of
+// the bug is not rewritten, so it can be used for a permalink.
+func OperationId(commit util.Hash, index int) string {
+	return fmt.Sprintf("%s%s%d", commit, operationIdSeparator, index)
+}
+
+// ParseOperationId split an operation id in the hash of the commit holding
+// the operation and the index of the operation in its pack
+func ParseOperationId(id string) (util.Hash, int, error) {
+	i := strings.LastIndex(id, operationIdSeparator)
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid operation id \"%s\"", id)
+	}
+
+	commit := util.Hash(id[:i])
+	if !commit.IsValid() {
+		return "", 0, fmt.Errorf("invalid operation id \"%s\"", id)
+	}
+
+	index, err := strconv.Atoi(id[i+len(operationIdSeparator):])
+	if err != nil || index < 0 {
+		return "", 0, fmt.Errorf("invalid operation id \"%s\"", id)
+	}
+
+	return commit, index, nil
+}
+
+// OperationById return the committed operation with the given id, or nil if
+// the bug doesn't have it
+func (bug *Bug) OperationById(id string) Operation {
+	commit, index, err := ParseOperationId(id)
+	if err != nil {
+		return nil
+	}
+
+	for _, pack := range bug.packs {
+		if pack.commitHash != commit {
+			continue
+		}
+
+		if index >= len(pack.Operations) {
+			return nil
+		}
+
+		return pack.Operations[index]
+	}
+
+	return nil
+}
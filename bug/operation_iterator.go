@@ -1,5 +1,7 @@
 package bug
 
+import "github.com/MichaelMure/git-bug/util"
+
 type OperationIterator struct {
 	bug       *Bug
 	packIndex int
@@ -15,35 +17,27 @@ func NewOperationIterator(bug *Bug) *OperationIterator {
 }
 
 func (it *OperationIterator) Next() bool {
-	// Special case of the staging area
-	if it.packIndex == len(it.bug.packs) {
-		pack := it.bug.staging
-		it.opIndex++
-		return it.opIndex < len(pack.Operations)
-	}
-
-	if it.packIndex >= len(it.bug.packs) {
-		return false
-	}
-
-	pack := it.bug.packs[it.packIndex]
-
 	it.opIndex++
 
-	if it.opIndex < len(pack.Operations) {
-		return true
-	}
+	// skip to the next pack with an operation left, if needed. Empty packs
+	// happen with unsupported data.
+	for {
+		// Special case of the staging area
+		if it.packIndex == len(it.bug.packs) {
+			return it.opIndex < len(it.bug.staging.Operations)
+		}
 
-	// Note: this iterator doesn't handle the empty pack case
-	it.opIndex = 0
-	it.packIndex++
+		if it.packIndex > len(it.bug.packs) {
+			return false
+		}
 
-	// Special case of the non-empty staging area
-	if it.packIndex == len(it.bug.packs) && len(it.bug.staging.Operations) > 0 {
-		return true
-	}
+		if it.opIndex < len(it.bug.packs[it.packIndex].Operations) {
+			return true
+		}
 
-	return it.packIndex < len(it.bug.packs)
+		it.opIndex = 0
+		it.packIndex++
+	}
 }
 
 func (it *OperationIterator) Value() Operation {
@@ -68,5 +62,46 @@ func (it *OperationIterator) Value() Operation {
 		panic("Iterator is not valid anymore")
 	}
 
-	return pack.Operations[it.opIndex]
+	op := pack.Operations[it.opIndex]
+
+	// the external payload is read on demand, a payload that can't be read
+	// is left out and reported when the operation is applied
+	if external, ok := op.(ExternalPayloadOperation); ok {
+		if loaded, err := external.LoadPayload(); err == nil {
+			return loaded
+		}
+	}
+
+	return op
+}
+
+// editTime return the logical edit time of the current operation, the one of
+// the pack holding it unless compacted. Operations in the staging area don't have one yet.
+func (it *OperationIterator) editTime() util.LamportTime {
+	if it.packIndex >= len(it.bug.packs) {
+		return 0
+	}
+
+	return it.bug.packs[it.packIndex].opEditTime(it.opIndex)
+}
+
+// commitHash return the hash of the commit holding the current operation.
+// Operations in the staging area don't have one yet.
+func (it *OperationIterator) commitHash() util.Hash {
+	if it.packIndex >= len(it.bug.packs) {
+		return ""
+	}
+
+	return it.bug.packs[it.packIndex].commitHash
+}
+
+// operationId return the id of the current operation, empty for the
+// operations in the staging area
+func (it *OperationIterator) operationId() string {
+	hash := it.commitHash()
+	if hash == "" {
+		return ""
+	}
+
+	return OperationId(hash, it.opIndex)
 }
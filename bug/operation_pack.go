@@ -2,12 +2,23 @@ package bug
 
 import (
 	"bytes"
+	"compress/flate"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"sync"
 
 	"github.com/MichaelMure/git-bug/repository"
 	"github.com/MichaelMure/git-bug/util"
 )
 
+// Payloads bigger than this size are stored as a separate blob
+const externalPayloadThreshold = 64 * 1024
+
 // OperationPack represent an ordered set of operation to apply
 // to a Bug. These operations are stored in a single Git commit.
 //
@@ -17,38 +28,190 @@ import (
 type OperationPack struct {
 	Operations []Operation
 
+	// The logical edit time of each operation, for a pack compacted from
+	// several commits. Empty otherwise, the operations sharing the edit time
+	// of the commit holding the pack.
+	EditTimes []util.LamportTime
+
 	// Private field so not serialized by gob
 	commitHash util.Hash
+	editTime   util.LamportTime
+	// the pack use a newer format and its operations couldn't be read
+	unsupported bool
+}
+
+// The serialized OperationPack start with a header holding the length and
+// a checksum of the gob payload, to detect a truncated or corrupted blob. The
+// magic start with a null byte, which a bare gob stream never does, so that
+// packs serialized before the header existed can still be read.
+const packMagic = "\x00gbp"
+const packFormatVersion = 1
+
+// magic, version, flags, payload length, payload checksum
+const packHeaderSize = len(packMagic) + 1 + 1 + 4 + 4
+
+// The payload is compressed with deflate. The length and the checksum are the
+// ones of the compressed payload.
+const packFlagCompressed = 1 << 0
+
+const packKnownFlags = packFlagCompressed
+
+// MaxPackSize is the size in bytes that the payload of a pack can't exceed
+// once decompressed, so that a crafted pack can't exhaust the memory
+const MaxPackSize = 32 * 1024 * 1024
+
+// The payload of a pack is compressed only above this size in bytes. The gob
+// type definitions make the bulk of a small pack: a single comment serialize
+// to about 650 bytes and only compress to 450, while 16 comments go from 3.8KB
+// to 0.5KB. Below the threshold, the gain is not worth the cost and git
+// compress its objects anyway.
+var (
+	packCompressionMutex     sync.RWMutex
+	packCompressionThreshold = 1024
+)
+
+// SetPackCompressionThreshold define the size in bytes above which the
+// payload of a pack is compressed. Zero or less disable the compression. It
+// apply to every repository of the process and can be called concurrently
+// with the commits.
+func SetPackCompressionThreshold(threshold int) {
+	packCompressionMutex.Lock()
+	defer packCompressionMutex.Unlock()
+
+	packCompressionThreshold = threshold
 }
 
+func getPackCompressionThreshold() int {
+	packCompressionMutex.RLock()
+	defer packCompressionMutex.RUnlock()
+
+	return packCompressionThreshold
+}
+
+var ErrTruncatedPack = errors.New("truncated operation pack")
+var ErrCorruptedPack = errors.New("corrupted operation pack")
+var ErrUnsupportedFormatVersion = errors.New("the data use a newer format version than supported")
+
 // ParseOperationPack will deserialize an OperationPack from raw bytes
 func ParseOperationPack(data []byte) (*OperationPack, error) {
-	reader := bytes.NewReader(data)
+	payload := data
+
+	if bytes.HasPrefix(data, []byte(packMagic)) {
+		if len(data) < packHeaderSize {
+			return nil, fmt.Errorf("%w: incomplete header", ErrTruncatedPack)
+		}
+
+		header := data[len(packMagic):packHeaderSize]
+		version := header[0]
+		flags := header[1]
+		length := binary.BigEndian.Uint32(header[2:6])
+		checksum := binary.BigEndian.Uint32(header[6:10])
+
+		if version > packFormatVersion {
+			return nil, fmt.Errorf("%w: operation pack version %d", ErrUnsupportedFormatVersion, version)
+		}
+		if version == 0 {
+			return nil, ErrCorruptedPack
+		}
+		if flags&^packKnownFlags != 0 {
+			return nil, fmt.Errorf("%w: operation pack flags %#x", ErrUnsupportedFormatVersion, flags)
+		}
+
+		payload = data[packHeaderSize:]
+
+		if uint32(len(payload)) < length {
+			return nil, fmt.Errorf("%w: %d bytes instead of %d", ErrTruncatedPack, len(payload), length)
+		}
+		if uint32(len(payload)) > length || crc32.ChecksumIEEE(payload) != checksum {
+			return nil, ErrCorruptedPack
+		}
+
+		if flags&packFlagCompressed != 0 {
+			// one byte more than allowed tell if the limit is exceeded
+			reader := io.LimitReader(flate.NewReader(bytes.NewReader(payload)), MaxPackSize+1)
+			decompressed, err := ioutil.ReadAll(reader)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrCorruptedPack, err)
+			}
+			if len(decompressed) > MaxPackSize {
+				return nil, fmt.Errorf("%w: more than %d bytes once decompressed", ErrCorruptedPack, MaxPackSize)
+			}
+			payload = decompressed
+		}
+	}
+
+	reader := bytes.NewReader(payload)
 	decoder := gob.NewDecoder(reader)
 
 	var opp OperationPack
 
 	err := decoder.Decode(&opp)
 
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrTruncatedPack
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
+	// rewrite the deprecated operations
+	for i, op := range opp.Operations {
+		opp.Operations[i], err = migrateOperation(op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &opp, nil
 }
 
 // Serialize will serialise an OperationPack into raw bytes
 func (opp *OperationPack) Serialize() ([]byte, error) {
-	var data bytes.Buffer
+	var payload bytes.Buffer
 
-	encoder := gob.NewEncoder(&data)
+	encoder := gob.NewEncoder(&payload)
 	err := encoder.Encode(*opp)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Bytes(), nil
+	// it couldn't be read back
+	if payload.Len() > MaxPackSize {
+		return nil, fmt.Errorf("the operation pack is too large: %d bytes, at most %d", payload.Len(), MaxPackSize)
+	}
+
+	var flags byte
+
+	if threshold := getPackCompressionThreshold(); threshold > 0 && payload.Len() > threshold {
+		var compressed bytes.Buffer
+
+		writer, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		_, err = writer.Write(payload.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		payload = compressed
+		flags |= packFlagCompressed
+	}
+
+	header := make([]byte, packHeaderSize)
+	copy(header, packMagic)
+	header[len(packMagic)] = packFormatVersion
+	header[len(packMagic)+1] = flags
+	binary.BigEndian.PutUint32(header[len(packMagic)+2:], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(header[len(packMagic)+6:], crc32.ChecksumIEEE(payload.Bytes()))
+
+	return append(header, payload.Bytes()...), nil
 }
 
 // Append a new operation to the pack
@@ -56,6 +219,15 @@ func (opp *OperationPack) Append(op Operation) {
 	opp.Operations = append(opp.Operations, op)
 }
 
+// opEditTime return the logical edit time of the operation at the given index
+func (opp *OperationPack) opEditTime(index int) util.LamportTime {
+	if index < len(opp.EditTimes) {
+		return opp.EditTimes[index]
+	}
+
+	return opp.editTime
+}
+
 // IsEmpty tell if the OperationPack is empty
 func (opp *OperationPack) IsEmpty() bool {
 	return len(opp.Operations) == 0
@@ -63,7 +235,8 @@ func (opp *OperationPack) IsEmpty() bool {
 
 // IsValid tell if the OperationPack is considered valid
 func (opp *OperationPack) IsValid() bool {
-	return !opp.IsEmpty()
+	// an unsupported pack can't be checked, but is not invalid as such
+	return opp.unsupported || !opp.IsEmpty()
 }
 
 // Write will serialize and store the OperationPack as a git blob and return
@@ -84,12 +257,77 @@ func (opp *OperationPack) Write(repo repository.Repo) (util.Hash, error) {
 	return hash, nil
 }
 
+// defaultAuthor set the author of the operations that have none to the
+// identity configured in the repository
+func (opp *OperationPack) defaultAuthor(repo repository.Repo) error {
+	var identity *Person
+
+	for i, op := range opp.Operations {
+		if op.GetAuthor() != (Person{}) {
+			continue
+		}
+
+		authored, ok := op.(AuthoredOperation)
+		if !ok {
+			continue
+		}
+
+		if identity == nil {
+			name, email, err := repo.GetUserIdentity()
+			if err != nil {
+				return err
+			}
+			identity = &Person{Name: name, Email: email}
+		}
+
+		opp.Operations[i] = authored.WithAuthor(*identity)
+	}
+
+	return nil
+}
+
+// externalizePayloads return a copy of the pack where the large payloads of
+// the operations are stored as separate blobs
+func (opp *OperationPack) externalizePayloads(repo repository.Repo) (OperationPack, error) {
+	result := opp.Clone()
+
+	for i, op := range result.Operations {
+		external, ok := op.(ExternalPayloadOperation)
+		if !ok {
+			continue
+		}
+
+		newOp, err := external.ExternalizePayload(repo, externalPayloadThreshold)
+		if err != nil {
+			return OperationPack{}, err
+		}
+
+		result.Operations[i] = newOp
+	}
+
+	return result, nil
+}
+
+// bindPayloads bind in place the external payloads of the operations to the
+// repository, so that they are read only when first needed, for example by
+// Compile
+func (opp *OperationPack) bindPayloads(repo repository.Repo) {
+	for i, op := range opp.Operations {
+		if external, ok := op.(ExternalPayloadOperation); ok {
+			opp.Operations[i] = external.BindPayload(repo)
+		}
+	}
+}
+
 // Make a deep copy
 func (opp *OperationPack) Clone() OperationPack {
 
 	clone := OperationPack{
-		Operations: make([]Operation, len(opp.Operations)),
-		commitHash: opp.commitHash,
+		Operations:  make([]Operation, len(opp.Operations)),
+		EditTimes:   append([]util.LamportTime(nil), opp.EditTimes...),
+		commitHash:  opp.commitHash,
+		editTime:    opp.editTime,
+		unsupported: opp.unsupported,
 	}
 
 	for i, op := range opp.Operations {
@@ -1,27 +1,57 @@
 package operations
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/repository"
 	"github.com/MichaelMure/git-bug/util"
 )
 
 // AddCommentOperation will add a new comment in the bug
 
 var _ bug.Operation = AddCommentOperation{}
+var _ bug.AuthoredOperation = AddCommentOperation{}
+var _ bug.ExternalPayloadOperation = AddCommentOperation{}
+var _ bug.CommentOperation = AddCommentOperation{}
+var _ bug.AttachmentOperation = AddCommentOperation{}
 
 type AddCommentOperation struct {
 	bug.OpBase
 	Message string
+	// Files attached by the author
 	// TODO: change for a map[string]util.hash to store the filename ?
-	files []util.Hash
+	FileHashes []util.Hash `json:",omitempty"`
+	CodeBlocks []bug.CodeBlock
+	// Hash of the blob holding the message, when too large to be stored
+	// inline. Where the message is stored is not part of the identity of the
+	// comment.
+	MessageHash util.Hash `json:"-"`
+	// Thumbnail of the image files, keyed by the hash of the file
+	Thumbnails map[util.Hash]util.Hash
+
+	// the external message, read when first needed
+	payload *bug.LazyBlob
 }
 
 func (op AddCommentOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	loaded, err := op.LoadPayload()
+	if err != nil {
+		snapshot.Warnings = append(snapshot.Warnings,
+			fmt.Sprintf("the message %s of a comment can't be read: %v", op.MessageHash, err))
+	} else {
+		op = loaded.(AddCommentOperation)
+	}
+
 	comment := bug.Comment{
-		Message:  op.Message,
-		Author:   op.Author,
-		Files:    op.files,
-		UnixTime: op.UnixTime,
+		Message:    op.Message,
+		Author:     op.Author,
+		Files:      op.FileHashes,
+		CodeBlocks: op.CodeBlocks,
+		Thumbnails: op.Thumbnails,
+		UnixTime:   op.UnixTime,
 	}
 
 	snapshot.Comments = append(snapshot.Comments, comment)
@@ -29,24 +59,154 @@ func (op AddCommentOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
 	return snapshot
 }
 
+func (op AddCommentOperation) Hash() (util.Hash, error) {
+	// the hash is the one of the inline form, stored externally or not
+	loaded, err := op.LoadPayload()
+	if err != nil {
+		return "", err
+	}
+
+	return bug.HashOperation(loaded)
+}
+
+func (op AddCommentOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
 func (op AddCommentOperation) Files() []util.Hash {
-	return op.files
+	if op.MessageHash == "" && len(op.Thumbnails) == 0 {
+		return op.FileHashes
+	}
+
+	files := op.FileHashes[:len(op.FileHashes):len(op.FileHashes)]
+
+	// the external message and the thumbnails need to be referenced to be
+	// pushed/pulled
+	if op.MessageHash != "" {
+		files = append(files, op.MessageHash)
+	}
+	thumbnails := make([]util.Hash, 0, len(op.Thumbnails))
+	for _, thumbnail := range op.Thumbnails {
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	sort.Slice(thumbnails, func(i, j int) bool { return thumbnails[i] < thumbnails[j] })
+	files = append(files, thumbnails...)
+
+	return files
+}
+
+func (op AddCommentOperation) AttachedFiles() []util.Hash {
+	return op.FileHashes
+}
+
+func (op AddCommentOperation) ExternalizePayload(repo repository.Repo, threshold int) (bug.Operation, error) {
+	if len(op.Message) <= threshold {
+		return op, nil
+	}
+
+	hash, err := repo.StoreData([]byte(op.Message))
+	if err != nil {
+		return nil, err
+	}
+
+	op.Message = ""
+	op.MessageHash = hash
+
+	return op, nil
+}
+
+func (op AddCommentOperation) BindPayload(repo repository.Repo) bug.Operation {
+	if op.MessageHash == "" || op.Message != "" {
+		return op
+	}
+
+	op.payload = bug.NewLazyBlob(repo, op.MessageHash)
+
+	return op
+}
+
+func (op AddCommentOperation) LoadPayload() (bug.Operation, error) {
+	if op.payload == nil {
+		return op, nil
+	}
+
+	data, err := op.payload.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	op.Message = string(data)
+	op.payload = nil
+
+	return op, nil
+}
+
+func (op AddCommentOperation) CommentBody() string {
+	loaded, err := op.LoadPayload()
+	if err != nil {
+		return op.Message
+	}
+
+	return loaded.(AddCommentOperation).Message
 }
 
 func NewAddCommentOp(author bug.Person, message string, files []util.Hash) AddCommentOperation {
 	return AddCommentOperation{
-		OpBase:  bug.NewOpBase(bug.AddCommentOp, author),
-		Message: message,
-		files:   files,
+		OpBase:     bug.NewOpBase(bug.AddCommentOp, author),
+		Message:    message,
+		FileHashes: files,
 	}
 }
 
 // Convenience function to apply the operation
-func Comment(b *bug.Bug, author bug.Person, message string) {
-	CommentWithFiles(b, author, message, nil)
+func Comment(b *bug.Bug, author bug.Person, message string) error {
+	return CommentWithFiles(b, author, message, nil)
 }
 
-func CommentWithFiles(b *bug.Bug, author bug.Person, message string, files []util.Hash) {
+func CommentWithFiles(b *bug.Bug, author bug.Person, message string, files []util.Hash) error {
 	addCommentOp := NewAddCommentOp(author, message, files)
-	b.Append(addCommentOp)
+	return b.Append(addCommentOp)
+}
+
+// Convenience function to add a comment with some attached files, stored in
+// the repository. A thumbnail is generated for the images if requested.
+func CommentWithAttachments(repo repository.Repo, b *bug.Bug, author bug.Person, message string, attachments [][]byte, withThumbnails bool) error {
+	var files []util.Hash
+	thumbnails := make(map[util.Hash]util.Hash)
+
+	for _, data := range attachments {
+		file, thumbnail, err := bug.StoreAttachment(repo, data, withThumbnails)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, file)
+		if thumbnail != "" {
+			thumbnails[file] = thumbnail
+		}
+	}
+
+	addCommentOp := NewAddCommentOp(author, message, files)
+	if len(thumbnails) > 0 {
+		addCommentOp.Thumbnails = thumbnails
+	}
+
+	return b.Append(addCommentOp)
+}
+
+// Convenience function to add a comment with a snippet of code or a diff
+func CommentWithCode(b *bug.Bug, author bug.Person, message string, language string, code string) error {
+	addCommentOp := NewAddCommentOp(author, message, nil)
+	addCommentOp.CodeBlocks = []bug.CodeBlock{
+		{Language: strings.ToLower(strings.TrimSpace(language)), Content: code},
+	}
+	return b.Append(addCommentOp)
+}
+
+// Convenience function to add a comment in response to another operation
+func Reply(b *bug.Bug, author bug.Person, parent util.Hash, message string) error {
+	addCommentOp := NewAddCommentOp(author, message, nil)
+	addCommentOp.ParentHash = parent
+	return b.Append(addCommentOp)
 }
@@ -0,0 +1,63 @@
+package operations
+
+import (
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// AddSignoffOperation will record a sign-off, like a QA verification. A
+// later sign-off from the same author and role supersede the previous one.
+
+var _ bug.Operation = AddSignoffOperation{}
+var _ bug.AuthoredOperation = AddSignoffOperation{}
+
+type AddSignoffOperation struct {
+	bug.OpBase
+	Role bug.SignoffRole
+	Note string
+}
+
+func (op AddSignoffOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	signoff := bug.Signoff{
+		Role:     op.Role,
+		Author:   op.Author,
+		Note:     op.Note,
+		UnixTime: op.UnixTime,
+	}
+
+	// copy to not alter a previous snapshot
+	signoffs := make([]bug.Signoff, 0, len(snapshot.Signoffs)+1)
+
+	for _, existing := range snapshot.Signoffs {
+		if existing.Role != op.Role || existing.Author != op.Author {
+			signoffs = append(signoffs, existing)
+		}
+	}
+
+	snapshot.Signoffs = append(signoffs, signoff)
+
+	return snapshot
+}
+
+func (op AddSignoffOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op AddSignoffOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewAddSignoffOp(author bug.Person, role bug.SignoffRole, note string) AddSignoffOperation {
+	return AddSignoffOperation{
+		OpBase: bug.NewOpBase(bug.AddSignoffOp, author),
+		Role:   role,
+		Note:   note,
+	}
+}
+
+// Convenience function to apply the operation
+func Signoff(b *bug.Bug, author bug.Person, role bug.SignoffRole, note string) {
+	op := NewAddSignoffOp(author, role, note)
+	b.Append(op)
+}
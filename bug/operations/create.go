@@ -8,16 +8,26 @@ import (
 // CreateOperation define the initial creation of a bug
 
 var _ bug.Operation = CreateOperation{}
+var _ bug.AuthoredOperation = CreateOperation{}
+var _ bug.CommentOperation = CreateOperation{}
+var _ bug.AttachmentOperation = CreateOperation{}
 
 type CreateOperation struct {
 	bug.OpBase
 	Title   string
 	Message string
-	files   []util.Hash
+	// Initial status of the bug, open if not set
+	Status bug.Status
+	// Files attached by the author
+	FileHashes []util.Hash `json:",omitempty"`
 }
 
 func (op CreateOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
 	snapshot.Title = op.Title
+	snapshot.Status = op.Status
+	if snapshot.Status == 0 {
+		snapshot.Status = bug.OpenStatus
+	}
 	snapshot.Comments = []bug.Comment{
 		{
 			Message:  op.Message,
@@ -30,16 +40,33 @@ func (op CreateOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
 	return snapshot
 }
 
+func (op CreateOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op CreateOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
 func (op CreateOperation) Files() []util.Hash {
-	return op.files
+	return op.FileHashes
+}
+
+func (op CreateOperation) AttachedFiles() []util.Hash {
+	return op.FileHashes
+}
+
+func (op CreateOperation) CommentBody() string {
+	return op.Message
 }
 
 func NewCreateOp(author bug.Person, title, message string, files []util.Hash) CreateOperation {
 	return CreateOperation{
-		OpBase:  bug.NewOpBase(bug.CreateOp, author),
-		Title:   title,
-		Message: message,
-		files:   files,
+		OpBase:     bug.NewOpBase(bug.CreateOp, author),
+		Title:      title,
+		Message:    message,
+		FileHashes: files,
 	}
 }
 
@@ -48,10 +75,29 @@ func Create(author bug.Person, title, message string) (*bug.Bug, error) {
 	return CreateWithFiles(author, title, message, nil)
 }
 
+// CreateWithStatus create a bug with a different initial status than open,
+// for example to import a bug from another system
+func CreateWithStatus(author bug.Person, title, message string, status bug.Status) (*bug.Bug, error) {
+	newBug := bug.NewBug()
+	createOp := NewCreateOp(author, title, message, nil)
+	createOp.Status = status
+
+	err := newBug.Append(createOp)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBug, nil
+}
+
 func CreateWithFiles(author bug.Person, title, message string, files []util.Hash) (*bug.Bug, error) {
 	newBug := bug.NewBug()
 	createOp := NewCreateOp(author, title, message, files)
-	newBug.Append(createOp)
+
+	err := newBug.Append(createOp)
+	if err != nil {
+		return nil, err
+	}
 
 	return newBug, nil
 }
@@ -19,7 +19,8 @@ func TestCreate(t *testing.T) {
 	snapshot = create.Apply(snapshot)
 
 	expected := bug.Snapshot{
-		Title: "title",
+		Title:  "title",
+		Status: bug.OpenStatus,
 		Comments: []bug.Comment{
 			{Author: rene, Message: "message", UnixTime: create.UnixTime},
 		},
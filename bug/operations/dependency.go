@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// DependencyOperation will add or remove a bug that need to be fixed before
+// this one
+
+var _ bug.Operation = DependencyOperation{}
+var _ bug.AuthoredOperation = DependencyOperation{}
+
+type DependencyOperation struct {
+	bug.OpBase
+	// Id of the bug this one depends on
+	Target  string
+	Removed bool
+}
+
+func (op DependencyOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	// copy to not alter a previous snapshot
+	dependsOn := make([]string, 0, len(snapshot.DependsOn)+1)
+
+	for _, id := range snapshot.DependsOn {
+		if id != op.Target {
+			dependsOn = append(dependsOn, id)
+		}
+	}
+
+	if !op.Removed {
+		dependsOn = append(dependsOn, op.Target)
+	}
+
+	snapshot.DependsOn = dependsOn
+
+	return snapshot
+}
+
+func (op DependencyOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op DependencyOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewDependencyOp(author bug.Person, target string, removed bool) DependencyOperation {
+	return DependencyOperation{
+		OpBase:  bug.NewOpBase(bug.DependencyOp, author),
+		Target:  target,
+		Removed: removed,
+	}
+}
+
+// Convenience function to apply the operation
+func DependsOn(b *bug.Bug, author bug.Person, target string) {
+	op := NewDependencyOp(author, target, false)
+	b.Append(op)
+}
+
+// Convenience function to apply the operation
+func RemoveDependency(b *bug.Bug, author bug.Person, target string) {
+	op := NewDependencyOp(author, target, true)
+	b.Append(op)
+}
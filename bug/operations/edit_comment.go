@@ -0,0 +1,103 @@
+package operations
+
+import (
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// EditCommentOperation will replace the message of a comment, keeping the
+// previous versions in its edit history
+
+var _ bug.Operation = EditCommentOperation{}
+var _ bug.AuthoredOperation = EditCommentOperation{}
+var _ bug.ReferencingOperation = EditCommentOperation{}
+var _ bug.CommentOperation = EditCommentOperation{}
+
+type EditCommentOperation struct {
+	bug.OpBase
+	// Id of the edited comment, that is the hash of the operation that
+	// created it
+	Target  util.Hash
+	Message string
+}
+
+func (op EditCommentOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	for i, comment := range snapshot.Comments {
+		if comment.Id != op.Target {
+			continue
+		}
+
+		// copy to not alter the history of a previous snapshot
+		history := make([]bug.CommentVersion, 0, len(comment.EditHistory)+2)
+		history = append(history, comment.EditHistory...)
+
+		if len(history) == 0 {
+			history = append(history, bug.CommentVersion{
+				Message:  comment.Message,
+				UnixTime: comment.UnixTime,
+			})
+		}
+
+		history = append(history, bug.CommentVersion{
+			Message:  op.Message,
+			UnixTime: op.UnixTime,
+		})
+
+		comments := make([]bug.Comment, len(snapshot.Comments))
+		copy(comments, snapshot.Comments)
+
+		comments[i].Message = op.Message
+		comments[i].EditHistory = history
+		snapshot.Comments = comments
+
+		break
+	}
+
+	return snapshot
+}
+
+func (op EditCommentOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op EditCommentOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func (op EditCommentOperation) CheckReferences(snapshot bug.Snapshot) error {
+	for _, comment := range snapshot.Comments {
+		if comment.Id == op.Target {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: no comment %s", bug.ErrUnresolvedReference, op.Target)
+}
+
+func (op EditCommentOperation) CommentBody() string {
+	return op.Message
+}
+
+func NewEditCommentOp(author bug.Person, target util.Hash, message string) EditCommentOperation {
+	return EditCommentOperation{
+		OpBase:  bug.NewOpBase(bug.EditCommentOp, author),
+		Target:  target,
+		Message: message,
+	}
+}
+
+// Convenience function to apply the operation. The edited comment must exist
+// in the bug, including its staging area.
+func EditComment(b *bug.Bug, author bug.Person, target util.Hash, message string) error {
+	op := NewEditCommentOp(author, target, message)
+
+	err := op.CheckReferences(b.Compile())
+	if err != nil {
+		return err
+	}
+
+	return b.Append(op)
+}
@@ -0,0 +1,50 @@
+package operations
+
+import (
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ExternalRefOperation will add a reference to something outside of the bug,
+// like a git commit
+
+var _ bug.Operation = ExternalRefOperation{}
+var _ bug.AuthoredOperation = ExternalRefOperation{}
+
+type ExternalRefOperation struct {
+	bug.OpBase
+	Kind   bug.ExternalRefKind
+	Target string
+}
+
+func (op ExternalRefOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	snapshot.ExternalRefs = append(snapshot.ExternalRefs, bug.ExternalRef{
+		Kind:   op.Kind,
+		Target: op.Target,
+	})
+
+	return snapshot
+}
+
+func (op ExternalRefOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op ExternalRefOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewExternalRefOp(author bug.Person, kind bug.ExternalRefKind, target string) ExternalRefOperation {
+	return ExternalRefOperation{
+		OpBase: bug.NewOpBase(bug.ExternalRefOp, author),
+		Kind:   kind,
+		Target: target,
+	}
+}
+
+// Convenience function to apply the operation
+func ReferenceCommit(b *bug.Bug, author bug.Person, commit string) {
+	op := NewExternalRefOp(author, bug.ExternalRefCommit, commit)
+	b.Append(op)
+}
@@ -7,9 +7,11 @@ import (
 	"sort"
 
 	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
 )
 
 var _ bug.Operation = LabelChangeOperation{}
+var _ bug.AuthoredOperation = LabelChangeOperation{}
 
 // LabelChangeOperation define a Bug operation to add or remove labels
 type LabelChangeOperation struct {
@@ -20,37 +22,68 @@ type LabelChangeOperation struct {
 
 // Apply apply the operation
 func (op LabelChangeOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
-	// Add in the set
-AddLoop:
-	for _, added := range op.Added {
-		for _, label := range snapshot.Labels {
-			if label == added {
-				// Already exist
-				continue AddLoop
-			}
-		}
+	// an invalid operation is ignored as a whole
+	if err := op.Validate(); err != nil {
+		snapshot.Warnings = append(snapshot.Warnings, err.Error())
+		return snapshot
+	}
 
-		snapshot.Labels = append(snapshot.Labels, added)
+	// build a new set, to not alter a previous snapshot
+	labels := make([]bug.Label, 0, len(snapshot.Labels)+len(op.Added))
+
+	for _, label := range snapshot.Labels {
+		// removing a label that isn't set does nothing
+		if !labelExist(op.Removed, label) {
+			labels = append(labels, label)
+		}
 	}
 
-	// Remove in the set
-	for _, removed := range op.Removed {
-		for i, label := range snapshot.Labels {
-			if label == removed {
-				snapshot.Labels[i] = snapshot.Labels[len(snapshot.Labels)-1]
-				snapshot.Labels = snapshot.Labels[:len(snapshot.Labels)-1]
-			}
+	for _, added := range op.Added {
+		if !labelExist(labels, added) {
+			labels = append(labels, added)
 		}
 	}
 
 	// Sort
-	sort.Slice(snapshot.Labels, func(i, j int) bool {
-		return string(snapshot.Labels[i]) < string(snapshot.Labels[j])
+	sort.Slice(labels, func(i, j int) bool {
+		return string(labels[i]) < string(labels[j])
 	})
 
+	snapshot.Labels = labels
+
 	return snapshot
 }
 
+func (op LabelChangeOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op LabelChangeOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+// Validate check that the labels are not empty and that a label is not both
+// added and removed
+func (op LabelChangeOperation) Validate() error {
+	for _, label := range op.Added {
+		if label == "" {
+			return fmt.Errorf("empty label")
+		}
+		if labelExist(op.Removed, label) {
+			return fmt.Errorf("label \"%s\" is both added and removed", label)
+		}
+	}
+
+	for _, label := range op.Removed {
+		if label == "" {
+			return fmt.Errorf("empty label")
+		}
+	}
+
+	return nil
+}
+
 func NewLabelChangeOperation(author bug.Person, added, removed []bug.Label) LabelChangeOperation {
 	return LabelChangeOperation{
 		OpBase:  bug.NewOpBase(bug.LabelChangeOp, author),
@@ -67,6 +100,14 @@ func ChangeLabels(out io.Writer, b *bug.Bug, author bug.Person, add, remove []st
 		out = ioutil.Discard
 	}
 
+	for _, str := range add {
+		for _, other := range remove {
+			if str == other {
+				return fmt.Errorf("label \"%s\" is both added and removed", str)
+			}
+		}
+	}
+
 	snap := b.Compile()
 
 	for _, str := range add {
@@ -111,9 +152,12 @@ func ChangeLabels(out io.Writer, b *bug.Bug, author bug.Person, add, remove []st
 
 	labelOp := NewLabelChangeOperation(author, added, removed)
 
-	b.Append(labelOp)
+	err := labelOp.Validate()
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return b.Append(labelOp)
 }
 
 func labelExist(labels []bug.Label, label bug.Label) bool {
@@ -0,0 +1,76 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+)
+
+func TestLabelChangeApply(t *testing.T) {
+	var rene = bug.Person{
+		Name:  "René Descartes",
+		Email: "rene@descartes.fr",
+	}
+
+	snapshot := bug.Snapshot{Labels: []bug.Label{"bug", "ui"}}
+	previous := append([]bug.Label(nil), snapshot.Labels...)
+
+	// removing a label that isn't set is not an error
+	op := NewLabelChangeOperation(rene, []bug.Label{"core", "bug"}, []bug.Label{"ui", "missing"})
+	if err := op.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := op.Apply(snapshot)
+
+	if !reflect.DeepEqual(result.Labels, []bug.Label{"bug", "core"}) {
+		t.Fatalf("unexpected labels %v", result.Labels)
+	}
+
+	if !reflect.DeepEqual(snapshot.Labels, previous) {
+		t.Fatal("the previous snapshot should not be altered")
+	}
+
+	both := NewLabelChangeOperation(rene, []bug.Label{"ui"}, []bug.Label{"ui"})
+	if both.Validate() == nil {
+		t.Fatal("a label both added and removed should be invalid")
+	}
+
+	result = both.Apply(snapshot)
+	if !reflect.DeepEqual(result.Labels, previous) || len(result.Warnings) != 1 {
+		t.Fatal("an invalid operation should be ignored with a warning")
+	}
+
+	empty := NewLabelChangeOperation(rene, nil, []bug.Label{""})
+	if empty.Validate() == nil {
+		t.Fatal("an empty label should be invalid")
+	}
+}
+
+func TestChangeLabelsValidation(t *testing.T) {
+	var rene = bug.Person{
+		Name:  "René Descartes",
+		Email: "rene@descartes.fr",
+	}
+
+	b, err := Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ChangeLabels(nil, b, rene, []string{"ui"}, []string{"ui"}) == nil {
+		t.Fatal("a label both added and removed should be rejected")
+	}
+
+	if ChangeLabels(nil, b, rene, []string{""}, nil) == nil {
+		t.Fatal("an empty label should be rejected")
+	}
+
+	// an invalid operation appended directly is refused at commit time
+	b.Append(NewLabelChangeOperation(rene, []bug.Label{""}, nil))
+
+	if b.ValidateStaging() == nil {
+		t.Fatal("the invalid operation should be refused")
+	}
+}
@@ -0,0 +1,51 @@
+package operations
+
+import (
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// LinkOperation will record a cross-reference to another bug
+
+var _ bug.Operation = LinkOperation{}
+var _ bug.AuthoredOperation = LinkOperation{}
+
+type LinkOperation struct {
+	bug.OpBase
+	// Id of the referenced bug
+	Target string
+}
+
+func (op LinkOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	for _, link := range snapshot.Links {
+		if link == op.Target {
+			return snapshot
+		}
+	}
+
+	snapshot.Links = append(snapshot.Links, op.Target)
+
+	return snapshot
+}
+
+func (op LinkOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op LinkOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewLinkOp(author bug.Person, target string) LinkOperation {
+	return LinkOperation{
+		OpBase: bug.NewOpBase(bug.LinkOp, author),
+		Target: target,
+	}
+}
+
+// Convenience function to apply the operation
+func Link(b *bug.Bug, author bug.Person, target string) {
+	op := NewLinkOp(author, target)
+	b.Append(op)
+}
@@ -9,4 +9,13 @@ func init() {
 	gob.Register(SetTitleOperation{})
 	gob.Register(SetStatusOperation{})
 	gob.Register(LabelChangeOperation{})
+	gob.Register(SetAssigneeOperation{})
+	gob.Register(LinkOperation{})
+	gob.Register(ExternalRefOperation{})
+	gob.Register(EditCommentOperation{})
+	gob.Register(AddSignoffOperation{})
+	gob.Register(DependencyOperation{})
+	gob.Register(TriageOperation{})
+	gob.Register(SetBuildStatusOperation{})
+	gob.Register(SetFixVersionOperation{})
 }
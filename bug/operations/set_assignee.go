@@ -0,0 +1,51 @@
+package operations
+
+import (
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// SetAssigneeOperation will change the person in charge of a bug
+
+var _ bug.Operation = SetAssigneeOperation{}
+var _ bug.AuthoredOperation = SetAssigneeOperation{}
+
+type SetAssigneeOperation struct {
+	bug.OpBase
+	// The zero value means that the bug is unassigned
+	Assignee bug.Person
+}
+
+func (op SetAssigneeOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	snapshot.Assignee = op.Assignee
+
+	return snapshot
+}
+
+func (op SetAssigneeOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op SetAssigneeOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewSetAssigneeOp(author bug.Person, assignee bug.Person) SetAssigneeOperation {
+	return SetAssigneeOperation{
+		OpBase:   bug.NewOpBase(bug.SetAssigneeOp, author),
+		Assignee: assignee,
+	}
+}
+
+// Convenience function to apply the operation
+func Assign(b *bug.Bug, author bug.Person, assignee bug.Person) {
+	op := NewSetAssigneeOp(author, assignee)
+	b.Append(op)
+}
+
+// Convenience function to apply the operation
+func Unassign(b *bug.Bug, author bug.Person) {
+	op := NewSetAssigneeOp(author, bug.Person{})
+	b.Append(op)
+}
@@ -0,0 +1,73 @@
+package operations
+
+import (
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// SetBuildStatusOperation will record the status of a CI build associated
+// with the bug. A later status of the same build context supersede the
+// previous one.
+
+var _ bug.Operation = SetBuildStatusOperation{}
+var _ bug.AuthoredOperation = SetBuildStatusOperation{}
+
+type SetBuildStatusOperation struct {
+	bug.OpBase
+	Context   string
+	State     bug.BuildState
+	TargetURL string
+}
+
+func (op SetBuildStatusOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	// copy to not alter a previous snapshot
+	statuses := make(map[string]bug.BuildStatus, len(snapshot.BuildStatuses)+1)
+	for context, status := range snapshot.BuildStatuses {
+		statuses[context] = status
+	}
+
+	statuses[op.Context] = bug.BuildStatus{
+		Context:   op.Context,
+		State:     op.State,
+		TargetURL: op.TargetURL,
+		Author:    op.Author,
+		UnixTime:  op.UnixTime,
+	}
+
+	snapshot.BuildStatuses = statuses
+
+	return snapshot
+}
+
+func (op SetBuildStatusOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op SetBuildStatusOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewSetBuildStatusOp(author bug.Person, context string, state bug.BuildState, targetURL string) SetBuildStatusOperation {
+	return SetBuildStatusOperation{
+		OpBase:    bug.NewOpBase(bug.SetBuildStatusOp, author),
+		Context:   context,
+		State:     state,
+		TargetURL: targetURL,
+	}
+}
+
+// Convenience function to apply the operation
+func SetBuildStatus(b *bug.Bug, author bug.Person, context string, state bug.BuildState, targetURL string) error {
+	if context == "" {
+		return fmt.Errorf("empty build context")
+	}
+	if !state.IsValid() {
+		return fmt.Errorf("unknown build state %s", state)
+	}
+
+	op := NewSetBuildStatusOp(author, context, state, targetURL)
+	return b.Append(op)
+}
@@ -0,0 +1,62 @@
+package operations
+
+import (
+	"fmt"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// SetFixVersionOperation will record the version a closed bug was fixed in.
+// The version is cleared when the bug is reopened.
+
+var _ bug.Operation = SetFixVersionOperation{}
+var _ bug.AuthoredOperation = SetFixVersionOperation{}
+
+type SetFixVersionOperation struct {
+	bug.OpBase
+	Version string
+}
+
+func (op SetFixVersionOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	// the bug might have been reopened concurrently
+	if !snapshot.Status.IsClosed() {
+		snapshot.Warnings = append(snapshot.Warnings,
+			fmt.Sprintf("fix version %s ignored, the bug is not closed", op.Version))
+		return snapshot
+	}
+
+	snapshot.FixVersion = op.Version
+
+	return snapshot
+}
+
+func (op SetFixVersionOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op SetFixVersionOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewSetFixVersionOp(author bug.Person, version string) SetFixVersionOperation {
+	return SetFixVersionOperation{
+		OpBase:  bug.NewOpBase(bug.SetFixVersionOp, author),
+		Version: version,
+	}
+}
+
+// Convenience function to apply the operation
+func SetFixVersion(b *bug.Bug, author bug.Person, version string) error {
+	if version == "" {
+		return fmt.Errorf("empty fix version")
+	}
+
+	if !b.Compile().Status.IsClosed() {
+		return fmt.Errorf("the fix version can only be set on a closed bug")
+	}
+
+	op := NewSetFixVersionOp(author, version)
+	return b.Append(op)
+}
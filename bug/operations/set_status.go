@@ -2,11 +2,13 @@ package operations
 
 import (
 	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
 )
 
 // SetStatusOperation will change the status of a bug
 
 var _ bug.Operation = SetStatusOperation{}
+var _ bug.AuthoredOperation = SetStatusOperation{}
 
 type SetStatusOperation struct {
 	bug.OpBase
@@ -16,9 +18,23 @@ type SetStatusOperation struct {
 func (op SetStatusOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
 	snapshot.Status = op.Status
 
+	// a reopened bug is not fixed anymore
+	if !op.Status.IsClosed() {
+		snapshot.FixVersion = ""
+	}
+
 	return snapshot
 }
 
+func (op SetStatusOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op SetStatusOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
 func NewSetStatusOp(author bug.Person, status bug.Status) SetStatusOperation {
 	return SetStatusOperation{
 		OpBase: bug.NewOpBase(bug.SetStatusOp, author),
@@ -37,3 +53,9 @@ func Close(b *bug.Bug, author bug.Person) {
 	op := NewSetStatusOp(author, bug.ClosedStatus)
 	b.Append(op)
 }
+
+// Convenience function to apply the operation
+func StartProgress(b *bug.Bug, author bug.Person) {
+	op := NewSetStatusOp(author, bug.InProgressStatus)
+	b.Append(op)
+}
@@ -2,11 +2,13 @@ package operations
 
 import (
 	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
 )
 
 // SetTitleOperation will change the title of a bug
 
 var _ bug.Operation = SetTitleOperation{}
+var _ bug.AuthoredOperation = SetTitleOperation{}
 
 type SetTitleOperation struct {
 	bug.OpBase
@@ -20,6 +22,15 @@ func (op SetTitleOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
 	return snapshot
 }
 
+func (op SetTitleOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op SetTitleOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
 func NewSetTitleOp(author bug.Person, title string, was string) SetTitleOperation {
 	return SetTitleOperation{
 		OpBase: bug.NewOpBase(bug.SetTitleOp, author),
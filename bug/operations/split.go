@@ -0,0 +1,66 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// SplitBug create a new bug from one of the comment of an existing bug, for
+// when a single report actually cover two different issues. The new bug is
+// seeded with the comment's author, message and files, and both bugs get a
+// link to each other. The history of the original bug is preserved.
+func SplitBug(repo repository.Repo, id string, fromCommentIndex int) (*bug.Bug, error) {
+	author, err := bug.GetUser(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := bug.ReadLocalBug(repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := original.Compile()
+
+	if fromCommentIndex < 0 || fromCommentIndex >= len(snap.Comments) {
+		return nil, fmt.Errorf("invalid comment index %d", fromCommentIndex)
+	}
+
+	comment := snap.Comments[fromCommentIndex]
+
+	title := strings.TrimSpace(strings.SplitN(comment.Message, "\n", 2)[0])
+	if title == "" {
+		title = snap.Title
+	}
+
+	newBug := bug.NewBug()
+	err = newBug.Append(NewCreateOp(comment.Author, title, comment.Message, comment.Files))
+	if err != nil {
+		return nil, err
+	}
+
+	// the new bug need to be stored first to know its id
+	err = newBug.Commit(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	Link(newBug, author, original.Id())
+
+	err = newBug.Commit(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	Link(original, author, newBug.Id())
+
+	err = original.Commit(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBug, nil
+}
@@ -0,0 +1,46 @@
+package operations
+
+import (
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// TriageOperation will record that a bug has been seen and triaged
+
+var _ bug.Operation = TriageOperation{}
+var _ bug.AuthoredOperation = TriageOperation{}
+
+type TriageOperation struct {
+	bug.OpBase
+}
+
+func (op TriageOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	snapshot.Triaged = true
+	snapshot.TriagedBy = op.Author
+	snapshot.TriagedAt = time.Unix(op.UnixTime, 0)
+
+	return snapshot
+}
+
+func (op TriageOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func (op TriageOperation) WithAuthor(author bug.Person) bug.Operation {
+	op.SetAuthor(author)
+	return op
+}
+
+func NewTriageOp(author bug.Person) TriageOperation {
+	return TriageOperation{
+		OpBase: bug.NewOpBase(bug.TriageOp, author),
+	}
+}
+
+// Convenience function to apply the operation
+func Triage(b *bug.Bug, author bug.Person) {
+	op := NewTriageOp(author)
+	b.Append(op)
+}
@@ -0,0 +1,118 @@
+package operations
+
+import (
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// WaitingLabel is the label marking a bug as waiting for more information
+// from its reporter
+const WaitingLabel = bug.Label("waiting-for-reporter")
+
+// WaitingPolicy define what to do with the bugs waiting for their reporter
+type WaitingPolicy struct {
+	// How long to wait for an answer of the reporter
+	Timeout time.Duration
+	// Close the bugs without an answer in time
+	Close bool
+	// Label to set on the bugs without an answer in time, if any
+	ExpiredLabel bug.Label
+}
+
+// WaitForReporter is a convenience function to mark a bug as waiting for its
+// reporter
+func WaitForReporter(b *bug.Bug, author bug.Person) error {
+	return ChangeLabels(nil, b, author, []string{WaitingLabel.String()}, nil)
+}
+
+// WaitingSince return when the bug started to wait for its reporter, or
+// false if it's not waiting.
+func WaitingSince(snap bug.Snapshot) (time.Time, bool) {
+	if !labelExist(snap.Labels, WaitingLabel) {
+		return time.Time{}, false
+	}
+
+	var since time.Time
+
+	for _, op := range snap.Operations {
+		labelOp, ok := op.(LabelChangeOperation)
+		if ok && labelExist(labelOp.Added, WaitingLabel) {
+			since = op.Time()
+		}
+	}
+
+	return since, true
+}
+
+// answered tell if the reporter commented after the bug started to wait
+func answered(snap bug.Snapshot) bool {
+	answered := false
+
+	for _, op := range snap.Operations {
+		switch op := op.(type) {
+		case LabelChangeOperation:
+			if labelExist(op.Added, WaitingLabel) {
+				answered = false
+			}
+		case AddCommentOperation:
+			if op.Author == snap.Author {
+				answered = true
+			}
+		}
+	}
+
+	return answered
+}
+
+// ExpireWaiting apply the policy to the local bugs waiting for their reporter.
+// A bug whose reporter answered is not waiting anymore and get its label
+// removed. A bug without an answer after the timeout is relabeled and/or
+// closed. The ids of the expired bugs are returned.
+func ExpireWaiting(repo repository.Repo, author bug.Person, policy WaitingPolicy, now time.Time) ([]string, error) {
+	var bugs []*bug.Bug
+
+	for streamed := range bug.ReadAllLocalBugs(repo) {
+		if streamed.Err != nil {
+			return nil, streamed.Err
+		}
+		bugs = append(bugs, streamed.Bug)
+	}
+
+	var expired []string
+
+	for _, b := range bugs {
+		snap := b.Compile()
+
+		since, waiting := WaitingSince(snap)
+		if !waiting {
+			continue
+		}
+
+		if answered(snap) {
+			b.Append(NewLabelChangeOperation(author, nil, []bug.Label{WaitingLabel}))
+		} else if now.Sub(since) >= policy.Timeout {
+			var added []bug.Label
+			if policy.ExpiredLabel != "" && !labelExist(snap.Labels, policy.ExpiredLabel) {
+				added = append(added, policy.ExpiredLabel)
+			}
+			b.Append(NewLabelChangeOperation(author, added, []bug.Label{WaitingLabel}))
+
+			if policy.Close && !snap.Status.IsClosed() {
+				Close(b, author)
+			}
+
+			expired = append(expired, b.Id())
+		} else {
+			continue
+		}
+
+		err := b.Commit(repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return expired, nil
+}
@@ -2,6 +2,8 @@ package bug
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/MichaelMure/git-bug/repository"
 )
@@ -13,21 +15,85 @@ type Person struct {
 
 // GetUser will query the repository for user detail and build the corresponding Person
 func GetUser(repo repository.Repo) (Person, error) {
-	name, err := repo.GetUserName()
+	name, email, err := repo.GetUserIdentity()
 	if err != nil {
 		return Person{}, err
 	}
 	if name == "" {
 		return Person{}, errors.New("User name is not configured in git yet. Please use `git config --global user.name \"John Doe\"`")
 	}
-
-	email, err := repo.GetUserEmail()
-	if err != nil {
-		return Person{}, err
-	}
 	if email == "" {
 		return Person{}, errors.New("User name is not configured in git yet. Please use `git config --global user.email johndoe@example.com`")
 	}
 
 	return Person{Name: name, Email: email}, nil
 }
+
+// match tell if the person is identified by the given name or email. An empty
+// string only match an empty Person.
+func (p Person) match(nameOrEmail string) bool {
+	if nameOrEmail == "" {
+		return p == Person{}
+	}
+
+	return p.Name == nameOrEmail || p.Email == nameOrEmail
+}
+
+// ErrPersonNotFound is returned when no known person match a name or an email
+var ErrPersonNotFound = errors.New("No matching person found.")
+
+// ResolvePerson find the person identified by a name or an email, among the
+// user configured in the repository and the authors and assignees of the
+// local bugs. This allow to refer to someone without spelling both its name
+// and email.
+func ResolvePerson(repo repository.Repo, nameOrEmail string) (Person, error) {
+	if nameOrEmail == "" {
+		return Person{}, ErrPersonNotFound
+	}
+
+	user, err := GetUser(repo)
+	if err == nil && user.match(nameOrEmail) {
+		return user, nil
+	}
+
+	var matching []Person
+
+	add := func(p Person) {
+		if p == (Person{}) || !p.match(nameOrEmail) {
+			return
+		}
+		for _, other := range matching {
+			if other == p {
+				return
+			}
+		}
+		matching = append(matching, p)
+	}
+
+	for streamed := range ReadAllLocalBugs(repo) {
+		if streamed.Err != nil {
+			return Person{}, streamed.Err
+		}
+
+		snap := streamed.Bug.Compile()
+
+		add(snap.Assignee)
+		for _, op := range snap.Operations {
+			add(op.GetAuthor())
+		}
+	}
+
+	switch len(matching) {
+	case 0:
+		return Person{}, ErrPersonNotFound
+	case 1:
+		return matching[0], nil
+	}
+
+	names := make([]string, len(matching))
+	for i, p := range matching {
+		names[i] = fmt.Sprintf("%s <%s>", p.Name, p.Email)
+	}
+
+	return Person{}, fmt.Errorf("multiple matching persons:\n%s", strings.Join(names, "\n"))
+}
@@ -0,0 +1,123 @@
+package bug
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ListPrunableBugObjects find the git objects of the bugs that are not
+// reachable anymore, for example after a bug has been removed or rewritten,
+// and return them. Objects shared with a live bug are not listed.
+//
+// Nothing is deleted: git can't safely prune only some objects, and deleting
+// objects while another git command is writing can corrupt the repository.
+// As those objects are unreachable, the regular git gc take care of them once
+// they are older than its expiration delay (gc.pruneExpire).
+func ListPrunableBugObjects(repo repository.Repo) ([]util.Hash, error) {
+	unreachable, err := repo.ListUnreachableObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	unreachableSet := make(map[util.Hash]bool, len(unreachable))
+	for _, hash := range unreachable {
+		unreachableSet[hash] = true
+	}
+
+	found := make(map[util.Hash]bool)
+
+	types, err := repo.ReadObjectTypes(unreachable)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, hash := range unreachable {
+		if types[i] != "commit" {
+			continue
+		}
+
+		treeHash, err := repo.GetTreeHash(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := repo.ListEntries(treeHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isBugTree(entries) {
+			continue
+		}
+
+		found[hash] = true
+
+		err = collectUnreachableTree(repo, treeHash, entries, unreachableSet, found)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]util.Hash, 0, len(found))
+	for hash := range found {
+		result = append(result, hash)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result, nil
+}
+
+// isBugTree tell if the entries of a git tree are the ones of a bug commit.
+// Every commit of a bug has a root entry and an edit clock, but the touch and
+// merge commits don't have an ops entry.
+func isBugTree(entries []repository.TreeEntry) bool {
+	hasRoot, hasClock := false, false
+
+	for _, entry := range entries {
+		switch {
+		case entry.Name == rootEntryName:
+			hasRoot = true
+		case entry.Name == editClockEntryName, strings.HasPrefix(entry.Name, editClockEntryPrefix):
+			hasClock = true
+		}
+	}
+
+	return hasRoot && hasClock
+}
+
+// collectUnreachableTree add a tree and its content to found, as long as they
+// are unreachable. An object shared with a live bug is left alone.
+func collectUnreachableTree(repo repository.Repo, treeHash util.Hash, entries []repository.TreeEntry,
+	unreachable map[util.Hash]bool, found map[util.Hash]bool) error {
+
+	if !unreachable[treeHash] {
+		return nil
+	}
+	found[treeHash] = true
+
+	for _, entry := range entries {
+		if !unreachable[entry.Hash] || found[entry.Hash] {
+			continue
+		}
+
+		if entry.ObjectType != repository.Tree {
+			found[entry.Hash] = true
+			continue
+		}
+
+		subEntries, err := repo.ListEntries(entry.Hash)
+		if err != nil {
+			return err
+		}
+
+		err = collectUnreachableTree(repo, entry.Hash, subEntries, unreachable, found)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
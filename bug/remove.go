@@ -0,0 +1,100 @@
+package bug
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+var ErrBugOnRemote = errors.New("the bug exist on a remote")
+
+// the refs of all the remotes
+const remotesRefPrefix = "refs/remotes/"
+
+// RemoveLocalBug delete a local bug matching a prefix, for example one created
+// by mistake, along with its staging area and read marker. As the bug would
+// come back with the next pull, the removal is refused if a remote has it,
+// unless forced.
+func RemoveLocalBug(repo repository.Repo, prefix string, force bool) error {
+	// the bug is not read, so that a corrupted one can be removed as well
+	id, err := ResolveIdentifier(repo, prefix)
+	if err != nil {
+		return err
+	}
+
+	// an alias is removed through the bug it point to
+	ref, err := repo.ResolveSymbolicRef(bugsRefPattern + id)
+	if err != nil {
+		return err
+	}
+	id = path.Base(ref)
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !force {
+		err := checkNotOnRemote(repo, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = repo.RemoveRef(bugsRefPattern + id)
+	if err != nil {
+		return err
+	}
+
+	err = clearStaging(repo, id)
+	if err != nil {
+		return err
+	}
+
+	if cache, err := NewCache(repo); err == nil {
+		err = cache.Remove(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	exist, err := repo.RefExist(readRefPattern + id)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return repo.RemoveRef(readRefPattern + id)
+	}
+
+	return nil
+}
+
+// checkNotOnRemote return ErrBugOnRemote if a remote has the bug. The remote
+// refs are listed, so that every remote is checked, configured or not.
+func checkNotOnRemote(repo repository.Repo, id string) error {
+	refs, err := repo.ListRefs(remotesRefPrefix)
+	if err != nil {
+		return err
+	}
+
+	suffix := "/bugs/" + id
+
+	var found []string
+	for _, ref := range refs {
+		if strings.HasSuffix(ref, suffix) {
+			found = append(found, strings.TrimSuffix(strings.TrimPrefix(ref, remotesRefPrefix), suffix))
+		}
+	}
+
+	if len(found) > 0 {
+		sort.Strings(found)
+		return fmt.Errorf("%w: %s", ErrBugOnRemote, strings.Join(found, ", "))
+	}
+
+	return nil
+}
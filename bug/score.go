@@ -0,0 +1,43 @@
+package bug
+
+import (
+	"math"
+	"time"
+)
+
+// ActivityHalfLife is the time after which an operation only count for half
+// in the activity score of a bug
+const ActivityHalfLife = 7 * 24 * time.Hour
+
+// the weight of each type of operation in the activity score, the others
+// weight 1
+var activityWeights = map[OperationType]float64{
+	CreateOp:      2,
+	AddCommentOp:  3,
+	EditCommentOp: 0.5,
+}
+
+// ActivityScore return a score measuring how active a bug has been recently,
+// to rank the trending bugs. Each operation contribute its weight, halved for
+// every ActivityHalfLife elapsed since it was made. The result only depends
+// on the bug and the given time.
+func ActivityScore(snap Snapshot, now time.Time) float64 {
+	score := 0.0
+
+	for _, op := range snap.Operations {
+		weight, ok := activityWeights[op.OpType()]
+		if !ok {
+			weight = 1
+		}
+
+		age := now.Sub(op.Time())
+		if age < 0 {
+			// clock skew, an operation can't be more recent than now
+			age = 0
+		}
+
+		score += weight * math.Exp2(-float64(age)/float64(ActivityHalfLife))
+	}
+
+	return score
+}
@@ -0,0 +1,182 @@
+package bug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// searchQuery is a parsed search query
+type searchQuery struct {
+	text     string
+	statuses []Status
+	labels   []Label
+}
+
+// parseSearchQuery split a query in its field filters and its free text. The
+// supported filters are "status:<status>" and "label:<label>". A status with a
+// space is written with a dash, like "status:in-progress".
+func parseSearchQuery(query string) (searchQuery, error) {
+	var result searchQuery
+	var words []string
+
+	for _, word := range strings.Fields(query) {
+		switch {
+		case strings.HasPrefix(word, "status:"):
+			name := strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimPrefix(word, "status:"))
+			status, ok := statusByName(name)
+			if !ok {
+				return searchQuery{}, fmt.Errorf("unknown status %s", strings.TrimPrefix(word, "status:"))
+			}
+			result.statuses = append(result.statuses, status)
+
+		case strings.HasPrefix(word, "label:"):
+			result.labels = append(result.labels, Label(strings.TrimPrefix(word, "label:")))
+
+		default:
+			words = append(words, word)
+		}
+	}
+
+	result.text = strings.ToLower(strings.Join(words, " "))
+
+	return result, nil
+}
+
+func statusByName(name string) (Status, bool) {
+	for _, status := range []Status{OpenStatus, ClosedStatus, InProgressStatus} {
+		if strings.EqualFold(status.String(), name) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// match tell if a compiled bug match the query. A bug match if it has one of
+// the requested statuses, all the requested labels and if its title or one of
+// its comments contains the text.
+func (q searchQuery) match(snap *Snapshot) bool {
+	if len(q.statuses) > 0 {
+		found := false
+		for _, status := range q.statuses {
+			if snap.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, label := range q.labels {
+		if !hasLabel(snap.Labels, label) {
+			return false
+		}
+	}
+
+	if q.text == "" {
+		return true
+	}
+
+	if strings.Contains(strings.ToLower(snap.Title), q.text) {
+		return true
+	}
+
+	// stop at the first matching comment
+	for _, comment := range snap.Comments {
+		if strings.Contains(strings.ToLower(comment.Message), q.text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasLabel(labels []Label, label Label) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchError is returned by Search, along with the matching bugs, when some
+// bugs can't be read. These bugs are skipped.
+type SearchError struct {
+	// The error of each skipped bug, by id
+	Failed map[string]error
+}
+
+func (e *SearchError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return fmt.Sprintf("%d bug(s) can't be read and were skipped, first %s: %v",
+		len(ids), formatHumanId(ids[0], 0), e.Failed[ids[0]])
+}
+
+// Search return the compiled local bugs matching a query. The text of the
+// query is searched, case-insensitively, in the title and the comments of the
+// bugs. The query can also filter the bugs with "status:<status>" and
+// "label:<label>", for example "status:open label:bug crash". The result is
+// in the canonical order.
+//
+// The bugs are read and compiled one after the other and only the matching
+// ones are kept. A bug that can't be read is skipped, and a *SearchError is
+// returned along with the result.
+func Search(repo repository.Repo, query string) ([]*Snapshot, error) {
+	q, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		createTime util.LamportTime
+		snap       *Snapshot
+	}
+
+	var matches []match
+	failed := make(map[string]error)
+
+	for _, id := range ids {
+		b, err := ReadLocalBug(repo, id)
+		if err != nil {
+			failed[id] = err
+			continue
+		}
+
+		snap := b.Compile()
+
+		if q.match(&snap) {
+			matches = append(matches, match{createTime: b.createTime, snap: &snap})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return canonicalLess(matches[i].createTime, matches[i].snap.Id(), matches[j].createTime, matches[j].snap.Id())
+	})
+
+	result := make([]*Snapshot, len(matches))
+	for i, m := range matches {
+		result[i] = m.snap
+	}
+
+	if len(failed) > 0 {
+		return result, &SearchError{Failed: failed}
+	}
+
+	return result, nil
+}
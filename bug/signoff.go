@@ -0,0 +1,19 @@
+package bug
+
+// SignoffRole is the capacity in which someone sign off a bug
+type SignoffRole string
+
+const (
+	// The fix has been verified by QA
+	SignoffQA SignoffRole = "qa"
+	// The fix has been reviewed
+	SignoffReview SignoffRole = "review"
+)
+
+// Signoff is an approval of a bug, separate from its status
+type Signoff struct {
+	Role     SignoffRole
+	Author   Person
+	Note     string
+	UnixTime int64
+}
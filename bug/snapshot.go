@@ -3,20 +3,60 @@ package bug
 import (
 	"fmt"
 	"time"
+
+	"github.com/MichaelMure/git-bug/util"
 )
 
 // Snapshot is a compiled form of the Bug data structure used for storage and merge
 type Snapshot struct {
 	id string
 
+	// length of the displayed id, see Bug
+	humanIdLength int
+
 	Status    Status
 	Title     string
 	Comments  []Comment
 	Labels    []Label
 	Author    Person
 	CreatedAt time.Time
+	// The person in charge of the bug, if any
+	Assignee Person
+	// Ids of the related bugs
+	Links []string
+	// References to things outside of the bug, like a git commit
+	ExternalRefs []ExternalRef
+	// Sign-offs, like a QA verification, at most one per author and role
+	Signoffs []Signoff
+	// Ids of the bugs that need to be fixed before this one
+	DependsOn []string
+	// Whether the bug has been seen and triaged, by whom and when
+	Triaged   bool
+	TriagedBy Person
+	TriagedAt time.Time
+	// Latest status of each CI build context, like "ci/lint"
+	BuildStatuses map[string]BuildStatus
+	// Version the bug was fixed in, only set while closed
+	FixVersion string
+	// Every change of the title, in order
+	TitleHistory []TitleEdit
+
+	// Comments arranged as a tree, following their InReplyTo
+	CommentTree []*CommentNode
+
+	// Non-fatal problems found while compiling the bug
+	Warnings []string
 
 	Operations []Operation
+
+	// logical edit time of the pack holding each operation
+	editTimes []util.LamportTime
+
+	// stable id of each operation, see OperationId
+	operationIds []string
+
+	// number of operations of each type
+	opCounts map[OperationType]int
 }
 
 // Return the Bug identifier
@@ -24,9 +64,10 @@ func (snap Snapshot) Id() string {
 	return snap.id
 }
 
-// Return the Bug identifier truncated for human consumption
+// Return the Bug identifier truncated for human consumption, long enough to
+// be unambiguous in the repository the bug was read from
 func (snap Snapshot) HumanId() string {
-	return fmt.Sprintf("%.8s", snap.id)
+	return formatHumanId(snap.id, snap.humanIdLength)
 }
 
 func (snap Snapshot) Summary() string {
@@ -44,3 +85,74 @@ func (snap Snapshot) LastEdit() time.Time {
 
 	return snap.Operations[len(snap.Operations)-1].Time()
 }
+
+// Return for how long the bug has existed, relative to the given time
+func (snap Snapshot) Age(now time.Time) time.Duration {
+	if snap.CreatedAt.IsZero() {
+		return 0
+	}
+
+	return now.Sub(snap.CreatedAt)
+}
+
+// Return for how long the bug hasn't been modified, relative to the given time
+func (snap Snapshot) InactiveFor(now time.Time) time.Duration {
+	if len(snap.Operations) == 0 {
+		return 0
+	}
+
+	return now.Sub(snap.LastEdit())
+}
+
+// Return the logical edit time of the commit holding the operation at the
+// given index. Operations not committed yet have a zero time.
+func (snap Snapshot) OperationEditTime(index int) util.LamportTime {
+	if index >= len(snap.editTimes) {
+		return 0
+	}
+
+	return snap.editTimes[index]
+}
+
+// Return the id of the operation at the given index, usable as an anchor.
+// Operations not committed yet don't have one.
+func (snap Snapshot) OperationId(index int) string {
+	if index >= len(snap.operationIds) {
+		return ""
+	}
+
+	return snap.operationIds[index]
+}
+
+// Return the operations made in response to the operation with the given hash
+func (snap Snapshot) Replies(parent util.Hash) []Operation {
+	var result []Operation
+
+	for _, op := range snap.Operations {
+		if op.Parent() == parent {
+			result = append(result, op)
+		}
+	}
+
+	return result
+}
+
+// Return the number of comments added after the creation of the bug
+func (snap Snapshot) CommentCount() int {
+	return snap.opCounts[AddCommentOp]
+}
+
+// Return the number of times the labels have been changed
+func (snap Snapshot) LabelChangeCount() int {
+	return snap.opCounts[LabelChangeOp]
+}
+
+// Return the number of times the status has been changed
+func (snap Snapshot) StatusChangeCount() int {
+	return snap.opCounts[SetStatusOp]
+}
+
+// Return the number of times the title has been changed
+func (snap Snapshot) TitleChangeCount() int {
+	return snap.opCounts[SetTitleOp]
+}
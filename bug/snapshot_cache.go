@@ -0,0 +1,130 @@
+package bug
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// SnapshotCache keep in memory the compiled snapshots of the most recently
+// used bugs, for a long-running process like a server. A snapshot is keyed by
+// the bug id and the last commit it was compiled from, so it's compiled again
+// as soon as the ref of the bug move. It's safe for concurrent use.
+//
+// The returned snapshots are shared, they must not be modified.
+type SnapshotCache struct {
+	mu      sync.Mutex
+	maxSize int
+	// most recently used first
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   SnapshotCacheStats
+}
+
+// SnapshotCacheStats are the counters of a SnapshotCache
+type SnapshotCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+type snapshotCacheEntry struct {
+	id         string
+	lastCommit util.Hash
+	snap       Snapshot
+}
+
+// NewSnapshotCache create a SnapshotCache holding at most maxSize snapshots.
+// The least recently used snapshots are evicted first.
+func NewSnapshotCache(maxSize int) *SnapshotCache {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+
+	return &SnapshotCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get return the compiled snapshot of a local bug, from the cache if the bug
+// didn't change since it was compiled
+func (c *SnapshotCache) Get(repo repository.Repo, id string) (Snapshot, error) {
+	head, err := repo.ResolveRef(bugsRefPattern + id)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*snapshotCacheEntry)
+		if entry.lastCommit == head {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			snap := entry.snap
+			c.mu.Unlock()
+			return snap, nil
+		}
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// compile without holding the lock, the other bugs can still be served
+	b, err := ReadLocalBug(repo, id)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snap := b.Compile()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// keyed by the commit actually read, the ref might have moved since
+	c.put(id, b.lastCommit, snap)
+
+	return snap, nil
+}
+
+// put store a snapshot, evicting the least recently used ones if needed
+func (c *SnapshotCache) put(id string, lastCommit util.Hash, snap Snapshot) {
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*snapshotCacheEntry)
+		entry.lastCommit = lastCommit
+		entry.snap = snap
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.lru.PushFront(&snapshotCacheEntry{
+		id:         id,
+		lastCommit: lastCommit,
+		snap:       snap,
+	})
+
+	for c.lru.Len() > c.maxSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*snapshotCacheEntry).id)
+		c.stats.Evictions++
+	}
+}
+
+// Len return the number of snapshots in the cache
+func (c *SnapshotCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Stats return the counters of the cache
+func (c *SnapshotCache) Stats() SnapshotCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
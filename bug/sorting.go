@@ -1,5 +1,12 @@
 package bug
 
+import (
+	"sort"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
 type BugsByCreationTime []*Bug
 
 func (b BugsByCreationTime) Len() int {
@@ -55,3 +62,57 @@ func (b BugsByEditTime) Less(i, j int) bool {
 func (b BugsByEditTime) Swap(i, j int) {
 	b[i], b[j] = b[j], b[i]
 }
+
+// canonicalLess is the canonical ordering of the bugs: by create time, then by
+// id. Unlike the timestamp, the id is the same everywhere and never equal for
+// two bugs, so this order is total and stable across requests and
+// repositories. This is the order to use for listing and paginating bugs.
+func canonicalLess(createA util.LamportTime, idA string, createB util.LamportTime, idB string) bool {
+	if createA != createB {
+		return createA < createB
+	}
+
+	return idA < idB
+}
+
+// BugsByCanonicalOrder sort bugs in the canonical order, see canonicalLess
+type BugsByCanonicalOrder []*Bug
+
+func (b BugsByCanonicalOrder) Len() int {
+	return len(b)
+}
+
+func (b BugsByCanonicalOrder) Less(i, j int) bool {
+	return canonicalLess(b[i].createTime, b[i].Id(), b[j].createTime, b[j].Id())
+}
+
+func (b BugsByCanonicalOrder) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// SortedLocalIds return the ids of the local bugs in the canonical order. Only
+// the heads of the bugs are read, not their operations.
+func SortedLocalIds(repo repository.Repo) ([]string, error) {
+	ids, err := ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make([]BugHead, len(ids))
+	for i, id := range ids {
+		heads[i], err = ReadBugHead(repo, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(heads, func(i, j int) bool {
+		return canonicalLess(heads[i].CreateTime, heads[i].Id, heads[j].CreateTime, heads[j].Id)
+	})
+
+	for i, head := range heads {
+		ids[i] = head.Id
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,88 @@
+package bug
+
+import (
+	"errors"
+
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// The staging area of a bug can be persisted under this local ref pattern.
+// As it's outside of bugsRefPattern, it's never pushed to a remote.
+const stagingRefPattern = "refs/bugs-staging/"
+
+// PersistStaging write the staging area in Git under a local ref, so that the
+// pending operations can be recovered with RecoverStaging if the process stop
+// before a Commit.
+func (bug *Bug) PersistStaging(repo repository.Repo) error {
+	if bug.id == "" {
+		return errors.New("can't persist the staging area of a bug that has never been stored")
+	}
+
+	ref := stagingRefPattern + bug.id
+
+	if bug.staging.IsEmpty() {
+		return clearStaging(repo, bug.id)
+	}
+
+	hash, err := bug.staging.Write(repo)
+	if err != nil {
+		return err
+	}
+
+	return repo.UpdateRef(ref, hash)
+}
+
+// RecoverStaging read a local bug and restore its persisted staging area, if any.
+// The returned bug can be committed as usual.
+func RecoverStaging(repo repository.Repo, id string) (*Bug, error) {
+	bug, err := ReadLocalBug(repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := stagingRefPattern + id
+
+	exist, err := repo.RefExist(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist {
+		return bug, nil
+	}
+
+	hash, err := repo.ResolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := repo.ReadData(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := ParseOperationPack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	bug.staging = *staging
+
+	return bug, nil
+}
+
+// remove the persisted staging area of a bug, if any
+func clearStaging(repo repository.Repo, id string) error {
+	ref := stagingRefPattern + id
+
+	exist, err := repo.RefExist(ref)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return nil
+	}
+
+	return repo.RemoveRef(ref)
+}
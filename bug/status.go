@@ -1,11 +1,14 @@
 package bug
 
+import "fmt"
+
 type Status int
 
 const (
 	_ Status = iota
 	OpenStatus
 	ClosedStatus
+	InProgressStatus
 )
 
 func (s Status) String() string {
@@ -14,18 +17,41 @@ func (s Status) String() string {
 		return "open"
 	case ClosedStatus:
 		return "closed"
+	case InProgressStatus:
+		return "in progress"
 	default:
 		return "unknown status"
 	}
 }
 
+// ParseStatus return the status with the given name, as returned by String
+func ParseStatus(name string) (Status, error) {
+	status, ok := statusByName(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown status %s", name)
+	}
+	return status, nil
+}
+
 func (s Status) Action() string {
 	switch s {
 	case OpenStatus:
 		return "opened"
 	case ClosedStatus:
 		return "closed"
+	case InProgressStatus:
+		return "marked in progress"
 	default:
 		return "unknown status"
 	}
 }
+
+// IsOpen tell if the bug still need some work, that is open or in progress
+func (s Status) IsOpen() bool {
+	return s == OpenStatus || s == InProgressStatus
+}
+
+// IsClosed tell if the bug doesn't need any more work
+func (s Status) IsClosed() bool {
+	return s == ClosedStatus
+}
@@ -0,0 +1,110 @@
+package bug
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// ThumbnailSize is the maximum width and height of a generated thumbnail
+const ThumbnailSize = 128
+
+// The number of pixels above which an image is not decoded, as a small file
+// can declare a huge image and exhaust the memory once decoded
+const maxThumbnailSourcePixels = 50 * 1000 * 1000
+
+// MakeThumbnail return a PNG thumbnail of an image, or false if the data is
+// not a recognized image (PNG, JPEG or GIF) or is too large to be decoded.
+func MakeThumbnail(data []byte) ([]byte, bool, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// not an image, or not one we know how to decode
+		return nil, false, nil
+	}
+
+	if config.Width <= 0 || config.Height <= 0 ||
+		int64(config.Width)*int64(config.Height) > maxThumbnailSourcePixels {
+		return nil, false, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// not an image, or not one we know how to decode
+		return nil, false, nil
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, false, nil
+	}
+
+	// keep the aspect ratio, never upscale
+	scale := 1.0
+	if width > ThumbnailSize || height > ThumbnailSize {
+		if width > height {
+			scale = float64(ThumbnailSize) / float64(width)
+		} else {
+			scale = float64(ThumbnailSize) / float64(height)
+		}
+	}
+
+	thumbWidth := maxInt(int(float64(width)*scale), 1)
+	thumbHeight := maxInt(int(float64(height)*scale), 1)
+
+	// nearest neighbor scaling is good enough for a preview
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		for x := 0; x < thumbWidth; x++ {
+			srcX := bounds.Min.X + x*width/thumbWidth
+			srcY := bounds.Min.Y + y*height/thumbHeight
+			thumb.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, thumb)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return buf.Bytes(), true, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// StoreAttachment store a file to be attached to an operation. If requested
+// and the file is a recognized image, a thumbnail is stored as well and its
+// hash returned, otherwise the thumbnail hash is empty.
+func StoreAttachment(repo repository.Repo, data []byte, withThumbnail bool) (file util.Hash, thumbnail util.Hash, err error) {
+	file, err = repo.StoreData(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !withThumbnail {
+		return file, "", nil
+	}
+
+	thumbData, ok, err := MakeThumbnail(data)
+	if err != nil || !ok {
+		return file, "", err
+	}
+
+	thumbnail, err = repo.StoreData(thumbData)
+	if err != nil {
+		return "", "", err
+	}
+
+	return file, thumbnail, nil
+}
@@ -0,0 +1,87 @@
+package bug
+
+import (
+	"time"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// TimelineItem is an operation of a bug with who made it and when
+type TimelineItem struct {
+	// stable id of the operation, empty if not committed. See OperationId.
+	Id        string
+	Operation Operation
+	Author    Person
+	// logical time of the commit holding the operation, zero if not committed
+	EditTime util.LamportTime
+	// time of the commit holding the operation, zero if not committed. Unlike
+	// the time of the operation, it's the time it was actually written.
+	CommitTime time.Time
+	// The operation didn't change anything, like setting the title it already
+	// had. It's still part of the history but can be hidden.
+	NoOp bool
+}
+
+// Timeline return the operations of the bug, in the same order as Compile,
+// along with their author, their logical edit time and the time of the
+// commit holding them. A title or status change that left the bug as it
+// was is marked as NoOp.
+func (bug *Bug) Timeline(repo repository.Repo) ([]TimelineItem, error) {
+	var result []TimelineItem
+
+	commitTimes := make(map[util.Hash]time.Time)
+
+	// the bug is compiled along to know what each operation changed
+	snap := Snapshot{
+		id:            bug.id,
+		humanIdLength: bug.humanIdLength,
+		opCounts:      make(map[OperationType]int),
+	}
+
+	it := NewOperationIterator(bug)
+
+	for it.Next() {
+		op := it.Value()
+
+		before := snap
+		snap = snap.apply(op, it.editTime(), it.operationId())
+
+		item := TimelineItem{
+			Id:        it.operationId(),
+			Operation: op,
+			Author:    op.GetAuthor(),
+			EditTime:  it.editTime(),
+			NoOp:      isNoOp(op, before, snap),
+		}
+
+		if hash := it.commitHash(); hash != "" {
+			commitTime, ok := commitTimes[hash]
+			if !ok {
+				var err error
+				commitTime, err = repo.GetCommitTime(hash)
+				if err != nil {
+					return nil, err
+				}
+				commitTimes[hash] = commitTime
+			}
+			item.CommitTime = commitTime
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// isNoOp tell if a title or status change left the snapshot as it was
+func isNoOp(op Operation, before Snapshot, after Snapshot) bool {
+	switch op.OpType() {
+	case SetTitleOp:
+		return before.Title == after.Title
+	case SetStatusOp:
+		return before.Status == after.Status
+	default:
+		return false
+	}
+}
@@ -0,0 +1,19 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// TitleEdit is a change of the title of a bug, as recorded in
+// Snapshot.TitleHistory
+type TitleEdit struct {
+	// The title before the change, as it was in the compiled bug. It can
+	// differ from what the author saw if the title was changed concurrently.
+	Old    string
+	New    string
+	Author Person
+	// logical time of the commit holding the change, zero if not committed
+	EditTime util.LamportTime
+	// Should be used only for human display, never for ordering
+	UnixTime int64
+}
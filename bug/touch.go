@@ -0,0 +1,169 @@
+package bug
+
+import (
+	"errors"
+
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// Touch write a commit without any operation, that only bump the edit clock
+// of the bug. This mark the bug as recently edited without changing its
+// content.
+func (bug *Bug) Touch(repo repository.Repo) error {
+	if bug.lastCommit == "" {
+		return errors.New("can't touch a bug that has never been stored")
+	}
+
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	editTime, err := repo.EditTimeIncrement()
+	if err != nil {
+		return err
+	}
+
+	editClockEntry, err := makeClockEntry(repo, editClockEntryPattern, editClockEntryName, editTime)
+	if err != nil {
+		return err
+	}
+
+	// the operations not committed yet don't count
+	status, err := bug.headStatus(repo)
+	if err != nil {
+		return err
+	}
+
+	statusEntry, err := makeStatusEntry(repo, status)
+	if err != nil {
+		return err
+	}
+
+	treeHash, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: bug.rootPack, Name: rootEntryName},
+		editClockEntry,
+		statusEntry,
+	})
+	if err != nil {
+		return err
+	}
+
+	hash, err := repo.StoreCommitWithParent(treeHash, bug.lastCommit)
+	if err != nil {
+		return err
+	}
+
+	err = bug.updateRef(repo, bug.lastCommit, hash)
+	if err != nil {
+		return err
+	}
+
+	bug.lastCommit = hash
+	bug.editTime = editTime
+
+	return nil
+}
+
+// CompactTouches rewrite the history of a local bug to collapse the
+// consecutive commits without operation into a single one, keeping the last
+// one and therefore the highest edit clock. The bug compile identically
+// afterward. The first commit is never rewritten, so the id of the bug is
+// preserved. The number of removed commits is returned.
+//
+// As the history is rewritten, only a bug that has never been shared with a
+// remote can be compacted.
+func CompactTouches(repo repository.Repo, id string) (int, error) {
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	err = checkNotOnRemote(repo, id)
+	if err != nil {
+		return 0, err
+	}
+
+	ref := bugsRefPattern + id
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	parents, err := repo.ListCommitParents(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range parents {
+		if len(p) > 1 {
+			return 0, errors.New("compacting a bug history with merge commits is not supported")
+		}
+	}
+
+	hashes := linearizeCommits(head, parents)
+
+	var kept []util.Hash
+	lastWasTouch := false
+
+	for _, hash := range hashes {
+		touch, err := isTouchCommit(repo, hash)
+		if err != nil {
+			return 0, err
+		}
+
+		if touch && lastWasTouch {
+			// the previous touch commit is superseded by this one
+			kept[len(kept)-1] = hash
+		} else {
+			kept = append(kept, hash)
+		}
+
+		lastWasTouch = touch
+	}
+
+	removed := len(hashes) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// rewrite the chain with the same trees
+	parent := kept[0]
+	for _, hash := range kept[1:] {
+		treeHash, err := repo.GetTreeHash(hash)
+		if err != nil {
+			return 0, err
+		}
+
+		parent, err = repo.StoreCommitWithParent(treeHash, parent)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = updateBugRef(repo, id, head, parent)
+	if err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func isTouchCommit(repo repository.Repo, hash util.Hash) (bool, error) {
+	entries, err := repo.ListEntries(hash)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == opsEntryName {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
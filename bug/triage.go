@@ -0,0 +1,26 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// ListUntriaged return the compiled local bugs that haven't been triaged yet
+func ListUntriaged(repo repository.Repo) ([]*Snapshot, error) {
+	var result []*Snapshot
+
+	for streamed := range ReadAllLocalBugs(repo) {
+		if streamed.Err != nil {
+			return nil, streamed.Err
+		}
+
+		snap := streamed.Bug.Compile()
+
+		if snap.Triaged {
+			continue
+		}
+
+		result = append(result, &snap)
+	}
+
+	return result, nil
+}
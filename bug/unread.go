@@ -0,0 +1,58 @@
+package bug
+
+import (
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// The last commit of a bug seen by the user is recorded under this local ref
+// pattern. As it's outside of bugsRefPattern, it's never pushed to a remote.
+const readRefPattern = "refs/bugs-read/"
+
+// MarkRead record that the user has seen the current state of a local bug
+func MarkRead(repo repository.Repo, id string) error {
+	hash, err := repo.ResolveRef(bugsRefPattern + id)
+	if err != nil {
+		return err
+	}
+
+	return repo.UpdateRef(readRefPattern+id, hash)
+}
+
+// UnreadOps return the operations of a local bug added since the last time
+// it was marked as read. If it was never marked, all the operations are unread.
+func UnreadOps(repo repository.Repo, id string) ([]Operation, error) {
+	bug, err := ReadLocalBug(repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := readRefPattern + id
+
+	exist, err := repo.RefExist(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[util.Hash][]util.Hash)
+
+	if exist {
+		// every commit reachable from the last seen one has been seen as well
+		seen, err = repo.ListCommitParents(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []Operation
+
+	for _, pack := range bug.packs {
+		if _, ok := seen[pack.commitHash]; ok {
+			continue
+		}
+
+		result = append(result, pack.Operations...)
+	}
+
+	return result, nil
+}
@@ -3,7 +3,6 @@ package cache
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/MichaelMure/git-bug/bug"
 	"github.com/MichaelMure/git-bug/bug/operations"
@@ -127,40 +126,19 @@ func (c *RepoCache) ResolveBug(id string) (BugCacher, error) {
 }
 
 func (c *RepoCache) ResolveBugPrefix(prefix string) (BugCacher, error) {
-	// preallocate but empty
-	matching := make([]string, 0, 5)
-
-	for id := range c.bugs {
-		if strings.HasPrefix(id, prefix) {
-			matching = append(matching, id)
-		}
-	}
-
-	// TODO: should check matching bug in the repo as well
-
-	if len(matching) > 1 {
-		return nil, fmt.Errorf("Multiple matching bug found:\n%s", strings.Join(matching, "\n"))
-	}
-
-	if len(matching) == 1 {
-		b := c.bugs[matching[0]]
-		return b, nil
-	}
-
-	b, err := bug.FindLocalBug(c.repo, prefix)
-
+	// the prefix must be unique in the repo, not only among the cached bugs
+	id, err := bug.ResolveIdentifier(c.repo, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	cached := NewBugCache(c.repo, b)
-	c.bugs[b.Id()] = cached
-
-	return cached, nil
+	return c.ResolveBug(id)
 }
 
+// AllBugIds return the ids of all the bugs, in the canonical order so that a
+// pagination is stable
 func (c *RepoCache) AllBugIds() ([]string, error) {
-	return bug.ListLocalIds(c.repo)
+	return bug.SortedLocalIds(c.repo)
 }
 
 func (c *RepoCache) ClearAllBugs() {
@@ -246,7 +224,10 @@ func (c *BugCache) AddCommentWithFiles(message string, files []util.Hash) error
 		return err
 	}
 
-	operations.CommentWithFiles(c.bug, author, message, files)
+	err = operations.CommentWithFiles(c.bug, author, message, files)
+	if err != nil {
+		return err
+	}
 
 	// TODO: perf --> the snapshot could simply be updated with the new op
 	c.ClearSnapshot()
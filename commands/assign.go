@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/spf13/cobra"
+)
+
+func runAssign(cmd *cobra.Command, args []string) error {
+	if len(args) > 2 {
+		return errors.New("Only assigning one bug to one person is supported")
+	}
+
+	if len(args) == 0 {
+		return errors.New("You must provide a bug id")
+	}
+
+	prefix := args[0]
+
+	b, err := bug.FindLocalBug(repo, prefix)
+	if err != nil {
+		return err
+	}
+
+	author, err := bug.GetUser(repo)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		operations.Unassign(b, author)
+		return b.Commit(repo)
+	}
+
+	assignee, err := bug.ResolvePerson(repo, args[1])
+	if err != nil {
+		return err
+	}
+
+	operations.Assign(b, author, assignee)
+
+	return b.Commit(repo)
+}
+
+var assignCmd = &cobra.Command{
+	Use:   "assign <id> [<name or email>]",
+	Short: "Assign a bug to someone, or unassign it",
+	RunE:  runAssign,
+}
+
+func init() {
+	RootCmd.AddCommand(assignCmd)
+}
@@ -56,7 +56,10 @@ func runComment(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	operations.Comment(b, author, commentMessage)
+	err = operations.Comment(b, author, commentMessage)
+	if err != nil {
+		return err
+	}
 
 	return b.Commit(repo)
 }
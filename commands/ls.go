@@ -9,14 +9,18 @@ import (
 )
 
 func runLsBug(cmd *cobra.Command, args []string) error {
-	bugs := bug.ReadAllLocalBugs(repo)
+	ids, err := bug.SortedLocalIds(repo)
+	if err != nil {
+		return err
+	}
 
-	for b := range bugs {
-		if b.Err != nil {
-			return b.Err
+	for _, id := range ids {
+		b, err := bug.ReadLocalBug(repo, id)
+		if err != nil {
+			return err
 		}
 
-		snapshot := b.Bug.Compile()
+		snapshot := b.Compile()
 
 		var author bug.Person
 
@@ -30,7 +34,7 @@ func runLsBug(cmd *cobra.Command, args []string) error {
 		authorFmt := fmt.Sprintf("%-15.15s", author.Name)
 
 		fmt.Printf("%s %s\t%s\t%s\t%s\n",
-			util.Cyan(b.Bug.HumanId()),
+			util.Cyan(b.HumanId()),
 			util.Yellow(snapshot.Status),
 			titleFmt,
 			util.Magenta(authorFmt),
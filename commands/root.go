@@ -12,7 +12,7 @@ import (
 // Will display "git bug"
 // \u00A0 is a non-breaking space
 // It's used to avoid cobra to split the Use string at the first space to get the root command name
-//const rootCommandName = "git\u00A0bug"
+// const rootCommandName = "git\u00A0bug"
 const rootCommandName = "git-bug"
 
 // package scoped var to hold the repo after the PreRun execution
@@ -10,6 +10,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var showAt string
+
 func runShowBug(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
 		return errors.New("Only showing one bug at a time is supported")
@@ -26,6 +28,19 @@ func runShowBug(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if showAt != "" {
+		// accept an abbreviated hash as well
+		commit, err := repo.ResolveRef(showAt)
+		if err != nil {
+			return err
+		}
+
+		b, err = bug.ReadLocalBugAt(repo, b.Id(), commit)
+		if err != nil {
+			return err
+		}
+	}
+
 	snapshot := b.Compile()
 
 	if len(snapshot.Comments) == 0 {
@@ -76,11 +91,15 @@ func runShowBug(cmd *cobra.Command, args []string) error {
 }
 
 var showCmd = &cobra.Command{
-	Use:   "show <id>",
+	Use:   "show [<option>...] <id>",
 	Short: "Display the details of a bug",
 	RunE:  runShowBug,
 }
 
 func init() {
 	RootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVarP(&showAt, "at", "", "",
+		"Show the bug as it was at one of its commits",
+	)
 }
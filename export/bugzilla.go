@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+const bugzillaTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// BugzillaStatus is the Bugzilla status and resolution a git-bug status is
+// mapped to
+type BugzillaStatus struct {
+	Status     string
+	Resolution string
+}
+
+// BugzillaConfig define how a bug is mapped to the Bugzilla vocabulary
+type BugzillaConfig struct {
+	Product   string
+	Component string
+	// The Bugzilla status and resolution of each git-bug status
+	Statuses map[bug.Status]BugzillaStatus
+}
+
+// DefaultBugzillaConfig return the mapping to the default workflow of
+// Bugzilla 5
+func DefaultBugzillaConfig() BugzillaConfig {
+	return BugzillaConfig{
+		Product:   "git-bug",
+		Component: "General",
+		Statuses: map[bug.Status]BugzillaStatus{
+			bug.OpenStatus:       {Status: "CONFIRMED"},
+			bug.InProgressStatus: {Status: "IN_PROGRESS"},
+			bug.ClosedStatus:     {Status: "RESOLVED", Resolution: "FIXED"},
+		},
+	}
+}
+
+type bugzillaXML struct {
+	XMLName xml.Name         `xml:"bugzilla"`
+	Version string           `xml:"version,attr"`
+	Bugs    []bugzillaBugXML `xml:"bug"`
+}
+
+// the elements are in the order of the Bugzilla DTD
+type bugzillaBugXML struct {
+	BugId      string               `xml:"bug_id"`
+	CreationTs string               `xml:"creation_ts"`
+	ShortDesc  string               `xml:"short_desc"`
+	DeltaTs    string               `xml:"delta_ts"`
+	Product    string               `xml:"product"`
+	Component  string               `xml:"component"`
+	BugStatus  string               `xml:"bug_status"`
+	Resolution string               `xml:"resolution,omitempty"`
+	Keywords   string               `xml:"keywords,omitempty"`
+	Reporter   bugzillaPersonXML    `xml:"reporter"`
+	AssignedTo *bugzillaPersonXML   `xml:"assigned_to,omitempty"`
+	Cc         []string             `xml:"cc"`
+	LongDescs  []bugzillaCommentXML `xml:"long_desc"`
+}
+
+type bugzillaPersonXML struct {
+	Name  string `xml:"name,attr"`
+	Email string `xml:",chardata"`
+}
+
+type bugzillaCommentXML struct {
+	IsPrivate    int               `xml:"isprivate,attr"`
+	CommentId    util.Hash         `xml:"commentid"`
+	CommentCount int               `xml:"comment_count"`
+	Who          bugzillaPersonXML `xml:"who"`
+	BugWhen      string            `xml:"bug_when"`
+	TheText      string            `xml:"thetext"`
+}
+
+// ExportBugzillaXML write a compiled bug in the XML format of Bugzilla. The
+// participants other than the reporter are set as the CC list.
+func ExportBugzillaXML(snap bug.Snapshot, config BugzillaConfig, w io.Writer) error {
+	status := config.Statuses[snap.Status]
+
+	labels := make([]string, len(snap.Labels))
+	for i, label := range snap.Labels {
+		labels[i] = label.String()
+	}
+
+	bugXML := bugzillaBugXML{
+		BugId:      snap.HumanId(),
+		CreationTs: snap.CreatedAt.UTC().Format(bugzillaTimeFormat),
+		ShortDesc:  snap.Title,
+		DeltaTs:    snap.LastEdit().UTC().Format(bugzillaTimeFormat),
+		Product:    config.Product,
+		Component:  config.Component,
+		BugStatus:  status.Status,
+		Resolution: status.Resolution,
+		Keywords:   strings.Join(labels, ", "),
+		Reporter:   bugzillaPerson(snap.Author),
+	}
+
+	if snap.Assignee != (bug.Person{}) {
+		assignee := bugzillaPerson(snap.Assignee)
+		bugXML.AssignedTo = &assignee
+	}
+
+	seen := map[string]bool{snap.Author.Email: true}
+
+	for i, comment := range snap.Comments {
+		if !seen[comment.Author.Email] {
+			seen[comment.Author.Email] = true
+			bugXML.Cc = append(bugXML.Cc, comment.Author.Email)
+		}
+
+		bugXML.LongDescs = append(bugXML.LongDescs, bugzillaCommentXML{
+			CommentId:    comment.Id,
+			CommentCount: i,
+			Who:          bugzillaPerson(comment.Author),
+			BugWhen:      time.Unix(comment.UnixTime, 0).UTC().Format(bugzillaTimeFormat),
+			TheText:      comment.Message,
+		})
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	err = encoder.Encode(bugzillaXML{
+		Version: "5.0",
+		Bugs:    []bugzillaBugXML{bugXML},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func bugzillaPerson(p bug.Person) bugzillaPersonXML {
+	return bugzillaPersonXML{Name: p.Name, Email: p.Email}
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestExportBugzillaXML(t *testing.T) {
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations.Comment(b, blaise, "a <comment> & more")
+	operations.Comment(b, rene, "answer")
+	operations.Assign(b, rene, blaise)
+	operations.Close(b, rene)
+	err = operations.ChangeLabels(nil, b, rene, []string{"bug", "ui"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultBugzillaConfig()
+	config.Statuses[bug.ClosedStatus] = BugzillaStatus{Status: "CLOSED", Resolution: "WONTFIX"}
+
+	var buf bytes.Buffer
+	err = ExportBugzillaXML(b.Compile(), config, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the elements of the bug must follow the order of the Bugzilla DTD
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	var path []string
+	var elements []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			path = append(path, token.Name.Local)
+			if len(path) == 3 && path[1] == "bug" {
+				elements = append(elements, token.Name.Local)
+			}
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		}
+	}
+	if len(path) != 0 {
+		t.Fatal("the XML is not well formed")
+	}
+
+	expectedElements := []string{
+		"bug_id", "creation_ts", "short_desc", "delta_ts", "product",
+		"component", "bug_status", "resolution", "keywords", "reporter",
+		"assigned_to", "cc", "long_desc", "long_desc", "long_desc",
+	}
+	if !reflect.DeepEqual(elements, expectedElements) {
+		t.Fatalf("unexpected elements: %v", elements)
+	}
+
+	var parsed bugzillaXML
+	err = xml.Unmarshal(buf.Bytes(), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.Bugs) != 1 {
+		t.Fatal("expected a single bug")
+	}
+	bugXML := parsed.Bugs[0]
+
+	if bugXML.BugStatus != "CLOSED" || bugXML.Resolution != "WONTFIX" {
+		t.Fatalf("unexpected status: %s %s", bugXML.BugStatus, bugXML.Resolution)
+	}
+	if bugXML.Keywords != "bug, ui" {
+		t.Fatalf("unexpected keywords: %s", bugXML.Keywords)
+	}
+	if bugXML.Reporter.Email != rene.Email || bugXML.Reporter.Name != rene.Name {
+		t.Fatalf("unexpected reporter: %v", bugXML.Reporter)
+	}
+	if bugXML.AssignedTo == nil || bugXML.AssignedTo.Email != blaise.Email {
+		t.Fatalf("unexpected assignee: %v", bugXML.AssignedTo)
+	}
+	if !reflect.DeepEqual(bugXML.Cc, []string{blaise.Email}) {
+		t.Fatalf("unexpected cc list: %v", bugXML.Cc)
+	}
+	if bugXML.LongDescs[1].TheText != "a <comment> & more" {
+		t.Fatalf("unexpected comment: %s", bugXML.LongDescs[1].TheText)
+	}
+	if bugXML.LongDescs[2].CommentCount != 2 {
+		t.Fatalf("unexpected comment count: %d", bugXML.LongDescs[2].CommentCount)
+	}
+}
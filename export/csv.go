@@ -0,0 +1,86 @@
+// Package export contains functions to export bugs in various formats.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+// ExportOperationsCSV write the operations of a compiled bug as CSV, one row
+// per operation, with the columns: lamport time, type, author, summary
+func ExportOperationsCSV(snap bug.Snapshot, w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"lamport time", "type", "author", "summary"})
+	if err != nil {
+		return err
+	}
+
+	for i, op := range snap.Operations {
+		author := op.GetAuthor()
+
+		err := writer.Write([]string{
+			fmt.Sprintf("%d", snap.OperationEditTime(i)),
+			op.OpType().String(),
+			fmt.Sprintf("%s <%s>", author.Name, author.Email),
+			summarizeOperation(op),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// summarizeOperation return a short textual summary of an operation, empty
+// for the operations without one
+func summarizeOperation(op bug.Operation) string {
+	switch op := op.(type) {
+	case operations.CreateOperation:
+		return op.Title
+	case operations.SetTitleOperation:
+		return op.Title
+	case operations.AddCommentOperation:
+		return op.CommentBody()
+	case operations.SetStatusOperation:
+		return op.Status.String()
+	case operations.LabelChangeOperation:
+		var parts []string
+		for _, label := range op.Added {
+			parts = append(parts, "+"+label.String())
+		}
+		for _, label := range op.Removed {
+			parts = append(parts, "-"+label.String())
+		}
+		return strings.Join(parts, " ")
+	case operations.SetAssigneeOperation:
+		return op.Assignee.Name
+	case operations.LinkOperation:
+		return op.Target
+	case operations.EditCommentOperation:
+		return op.Message
+	case operations.AddSignoffOperation:
+		return strings.TrimSpace(fmt.Sprintf("%s %s", op.Role, op.Note))
+	case operations.DependencyOperation:
+		if op.Removed {
+			return "-" + op.Target
+		}
+		return "+" + op.Target
+	case operations.SetBuildStatusOperation:
+		return fmt.Sprintf("%s %s", op.Context, op.State)
+	case operations.SetFixVersionOperation:
+		return op.Version
+	case operations.ExternalRefOperation:
+		return fmt.Sprintf("%s %s", op.Kind, op.Target)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+var rene = bug.Person{
+	Name:  "René Descartes",
+	Email: "rene@descartes.fr",
+}
+
+func TestExportOperationsCSV(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations.Comment(b, rene, "a comment, with a comma\nand a \"newline\"")
+	operations.SetTitle(b, rene, "title2")
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations.Close(b, rene)
+	err = operations.ChangeLabels(nil, b, rene, []string{"bug", "ui"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = ExportOperationsCSV(b.Compile(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := ioutil.ReadFile("testdata/operations.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != string(expected) {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+// an operation the export doesn't know about
+type unknownOperation struct {
+	bug.OpBase
+}
+
+func (op unknownOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	return snapshot
+}
+
+func (op unknownOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func TestExportOperationsCSVAuthor(t *testing.T) {
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap := b.Compile()
+	snap.Operations = append(snap.Operations, unknownOperation{
+		OpBase: bug.NewOpBase(bug.OperationType(1000), rene),
+	})
+
+	var buf bytes.Buffer
+	err = ExportOperationsCSV(snap, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "René Descartes <rene@descartes.fr>") {
+		t.Fatalf("the author of every operation should be exported:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,291 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// CollisionPolicy define what to do when an imported bug has the id of an
+// existing local bug, or was already imported with this id
+type CollisionPolicy int
+
+const (
+	// CollisionSkip keep the existing bug and skip the imported one
+	CollisionSkip CollisionPolicy = iota
+	// CollisionOverwrite replace the existing bug by the imported one. As the
+	// id of a bug can't be chosen, the imported bug get a new id and the
+	// existing one is removed. A bug that a remote has would come back with
+	// the next pull, so it's never replaced and the record fail with
+	// bug.ErrBugOnRemote.
+	CollisionOverwrite
+	// CollisionNewId import the bug as a new one, next to the existing one.
+	// The original id keep referring to the existing bug.
+	CollisionNewId
+)
+
+// ImportResult is the outcome of the import of one record
+type ImportResult struct {
+	// Line number of the record, starting at 1
+	Line int
+	// Id of the bug in the imported record
+	OriginalId string
+	// Id of the created bug, empty if skipped or if it couldn't be created
+	Id      string
+	Skipped bool
+	Err     error
+}
+
+// ImportAllJSON read JSON Lines as written by ExportAllJSON and create and
+// commit a bug for each record. As the id of a bug is the hash of its first
+// commit, an imported bug always get a new id. The original id is written as
+// an alias of the new one, so that it can still be resolved and that
+// importing the same records again is a collision.
+//
+// The title, status, labels, assignee and comments with their author and time
+// are restored. The files of the comments and the rest of the history are not.
+//
+// A failing record doesn't stop the import, the outcome of each record is
+// returned. An error is returned only if the stream itself can't be read.
+func ImportAllJSON(repo repository.Repo, r io.Reader, collision CollisionPolicy) ([]ImportResult, error) {
+	ids, err := bug.ListLocalIds(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		existing[id] = true
+	}
+
+	var results []ImportResult
+
+	reader := bufio.NewReader(r)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return results, err
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			result := importJSONRecord(repo, line, existing, collision)
+			result.Line = lineNumber
+			results = append(results, result)
+		}
+
+		if err == io.EOF {
+			return results, nil
+		}
+	}
+}
+
+func importJSONRecord(repo repository.Repo, line []byte, existing map[string]bool, collision CollisionPolicy) ImportResult {
+	var record jsonBug
+
+	err := json.Unmarshal(line, &record)
+	if err != nil {
+		return ImportResult{Err: err}
+	}
+
+	result := ImportResult{OriginalId: record.Id}
+
+	// the bug with the original id, or the one it was imported as
+	target, err := bug.ResolveAlias(repo, record.Id)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	overwrite := false
+	keepAlias := false
+
+	if existing[target] {
+		switch collision {
+		case CollisionSkip:
+			result.Skipped = true
+			return result
+
+		case CollisionOverwrite:
+			overwrite = true
+
+		case CollisionNewId:
+			// an imported bug always get a new id
+			keepAlias = true
+
+		default:
+			result.Err = fmt.Errorf("unknown collision policy %d", collision)
+			return result
+		}
+	}
+
+	b, err := commitJSONRecord(repo, record)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// the existing bug is removed only once its replacement is stored
+	if overwrite {
+		err = bug.RemoveLocalBug(repo, target, false)
+		if err != nil {
+			// the imported bug is local only, it can be removed without force
+			if removeErr := bug.RemoveLocalBug(repo, b.Id(), false); removeErr != nil {
+				err = fmt.Errorf("%w, and the imported bug %s can't be removed: %v", err, b.HumanId(), removeErr)
+			}
+			result.Err = err
+			return result
+		}
+		delete(existing, target)
+	}
+
+	result.Id = b.Id()
+	existing[result.Id] = true
+
+	// an alias would be shadowed by a live bug with the original id
+	if !keepAlias && !existing[record.Id] {
+		err = bug.WriteAlias(repo, record.Id, b.Id())
+		if err != nil {
+			result.Err = fmt.Errorf("the original id can't be kept as an alias: %w", err)
+		}
+	}
+
+	return result
+}
+
+// ImportSnapshot create and commit a bug from a JSON document as written by
+// ExportJSON, like ImportAllJSON does for each record. The bug always get a
+// new id, whether the document has one or not.
+func ImportSnapshot(repo repository.Repo, data []byte) (*bug.Bug, error) {
+	var record jsonBug
+
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	return commitJSONRecord(repo, record)
+}
+
+// commitJSONRecord create and commit the bug of a record. The logical edit
+// times of the record are witnessed first, so that the imported bug is
+// ordered after the edits it was exported with.
+func commitJSONRecord(repo repository.Repo, record jsonBug) (*bug.Bug, error) {
+	b, err := bugFromJSON(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastEditTime util.LamportTime
+	for _, op := range record.Operations {
+		if op.EditTime > lastEditTime {
+			lastEditTime = op.EditTime
+		}
+	}
+
+	if lastEditTime > 0 {
+		err = repo.EditWitness(lastEditTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = b.Commit(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// bugFromJSON build a new bug with the operations needed to get the
+// compiled bug of a record
+func bugFromJSON(record jsonBug) (*bug.Bug, error) {
+	if len(record.Comments) == 0 {
+		return nil, fmt.Errorf("bug %s has no comment", record.Id)
+	}
+
+	status, err := bug.ParseStatus(record.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// the labels and the assignee are set at the last edit
+	lastEdit, err := time.Parse(time.RFC3339, record.LastEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	author := personOfJSON(record.Author)
+	first := record.Comments[0]
+
+	create := operations.NewCreateOp(author, record.Title, first.Message, nil)
+	create.Status = status
+	create.UnixTime = createdAt.Unix()
+
+	b := bug.NewBug()
+
+	err = b.Append(create)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range record.Comments[1:] {
+		commentTime, err := time.Parse(time.RFC3339, comment.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		op := operations.NewAddCommentOp(personOfJSON(comment.Author), comment.Message, nil)
+		op.UnixTime = commentTime.Unix()
+
+		err = b.Append(op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(record.Labels) > 0 {
+		labels := make([]bug.Label, len(record.Labels))
+		for i, label := range record.Labels {
+			labels[i] = bug.Label(label)
+		}
+
+		op := operations.NewLabelChangeOperation(author, labels, nil)
+		op.UnixTime = lastEdit.Unix()
+
+		err = b.Append(op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if record.Assignee != nil {
+		op := operations.NewSetAssigneeOp(author, personOfJSON(*record.Assignee))
+		op.UnixTime = lastEdit.Unix()
+
+		err = b.Append(op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func personOfJSON(p jsonPerson) bug.Person {
+	return bug.Person{Name: p.Name, Email: p.Email}
+}
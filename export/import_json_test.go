@@ -0,0 +1,358 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestImportAllJSON(t *testing.T) {
+	source := repository.NewMockRepoForTest()
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	b1, err := operations.Create(rene, "first", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations.Comment(b1, blaise, "answer")
+	operations.Close(b1, rene)
+	operations.Assign(b1, rene, blaise)
+	err = operations.ChangeLabels(nil, b1, rene, []string{"ui", "bug"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b1.Commit(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b2, err := operations.Create(rene, "second", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b2.Commit(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = ExportAllJSON(source, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("expected one line per bug:\n%s", buf.String())
+	}
+
+	// a broken record doesn't stop the import
+	input := buf.String() + "{broken\n"
+
+	target := repository.NewMockRepoForTest()
+
+	results, err := ImportAllJSON(target, strings.NewReader(input), CollisionSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for _, result := range results[:2] {
+		if result.Err != nil || result.Id == "" {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	}
+	if results[2].Err == nil || results[2].Line != 3 {
+		t.Fatalf("the broken record should fail, got %+v", results[2])
+	}
+
+	ids, err := bug.ListLocalIds(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 imported bugs, got %d", len(ids))
+	}
+
+	imported, err := bug.ReadLocalBug(target, results[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected, actual bytes.Buffer
+	err = ExportJSON(b1.Compile(), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ExportJSON(imported.Compile(), &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(data []byte) jsonBug {
+		var decoded jsonBug
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	want, got := decode(expected.Bytes()), decode(actual.Bytes())
+
+	if got.Title != want.Title || got.Status != want.Status || got.CreatedAt != want.CreatedAt ||
+		!reflect.DeepEqual(got.Author, want.Author) || !reflect.DeepEqual(got.Assignee, want.Assignee) ||
+		!reflect.DeepEqual(got.Labels, want.Labels) {
+		t.Fatalf("unexpected imported bug:\n%s", actual.String())
+	}
+
+	if len(got.Comments) != len(want.Comments) {
+		t.Fatal("the comments should be imported")
+	}
+	for i := range got.Comments {
+		if got.Comments[i].Message != want.Comments[i].Message ||
+			got.Comments[i].Author != want.Comments[i].Author ||
+			got.Comments[i].Time != want.Comments[i].Time {
+			t.Fatalf("unexpected comment %d: %+v", i, got.Comments[i])
+		}
+	}
+}
+
+func TestImportAllJSONCollision(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = ExportAllJSON(repo, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countBugs := func() int {
+		ids, err := bug.ListLocalIds(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(ids)
+	}
+
+	results, err := ImportAllJSON(repo, bytes.NewReader(buf.Bytes()), CollisionSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Skipped || countBugs() != 1 {
+		t.Fatal("the existing bug should be kept")
+	}
+
+	results, err = ImportAllJSON(repo, bytes.NewReader(buf.Bytes()), CollisionNewId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || countBugs() != 2 {
+		t.Fatal("the bug should be imported next to the existing one")
+	}
+
+	results, err = ImportAllJSON(repo, bytes.NewReader(buf.Bytes()), CollisionOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || countBugs() != 2 {
+		t.Fatal("the existing bug should be replaced")
+	}
+
+	if _, err := bug.ReadLocalBug(repo, b.Id()); err == nil {
+		t.Fatal("the overwritten bug should be removed")
+	}
+
+	// a bug that a remote has is never replaced
+	remote, err := operations.Create(rene, "remote", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.(interface {
+		AddRemote(name string, url string) error
+	}).AddRemote("origin", "https://example.com/origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repo.CopyRef("refs/bugs/"+remote.Id(), "refs/remotes/origin/bugs/"+remote.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	err = json.NewEncoder(&buf).Encode(jsonBugOf(remote.Compile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = ImportAllJSON(repo, bytes.NewReader(buf.Bytes()), CollisionOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, bug.ErrBugOnRemote) {
+		t.Fatalf("expected ErrBugOnRemote, got %v", results[0].Err)
+	}
+	if countBugs() != 3 {
+		t.Fatal("neither the existing nor the imported bug should be left over")
+	}
+}
+
+func TestImportAllJSONReimport(t *testing.T) {
+	source := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = ExportAllJSON(source, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := repository.NewMockRepoForTest()
+
+	countBugs := func() int {
+		ids, err := bug.ListLocalIds(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(ids)
+	}
+
+	results, err := ImportAllJSON(target, bytes.NewReader(buf.Bytes()), CollisionSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || countBugs() != 1 {
+		t.Fatalf("unexpected result %+v", results[0])
+	}
+	restored := results[0].Id
+
+	// the original id still resolve to the restored bug
+	resolved, err := bug.ResolveAlias(target, b.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != restored {
+		t.Fatalf("the original id should resolve to %s, got %s", restored, resolved)
+	}
+
+	results, err = ImportAllJSON(target, bytes.NewReader(buf.Bytes()), CollisionSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Skipped || countBugs() != 1 {
+		t.Fatal("the restored bug should be kept")
+	}
+
+	results, err = ImportAllJSON(target, bytes.NewReader(buf.Bytes()), CollisionNewId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || countBugs() != 2 {
+		t.Fatal("the bug should be imported next to the restored one")
+	}
+
+	resolved, err = bug.ResolveAlias(target, b.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != restored {
+		t.Fatal("the original id should keep referring to the restored bug")
+	}
+
+	results, err = ImportAllJSON(target, bytes.NewReader(buf.Bytes()), CollisionOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || countBugs() != 2 {
+		t.Fatal("the restored bug should be replaced")
+	}
+
+	exist, err := bug.LocalBugExists(target, restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exist {
+		t.Fatal("the replaced bug should be removed")
+	}
+
+	resolved, err = bug.ResolveAlias(target, b.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != results[0].Id {
+		t.Fatal("the original id should refer to the replacement")
+	}
+}
+
+func TestImportSnapshot(t *testing.T) {
+	source := repository.NewMockRepoForTest()
+
+	b1, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		operations.Comment(b1, rene, "comment")
+		err = b1.Commit(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = ExportJSON(b1.Compile(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := repository.NewMockRepoForTest()
+
+	imported, err := ImportSnapshot(target, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap := imported.Compile()
+	if snap.Title != "title" || len(snap.Comments) != 4 {
+		t.Fatalf("unexpected imported bug %+v", snap)
+	}
+
+	// ordered after the original edits
+	if imported.EditLamportTime() <= b1.EditLamportTime() {
+		t.Fatalf("the imported bug should be edited after %d, got %d",
+			b1.EditLamportTime(), imported.EditLamportTime())
+	}
+
+	_, err = ImportSnapshot(target, []byte("{broken"))
+	if err == nil {
+		t.Fatal("a broken document should be refused")
+	}
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+type jsonBug struct {
+	Id         string          `json:"id"`
+	HumanId    string          `json:"human_id"`
+	Status     string          `json:"status"`
+	Title      string          `json:"title"`
+	Author     jsonPerson      `json:"author"`
+	Assignee   *jsonPerson     `json:"assignee,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+	LastEdit   string          `json:"last_edit"`
+	Labels     []string        `json:"labels"`
+	Comments   []jsonComment   `json:"comments"`
+	Operations []jsonOperation `json:"operations"`
+}
+
+type jsonPerson struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type jsonComment struct {
+	Id        util.Hash   `json:"id"`
+	InReplyTo util.Hash   `json:"in_reply_to,omitempty"`
+	Author    jsonPerson  `json:"author"`
+	Message   string      `json:"message"`
+	Time      string      `json:"time"`
+	Files     []util.Hash `json:"files,omitempty"`
+}
+
+type jsonOperation struct {
+	Type   string     `json:"type"`
+	Author jsonPerson `json:"author"`
+	// wall-clock time set by the author, for display only
+	Time string `json:"time"`
+	// logical time of the commit holding the operation, zero if not committed
+	EditTime util.LamportTime `json:"edit_time"`
+	Summary  string           `json:"summary"`
+}
+
+// ExportJSON write a compiled bug as indented JSON, for external tooling. The
+// operations are listed in order with both their wall-clock time and the
+// logical time of their commit. The output only depends on the bug, so the
+// same bug always give the same JSON.
+func ExportJSON(snap bug.Snapshot, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(jsonBugOf(snap))
+}
+
+// ExportAllJSON write all the local bugs as JSON Lines, one compact JSON
+// document per line in the same format as ExportJSON, in the canonical order
+func ExportAllJSON(repo repository.Repo, w io.Writer) error {
+	ids, err := bug.SortedLocalIds(repo)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for _, id := range ids {
+		b, err := bug.ReadLocalBug(repo, id)
+		if err != nil {
+			return err
+		}
+
+		err = encoder.Encode(jsonBugOf(b.Compile()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func jsonBugOf(snap bug.Snapshot) jsonBug {
+	bugJSON := jsonBug{
+		Id:         snap.Id(),
+		HumanId:    snap.HumanId(),
+		Status:     snap.Status.String(),
+		Title:      snap.Title,
+		Author:     jsonPersonOf(snap.Author),
+		CreatedAt:  jsonTime(snap.CreatedAt),
+		LastEdit:   jsonTime(snap.LastEdit()),
+		Labels:     make([]string, len(snap.Labels)),
+		Comments:   make([]jsonComment, len(snap.Comments)),
+		Operations: make([]jsonOperation, len(snap.Operations)),
+	}
+
+	if snap.Assignee != (bug.Person{}) {
+		assignee := jsonPersonOf(snap.Assignee)
+		bugJSON.Assignee = &assignee
+	}
+
+	for i, label := range snap.Labels {
+		bugJSON.Labels[i] = label.String()
+	}
+
+	for i, comment := range snap.Comments {
+		bugJSON.Comments[i] = jsonComment{
+			Id:        comment.Id,
+			InReplyTo: comment.InReplyTo,
+			Author:    jsonPersonOf(comment.Author),
+			Message:   comment.Message,
+			Time:      jsonTime(time.Unix(comment.UnixTime, 0)),
+			Files:     comment.Files,
+		}
+	}
+
+	for i, op := range snap.Operations {
+		bugJSON.Operations[i] = jsonOperation{
+			Type:     op.OpType().String(),
+			Author:   jsonPersonOf(op.GetAuthor()),
+			Time:     jsonTime(op.Time()),
+			EditTime: snap.OperationEditTime(i),
+			Summary:  summarizeOperation(op),
+		}
+	}
+
+	return bugJSON
+}
+
+func jsonPersonOf(p bug.Person) jsonPerson {
+	return jsonPerson{Name: p.Name, Email: p.Email}
+}
+
+func jsonTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestExportJSON(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations.Comment(b, rene, "a comment")
+	operations.Close(b, rene)
+	err = operations.ChangeLabels(nil, b, rene, []string{"ui", "bug"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Commit(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf1 bytes.Buffer
+	err = ExportJSON(b.Compile(), &buf1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same bug read again give the same output
+	read, err := bug.ReadLocalBug(repo, b.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf2 bytes.Buffer
+	err = ExportJSON(read.Compile(), &buf2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf1.String() != buf2.String() {
+		t.Fatalf("the output is not deterministic:\n%s\n%s", buf1.String(), buf2.String())
+	}
+
+	var decoded struct {
+		Id         string
+		Status     string
+		Title      string
+		Labels     []string
+		Comments   []struct{ Message string }
+		Operations []struct {
+			Type     string
+			EditTime uint64 `json:"edit_time"`
+		}
+	}
+	err = json.Unmarshal(buf1.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Id != b.Id() || decoded.Status != "closed" || decoded.Title != "title" {
+		t.Fatalf("unexpected bug: %+v", decoded)
+	}
+	if !reflect.DeepEqual(decoded.Labels, []string{"bug", "ui"}) {
+		t.Fatalf("unexpected labels: %v", decoded.Labels)
+	}
+	if len(decoded.Comments) != 2 || decoded.Comments[1].Message != "a comment" {
+		t.Fatalf("unexpected comments: %+v", decoded.Comments)
+	}
+
+	types := make([]string, len(decoded.Operations))
+	for i, op := range decoded.Operations {
+		types[i] = op.Type
+		if op.EditTime == 0 {
+			t.Fatalf("the operation %d should have an edit time", i)
+		}
+	}
+	expectedTypes := []string{"create", "add_comment", "set_status", "label_change"}
+	if !reflect.DeepEqual(types, expectedTypes) {
+		t.Fatalf("unexpected operations: %v", types)
+	}
+	if decoded.Operations[0].EditTime >= decoded.Operations[1].EditTime {
+		t.Fatal("the operations of the second commit should have a later edit time")
+	}
+}
@@ -46,7 +46,8 @@ func (setTitleOperationResolver) Date(ctx context.Context, obj *operations.SetTi
 
 func convertStatus(status bug.Status) (models.Status, error) {
 	switch status {
-	case bug.OpenStatus:
+	case bug.OpenStatus, bug.InProgressStatus:
+		// the schema has no in progress status, it's still open
 		return models.StatusOpen, nil
 	case bug.ClosedStatus:
 		return models.StatusClosed, nil
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func testNamedClocks(t *testing.T, repo Repo) {
+	create, err := repo.CreateTimeIncrement()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a third clock is independent of the create and edit clocks
+	comment, err := repo.ClockIncrement("comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment != 1 {
+		t.Fatalf("expected the comment clock at 1, got %d", comment)
+	}
+
+	err = repo.Witness("comment", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err = repo.ClockIncrement("comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment != 11 {
+		t.Fatalf("expected the comment clock at 11, got %d", comment)
+	}
+
+	// the create clock is the named clock "create"
+	named, err := repo.ClockIncrement(CreateClockName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if named != create+1 {
+		t.Fatalf("expected the create clock at %d, got %d", create+1, named)
+	}
+
+	edit, err := repo.EditTimeIncrement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit != 1 {
+		t.Fatalf("expected the edit clock at 1, got %d", edit)
+	}
+
+	if _, err := repo.ClockIncrement("../escape"); err == nil {
+		t.Fatal("an invalid clock name should be rejected")
+	}
+}
+
+func TestNamedClocksMock(t *testing.T) {
+	testNamedClocks(t, NewMockRepoForTest())
+}
+
+func TestNamedClocksGit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := InitGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testNamedClocks(t, repo)
+
+	// the named clocks are persisted
+	reopened, err := NewGitRepo(dir, func(*GitRepo) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := reopened.ClockIncrement("comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment != util.LamportTime(12) {
+		t.Fatalf("expected the comment clock at 12, got %d", comment)
+	}
+}
@@ -9,22 +9,27 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/MichaelMure/git-bug/util"
 )
 
-const createClockFile = "/.git/git-bug/create-clock"
-const editClockFile = "/.git/git-bug/edit-clock"
+// The named logical clocks are persisted in this directory, one file per clock
+const clockDir = "/.git/git-bug/"
+const clockFilePattern = "%s-clock"
 
 // ErrNotARepo is the error returned when the git repo root wan't be found
 var ErrNotARepo = errors.New("not a git repository")
 
 // GitRepo represents an instance of a (local) git repository.
 type GitRepo struct {
-	Path        string
-	createClock *util.PersistedLamport
-	editClock   *util.PersistedLamport
+	Path string
+
+	clocksMutex sync.Mutex
+	clocks      map[string]*util.PersistedLamport
 }
 
 // Run the given git command with the given I/O reader/writers, returning an error if it fails.
@@ -55,7 +60,7 @@ func (repo *GitRepo) runGitCommandWithStdin(stdin io.Reader, args ...string) (st
 		if stderr == "" {
 			stderr = "Error running git command: " + strings.Join(args, " ")
 		}
-		err = fmt.Errorf(stderr)
+		err = errors.New(stderr)
 	}
 	return stdout, err
 }
@@ -138,6 +143,11 @@ func (repo *GitRepo) GetPath() string {
 	return repo.Path
 }
 
+// LocalStoragePath return the directory holding the local data of git-bug
+func (repo *GitRepo) LocalStoragePath() string {
+	return path.Join(repo.Path, ".git", "git-bug")
+}
+
 // GetUserName returns the name the the user has used to configure git
 func (repo *GitRepo) GetUserName() (string, error) {
 	return repo.runGitCommand("config", "user.name")
@@ -148,6 +158,22 @@ func (repo *GitRepo) GetUserEmail() (string, error) {
 	return repo.runGitCommand("config", "user.email")
 }
 
+// GetUserIdentity returns the name and email address that the user has used
+// to configure git, as git commit would use them
+func (repo *GitRepo) GetUserIdentity() (string, string, error) {
+	name, err := repo.GetUserName()
+	if err != nil {
+		return "", "", err
+	}
+
+	email, err := repo.GetUserEmail()
+	if err != nil {
+		return "", "", err
+	}
+
+	return name, email, nil
+}
+
 // GetCoreEditor returns the name of the editor that the user has used to configure git.
 func (repo *GitRepo) GetCoreEditor() (string, error) {
 	return repo.runGitCommand("var", "GIT_EDITOR")
@@ -174,6 +200,32 @@ func (repo *GitRepo) PushRefs(remote string, refSpec string) (string, error) {
 	return stdout + stderr, nil
 }
 
+// Remotes returns the configured remotes, as a map of name --> URL
+func (repo *GitRepo) Remotes() (map[string]string, error) {
+	stdout, stderr, err := repo.runGitCommandRaw(nil, "config", "--get-regexp", `^remote\..*\.url$`)
+
+	// git config exit with an error and nothing on stderr when nothing match
+	if err != nil && stderr != "" {
+		return nil, errors.New(stderr)
+	}
+
+	remotes := make(map[string]string)
+
+	for _, line := range strings.Split(stdout, "\n") {
+		// the url can contain spaces, like a local path
+		fields := strings.SplitN(line, " ", 2)
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[0], "remote."), ".url")
+		remotes[name] = fields[1]
+	}
+
+	return remotes, nil
+}
+
 // StoreData will store arbitrary data and return the corresponding hash
 func (repo *GitRepo) StoreData(data []byte) (util.Hash, error) {
 	var stdin = bytes.NewReader(data)
@@ -197,6 +249,108 @@ func (repo *GitRepo) ReadData(hash util.Hash) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// ReadRawObject will return the type ("blob", "tree" or "commit") and the
+// raw content of a Git object, to be transferred to another repository
+func (repo *GitRepo) ReadRawObject(hash util.Hash) (string, []byte, error) {
+	objType, err := repo.runGitCommand("cat-file", "-t", string(hash))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+
+	err = repo.runGitCommandWithIO(nil, &stdout, &stderr, "cat-file", objType, string(hash))
+	if err != nil {
+		return "", nil, errors.New(strings.TrimSpace(stderr.String()))
+	}
+
+	return objType, stdout.Bytes(), nil
+}
+
+// ReadObjectTypes will return the type ("blob", "tree" or "commit") of each
+// of the given objects, in the same order, without reading them
+func (repo *GitRepo) ReadObjectTypes(hashes []util.Hash) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	var stdin bytes.Buffer
+	for _, hash := range hashes {
+		fmt.Fprintln(&stdin, hash)
+	}
+
+	stdout, err := repo.runGitCommandWithStdin(&stdin, "cat-file", "--batch-check=%(objecttype)")
+	if err != nil {
+		return nil, err
+	}
+
+	types := strings.Split(stdout, "\n")
+	if len(types) != len(hashes) {
+		return nil, fmt.Errorf("unexpected output of git cat-file: %s", stdout)
+	}
+
+	for i, objType := range types {
+		// the line of a missing object is "<hash> missing"
+		if strings.HasSuffix(objType, " missing") {
+			return nil, fmt.Errorf("unknown object %s", hashes[i])
+		}
+	}
+
+	return types, nil
+}
+
+// StoreRawObject will store a Git object read with ReadRawObject, keeping
+// its hash
+func (repo *GitRepo) StoreRawObject(objType string, data []byte) (util.Hash, error) {
+	switch objType {
+	case "blob", "tree", "commit":
+	default:
+		return "", fmt.Errorf("unsupported git object type %s", objType)
+	}
+
+	stdout, err := repo.runGitCommandWithStdin(bytes.NewReader(data),
+		"hash-object", "-t", objType, "-w", "--stdin")
+
+	return util.Hash(stdout), err
+}
+
+// ListUnreachableObjects will return the objects not reachable from any
+// reference anymore. The reflogs are ignored, as git-bug doesn't rely on them.
+func (repo *GitRepo) ListUnreachableObjects() ([]util.Hash, error) {
+	// git fsck --unreachable only report the dangling objects when there is
+	// no reference at all, so the reachable objects are listed instead
+	reachableOut, err := repo.runGitCommand("rev-list", "--objects", "--all")
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := make(map[string]bool)
+	for _, line := range strings.Split(reachableOut, "\n") {
+		// <hash> [<path>]
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			reachable[fields[0]] = true
+		}
+	}
+
+	allOut, err := repo.runGitCommand("cat-file", "--batch-all-objects", "--batch-check=%(objectname)")
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []util.Hash
+
+	for _, line := range strings.Split(allOut, "\n") {
+		if line == "" || reachable[line] {
+			continue
+		}
+		hashes = append(hashes, util.Hash(line))
+	}
+
+	return hashes, nil
+}
+
 // StoreTree will store a mapping key-->Hash as a Git tree
 func (repo *GitRepo) StoreTree(entries []TreeEntry) (util.Hash, error) {
 	buffer := prepareTreeEntries(entries)
@@ -233,6 +387,18 @@ func (repo *GitRepo) StoreCommitWithParent(treeHash util.Hash, parent util.Hash)
 	return util.Hash(stdout), nil
 }
 
+// StoreMergeCommit will store a Git commit with the given Git tree and two parents
+func (repo *GitRepo) StoreMergeCommit(treeHash util.Hash, parent1 util.Hash, parent2 util.Hash) (util.Hash, error) {
+	stdout, err := repo.runGitCommand("commit-tree", string(treeHash),
+		"-p", string(parent1), "-p", string(parent2))
+
+	if err != nil {
+		return "", err
+	}
+
+	return util.Hash(stdout), nil
+}
+
 // UpdateRef will create or update a Git reference
 func (repo *GitRepo) UpdateRef(ref string, hash util.Hash) error {
 	_, err := repo.runGitCommand("update-ref", ref, string(hash))
@@ -240,6 +406,34 @@ func (repo *GitRepo) UpdateRef(ref string, hash util.Hash) error {
 	return err
 }
 
+// UpdateRefFrom will update a Git reference only if it still point to the
+// expected hash, or create it only if it doesn't exist when the expected hash
+// is empty. ErrRefChanged is returned otherwise.
+func (repo *GitRepo) UpdateRefFrom(ref string, hash util.Hash, expected util.Hash) error {
+	old := string(expected)
+	if old == "" {
+		// git understand the null hash as "the ref must not exist"
+		old = strings.Repeat("0", 40)
+	}
+
+	_, err := repo.runGitCommand("update-ref", ref, string(hash), old)
+	if err == nil {
+		return nil
+	}
+
+	// tell apart a moved ref from another failure
+	current, resolveErr := repo.ResolveRef(ref)
+	if resolveErr != nil {
+		current = ""
+	}
+
+	if current != expected {
+		return ErrRefChanged
+	}
+
+	return err
+}
+
 // ListRefs will return a list of Git ref matching the given refspec
 func (repo *GitRepo) ListRefs(refspec string) ([]string, error) {
 	stdout, err := repo.runGitCommand("for-each-ref", "--format=%(refname)", refspec)
@@ -287,6 +481,48 @@ func (repo *GitRepo) RefExist(ref string) (bool, error) {
 	return stdout != "", nil
 }
 
+// CommitExist will check if a commit exist in Git
+func (repo *GitRepo) CommitExist(hash util.Hash) (bool, error) {
+	_, stderr, err := repo.runGitCommandRaw(nil, "rev-parse", "--quiet", "--verify", string(hash)+"^{commit}")
+
+	// rev-parse exit with an error and nothing on stderr when the commit is missing
+	if err != nil && stderr != "" {
+		return false, errors.New(stderr)
+	}
+
+	return err == nil, nil
+}
+
+// BlobExist will check if a blob exist in Git, without reading it
+func (repo *GitRepo) BlobExist(hash util.Hash) (bool, error) {
+	_, stderr, err := repo.runGitCommandRaw(nil, "cat-file", "-e", string(hash))
+
+	// cat-file exit with an error and nothing on stderr when the object is missing
+	if err != nil && stderr != "" {
+		return false, errors.New(stderr)
+	}
+	if err != nil {
+		return false, nil
+	}
+
+	objType, err := repo.runGitCommand("cat-file", "-t", string(hash))
+	if err != nil {
+		return false, err
+	}
+
+	return objType == "blob", nil
+}
+
+// BlobSize will return the size in bytes of a blob, without reading it
+func (repo *GitRepo) BlobSize(hash util.Hash) (int64, error) {
+	stdout, err := repo.runGitCommand("cat-file", "-s", string(hash))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(stdout, 10, 64)
+}
+
 // CopyRef will create a new reference with the same value as another one
 func (repo *GitRepo) CopyRef(source string, dest string) error {
 	_, err := repo.runGitCommand("update-ref", dest, source)
@@ -294,6 +530,49 @@ func (repo *GitRepo) CopyRef(source string, dest string) error {
 	return err
 }
 
+// ResolveRef will return the hash of the object a reference point to
+func (repo *GitRepo) ResolveRef(ref string) (util.Hash, error) {
+	stdout, err := repo.runGitCommand("rev-parse", "--verify", ref)
+
+	if err != nil {
+		return "", err
+	}
+
+	return util.Hash(stdout), nil
+}
+
+// UpdateSymbolicRef will create or update a symbolic reference pointing to
+// another reference
+func (repo *GitRepo) UpdateSymbolicRef(ref string, target string) error {
+	_, err := repo.runGitCommand("symbolic-ref", ref, target)
+
+	return err
+}
+
+// ResolveSymbolicRef will return the reference a symbolic reference point
+// to. A regular reference is returned as is.
+func (repo *GitRepo) ResolveSymbolicRef(ref string) (string, error) {
+	stdout, stderr, err := repo.runGitCommandRaw(nil, "symbolic-ref", "--quiet", ref)
+
+	// symbolic-ref exit with an error and nothing on stderr when the ref is
+	// not a symbolic one
+	if err != nil && stderr != "" {
+		return "", errors.New(stderr)
+	}
+	if err != nil {
+		return ref, nil
+	}
+
+	return strings.TrimSpace(stdout), nil
+}
+
+// RemoveRef will delete a Git reference
+func (repo *GitRepo) RemoveRef(ref string) error {
+	_, err := repo.runGitCommand("update-ref", "-d", ref)
+
+	return err
+}
+
 // ListCommits will return the list of commit hashes of a ref, in chronological order
 func (repo *GitRepo) ListCommits(ref string) ([]util.Hash, error) {
 	stdout, err := repo.runGitCommand("rev-list", "--first-parent", "--reverse", ref)
@@ -313,6 +592,35 @@ func (repo *GitRepo) ListCommits(ref string) ([]util.Hash, error) {
 
 }
 
+// ListCommitParents will return the parents of every commit reachable from
+// the given revision
+func (repo *GitRepo) ListCommitParents(rev string) (map[util.Hash][]util.Hash, error) {
+	stdout, err := repo.runGitCommand("rev-list", "--parents", rev)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[util.Hash][]util.Hash)
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		parents := make([]util.Hash, len(fields)-1)
+		for i, field := range fields[1:] {
+			parents[i] = util.Hash(field)
+		}
+
+		result[util.Hash(fields[0])] = parents
+	}
+
+	return result, nil
+}
+
 // ListEntries will return the list of entries in a Git tree
 func (repo *GitRepo) ListEntries(hash util.Hash) ([]TreeEntry, error) {
 	stdout, err := repo.runGitCommand("ls-tree", string(hash))
@@ -346,6 +654,22 @@ func (repo *GitRepo) GetTreeHash(commit util.Hash) (util.Hash, error) {
 	return util.Hash(stdout), nil
 }
 
+// GetCommitTime return the time a commit was made at, as recorded by its
+// committer
+func (repo *GitRepo) GetCommitTime(commit util.Hash) (time.Time, error) {
+	stdout, err := repo.runGitCommand("show", "-s", "--format=%ct", string(commit))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	unixTime, err := strconv.ParseInt(stdout, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(unixTime, 0), nil
+}
+
 // AddRemote add a new remote to the repository
 // Not in the interface because it's only used for testing
 func (repo *GitRepo) AddRemote(name string, url string) error {
@@ -354,56 +678,117 @@ func (repo *GitRepo) AddRemote(name string, url string) error {
 	return err
 }
 
+func (repo *GitRepo) clockPath(name string) string {
+	return path.Join(repo.Path, clockDir, fmt.Sprintf(clockFilePattern, name))
+}
+
 func (repo *GitRepo) createClocks() {
-	createPath := path.Join(repo.Path, createClockFile)
-	repo.createClock = util.NewPersistedLamport(createPath)
+	repo.clocksMutex.Lock()
+	defer repo.clocksMutex.Unlock()
 
-	editPath := path.Join(repo.Path, editClockFile)
-	repo.editClock = util.NewPersistedLamport(editPath)
+	repo.clocks = make(map[string]*util.PersistedLamport)
+	for _, name := range []string{CreateClockName, EditClockName} {
+		repo.clocks[name] = util.NewPersistedLamport(repo.clockPath(name))
+	}
 }
 
+// LoadClocks read the create and edit clocks. The other named clocks are read
+// when first used.
 func (repo *GitRepo) LoadClocks() error {
-	createClock, err := util.LoadPersistedLamport(repo.GetPath() + createClockFile)
-	if err != nil {
-		return err
-	}
+	clocks := make(map[string]*util.PersistedLamport)
 
-	editClock, err := util.LoadPersistedLamport(repo.GetPath() + editClockFile)
-	if err != nil {
-		return err
+	for _, name := range []string{CreateClockName, EditClockName} {
+		clock, err := util.LoadPersistedLamport(repo.clockPath(name))
+		if err != nil {
+			return err
+		}
+		clocks[name] = clock
 	}
 
-	repo.createClock = createClock
-	repo.editClock = editClock
+	repo.clocksMutex.Lock()
+	defer repo.clocksMutex.Unlock()
+
+	repo.clocks = clocks
 	return nil
 }
 
 func (repo *GitRepo) WriteClocks() error {
-	err := repo.createClock.Write()
+	repo.clocksMutex.Lock()
+	defer repo.clocksMutex.Unlock()
+
+	for _, clock := range repo.clocks {
+		err := clock.Write()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getClock return a named clock, reading it or creating it if needed
+func (repo *GitRepo) getClock(name string) (*util.PersistedLamport, error) {
+	if err := checkClockName(name); err != nil {
+		return nil, err
+	}
+
+	repo.clocksMutex.Lock()
+	defer repo.clocksMutex.Unlock()
+
+	if repo.clocks == nil {
+		repo.clocks = make(map[string]*util.PersistedLamport)
+	}
+
+	if clock, ok := repo.clocks[name]; ok {
+		return clock, nil
+	}
+
+	clockPath := repo.clockPath(name)
+
+	clock, err := util.LoadPersistedLamport(clockPath)
+	if os.IsNotExist(err) {
+		clock, err = util.NewPersistedLamport(clockPath), nil
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	repo.clocks[name] = clock
+	return clock, nil
+}
+
+// ClockIncrement increment a named clock and return its new value
+func (repo *GitRepo) ClockIncrement(name string) (util.LamportTime, error) {
+	clock, err := repo.getClock(name)
+	if err != nil {
+		return 0, err
 	}
 
-	err = repo.editClock.Write()
+	return clock.Increment()
+}
+
+// Witness update a named clock with a value seen in the data
+func (repo *GitRepo) Witness(name string, time util.LamportTime) error {
+	clock, err := repo.getClock(name)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return clock.Witness(time)
 }
 
 func (repo *GitRepo) CreateTimeIncrement() (util.LamportTime, error) {
-	return repo.createClock.Increment()
+	return repo.ClockIncrement(CreateClockName)
 }
 
 func (repo *GitRepo) EditTimeIncrement() (util.LamportTime, error) {
-	return repo.editClock.Increment()
+	return repo.ClockIncrement(EditClockName)
 }
 
 func (repo *GitRepo) CreateWitness(time util.LamportTime) error {
-	return repo.createClock.Witness(time)
+	return repo.Witness(CreateClockName, time)
 }
 
 func (repo *GitRepo) EditWitness(time util.LamportTime) error {
-	return repo.editClock.Witness(time)
+	return repo.Witness(EditClockName, time)
 }
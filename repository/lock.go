@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const lockFile = "/.git/git-bug/lock"
+
+var ErrLocked = errors.New("another git-bug operation is in progress")
+
+// LockTimeout is how long to wait for a lock held by another process
+var LockTimeout = 5 * time.Second
+
+// Locker serialize the mutating operations of different processes, like a
+// commit or a merge, so that they don't overwrite each other's refs
+type Locker interface {
+	// Lock acquire the repository lock, waiting up to LockTimeout
+	Lock() error
+
+	// Unlock release the repository lock
+	Unlock() error
+}
+
+// Lock acquire the repository lock, waiting up to LockTimeout. A lock left
+// behind by a process that doesn't exist anymore is broken. As another
+// process might have changed them, the clocks are reloaded once the lock is
+// held.
+func (repo *GitRepo) Lock() error {
+	lockPath := path.Join(repo.Path, lockFile)
+
+	err := os.MkdirAll(path.Dir(lockPath), 0777)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d", os.Getpid())
+			f.Close()
+			if err != nil {
+				return err
+			}
+			break
+		}
+
+		if !os.IsExist(err) {
+			return err
+		}
+
+		if isStaleLock(lockPath) {
+			// another process might have broken it already
+			err = os.Remove(lockPath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = repo.LoadClocks()
+	if err != nil && !os.IsNotExist(err) {
+		repo.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Unlock release the repository lock
+func (repo *GitRepo) Unlock() error {
+	return os.Remove(path.Join(repo.Path, lockFile))
+}
+
+// isStaleLock tell if the lock file has been left behind by a process that
+// is not running anymore
+func isStaleLock(lockPath string) bool {
+	data, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		// the owner might not have written its PID yet
+		return false
+	}
+
+	return !processExist(pid)
+}
+
+func processExist(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer process.Release()
+
+	// on windows, FindProcess already fail if the process doesn't exist
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	// EPERM means that the process exist but belong to another user
+	return err == nil || err == syscall.EPERM
+}
@@ -1,36 +1,44 @@
 package repository
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/MichaelMure/git-bug/util"
 )
 
 // mockRepoForTest defines an instance of Repo that can be used for testing.
+// It keeps everything in memory and doesn't rely on git at all.
 type mockRepoForTest struct {
-	blobs       map[util.Hash][]byte
-	trees       map[util.Hash]string
-	commits     map[util.Hash]commit
-	refs        map[string]util.Hash
-	createClock util.LamportClock
-	editClock   util.LamportClock
+	blobs   map[util.Hash][]byte
+	trees   map[util.Hash]string
+	commits map[util.Hash]commit
+	refs    map[string]util.Hash
+	symrefs map[string]string
+	remotes map[string]string
+	clocks  map[string]*util.LamportClock
+	locked  bool
 }
 
 type commit struct {
 	treeHash util.Hash
-	parent   util.Hash
+	parents  []util.Hash
+	time     time.Time
 }
 
 func NewMockRepoForTest() Repo {
 	return &mockRepoForTest{
-		blobs:       make(map[util.Hash][]byte),
-		trees:       make(map[util.Hash]string),
-		commits:     make(map[util.Hash]commit),
-		refs:        make(map[string]util.Hash),
-		createClock: util.NewLamportClock(),
-		editClock:   util.NewLamportClock(),
+		blobs:   make(map[util.Hash][]byte),
+		trees:   make(map[util.Hash]string),
+		commits: make(map[util.Hash]commit),
+		refs:    make(map[string]util.Hash),
+		symrefs: make(map[string]string),
+		remotes: make(map[string]string),
+		clocks:  make(map[string]*util.LamportClock),
 	}
 }
 
@@ -48,6 +56,12 @@ func (r *mockRepoForTest) GetUserEmail() (string, error) {
 	return "user@example.com", nil
 }
 
+// GetUserIdentity returns the name and email address that the user has used
+// to configure git, as git commit would use them
+func (r *mockRepoForTest) GetUserIdentity() (string, string, error) {
+	return "René Descartes", "user@example.com", nil
+}
+
 // GetCoreEditor returns the name of the editor that the user has used to configure git.
 func (r *mockRepoForTest) GetCoreEditor() (string, error) {
 	return "vi", nil
@@ -62,6 +76,21 @@ func (r *mockRepoForTest) FetchRefs(remote string, refSpec string) (string, erro
 	return "", nil
 }
 
+func (r *mockRepoForTest) Remotes() (map[string]string, error) {
+	remotes := make(map[string]string, len(r.remotes))
+	for name, url := range r.remotes {
+		remotes[name] = url
+	}
+	return remotes, nil
+}
+
+// AddRemote add a new remote to the repository
+// Not in the interface because it's only used for testing
+func (r *mockRepoForTest) AddRemote(name string, url string) error {
+	r.remotes[name] = url
+	return nil
+}
+
 func (r *mockRepoForTest) StoreData(data []byte) (util.Hash, error) {
 	rawHash := sha1.Sum(data)
 	hash := util.Hash(fmt.Sprintf("%x", rawHash))
@@ -93,6 +122,7 @@ func (r *mockRepoForTest) StoreCommit(treeHash util.Hash) (util.Hash, error) {
 	hash := util.Hash(fmt.Sprintf("%x", rawHash))
 	r.commits[hash] = commit{
 		treeHash: treeHash,
+		time:     time.Now(),
 	}
 	return hash, nil
 }
@@ -102,7 +132,19 @@ func (r *mockRepoForTest) StoreCommitWithParent(treeHash util.Hash, parent util.
 	hash := util.Hash(fmt.Sprintf("%x", rawHash))
 	r.commits[hash] = commit{
 		treeHash: treeHash,
-		parent:   parent,
+		parents:  []util.Hash{parent},
+		time:     time.Now(),
+	}
+	return hash, nil
+}
+
+func (r *mockRepoForTest) StoreMergeCommit(treeHash util.Hash, parent1 util.Hash, parent2 util.Hash) (util.Hash, error) {
+	rawHash := sha1.Sum([]byte(treeHash + parent1 + parent2))
+	hash := util.Hash(fmt.Sprintf("%x", rawHash))
+	r.commits[hash] = commit{
+		treeHash: treeHash,
+		parents:  []util.Hash{parent1, parent2},
+		time:     time.Now(),
 	}
 	return hash, nil
 }
@@ -112,11 +154,41 @@ func (r *mockRepoForTest) UpdateRef(ref string, hash util.Hash) error {
 	return nil
 }
 
+func (r *mockRepoForTest) UpdateRefFrom(ref string, hash util.Hash, expected util.Hash) error {
+	ref, _ = r.ResolveSymbolicRef(ref)
+
+	if r.refs[ref] != expected {
+		return ErrRefChanged
+	}
+
+	r.refs[ref] = hash
+	return nil
+}
+
 func (r *mockRepoForTest) RefExist(ref string) (bool, error) {
+	ref, _ = r.ResolveSymbolicRef(ref)
 	_, exist := r.refs[ref]
 	return exist, nil
 }
 
+func (r *mockRepoForTest) CommitExist(hash util.Hash) (bool, error) {
+	_, exist := r.commits[hash]
+	return exist, nil
+}
+
+func (r *mockRepoForTest) BlobExist(hash util.Hash) (bool, error) {
+	_, exist := r.blobs[hash]
+	return exist, nil
+}
+
+func (r *mockRepoForTest) BlobSize(hash util.Hash) (int64, error) {
+	data, exist := r.blobs[hash]
+	if !exist {
+		return 0, fmt.Errorf("unknown hash")
+	}
+	return int64(len(data)), nil
+}
+
 func (r *mockRepoForTest) CopyRef(source string, dest string) error {
 	hash, exist := r.refs[source]
 
@@ -128,13 +200,57 @@ func (r *mockRepoForTest) CopyRef(source string, dest string) error {
 	return nil
 }
 
+func (r *mockRepoForTest) ResolveRef(ref string) (util.Hash, error) {
+	ref, _ = r.ResolveSymbolicRef(ref)
+	hash, exist := r.refs[ref]
+
+	if exist {
+		return hash, nil
+	}
+
+	// Git will understand a commit hash as well
+	if _, ok := r.commits[util.Hash(ref)]; ok {
+		return util.Hash(ref), nil
+	}
+
+	return "", fmt.Errorf("Unknown ref")
+}
+
+func (r *mockRepoForTest) UpdateSymbolicRef(ref string, target string) error {
+	delete(r.refs, ref)
+	r.symrefs[ref] = target
+	return nil
+}
+
+func (r *mockRepoForTest) ResolveSymbolicRef(ref string) (string, error) {
+	visited := make(map[string]bool)
+
+	for {
+		target, ok := r.symrefs[ref]
+		if !ok {
+			return ref, nil
+		}
+		if visited[ref] {
+			return "", fmt.Errorf("symbolic ref loop")
+		}
+		visited[ref] = true
+		ref = target
+	}
+}
+
+func (r *mockRepoForTest) RemoveRef(ref string) error {
+	delete(r.refs, ref)
+	delete(r.symrefs, ref)
+	return nil
+}
+
 func (r *mockRepoForTest) ListRefs(refspec string) ([]string, error) {
-	keys := make([]string, len(r.refs))
+	var keys []string
 
-	i := 0
 	for k := range r.refs {
-		keys[i] = k
-		i++
+		if strings.HasPrefix(k, refspec) {
+			keys = append(keys, k)
+		}
 	}
 
 	return keys, nil
@@ -143,13 +259,13 @@ func (r *mockRepoForTest) ListRefs(refspec string) ([]string, error) {
 // ListIds will return a list of Git ref matching the given refspec,
 // stripped to only the last part of the ref
 func (r *mockRepoForTest) ListIds(refspec string) ([]string, error) {
-	keys := make([]string, len(r.refs))
+	var keys []string
 
-	i := 0
 	for k := range r.refs {
-		splitted := strings.Split(k, "/")
-		keys[i] = splitted[len(splitted)-1]
-		i++
+		if strings.HasPrefix(k, refspec) {
+			splitted := strings.Split(k, "/")
+			keys = append(keys, splitted[len(splitted)-1])
+		}
 	}
 
 	return keys, nil
@@ -158,6 +274,7 @@ func (r *mockRepoForTest) ListIds(refspec string) ([]string, error) {
 func (r *mockRepoForTest) ListCommits(ref string) ([]util.Hash, error) {
 	var hashes []util.Hash
 
+	ref, _ = r.ResolveSymbolicRef(ref)
 	hash := r.refs[ref]
 
 	for {
@@ -168,12 +285,45 @@ func (r *mockRepoForTest) ListCommits(ref string) ([]util.Hash, error) {
 		}
 
 		hashes = append([]util.Hash{hash}, hashes...)
-		hash = commit.parent
+
+		if len(commit.parents) == 0 {
+			break
+		}
+		hash = commit.parents[0]
 	}
 
 	return hashes, nil
 }
 
+func (r *mockRepoForTest) ListCommitParents(rev string) (map[util.Hash][]util.Hash, error) {
+	head, err := r.ResolveRef(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[util.Hash][]util.Hash)
+	queue := []util.Hash{head}
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if _, ok := result[hash]; ok {
+			continue
+		}
+
+		c, ok := r.commits[hash]
+		if !ok {
+			return nil, fmt.Errorf("unknown commit %s", hash)
+		}
+
+		result[hash] = c.parents
+		queue = append(queue, c.parents...)
+	}
+
+	return result, nil
+}
+
 func (r *mockRepoForTest) ListEntries(hash util.Hash) ([]TreeEntry, error) {
 	var data string
 
@@ -198,11 +348,199 @@ func (r *mockRepoForTest) ListEntries(hash util.Hash) ([]TreeEntry, error) {
 }
 
 func (r *mockRepoForTest) FindCommonAncestor(hash1 util.Hash, hash2 util.Hash) (util.Hash, error) {
-	panic("implement me")
+	ancestors, err := r.ListCommitParents(string(hash1))
+	if err != nil {
+		return "", err
+	}
+
+	// walk the history of the second commit, closest commits first
+	visited := make(map[util.Hash]struct{})
+	queue := []util.Hash{hash2}
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if _, ok := ancestors[hash]; ok {
+			return hash, nil
+		}
+
+		if _, ok := visited[hash]; ok {
+			continue
+		}
+		visited[hash] = struct{}{}
+
+		c, ok := r.commits[hash]
+		if !ok {
+			return "", fmt.Errorf("unknown commit %s", hash)
+		}
+
+		queue = append(queue, c.parents...)
+	}
+
+	return "", fmt.Errorf("no common ancestor")
 }
 
 func (r *mockRepoForTest) GetTreeHash(commit util.Hash) (util.Hash, error) {
-	panic("implement me")
+	c, ok := r.commits[commit]
+
+	if !ok {
+		return "", fmt.Errorf("unknown commit")
+	}
+
+	return c.treeHash, nil
+}
+
+func (r *mockRepoForTest) GetCommitTime(commit util.Hash) (time.Time, error) {
+	c, ok := r.commits[commit]
+
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown commit")
+	}
+
+	return c.time, nil
+}
+
+func (r *mockRepoForTest) ReadRawObject(hash util.Hash) (string, []byte, error) {
+	if data, ok := r.blobs[hash]; ok {
+		return "blob", data, nil
+	}
+
+	if data, ok := r.trees[hash]; ok {
+		return "tree", []byte(data), nil
+	}
+
+	if c, ok := r.commits[hash]; ok {
+		var buffer bytes.Buffer
+		fmt.Fprintf(&buffer, "tree %s\n", c.treeHash)
+		for _, parent := range c.parents {
+			fmt.Fprintf(&buffer, "parent %s\n", parent)
+		}
+		return "commit", buffer.Bytes(), nil
+	}
+
+	return "", nil, fmt.Errorf("unknown object %s", hash)
+}
+
+func (r *mockRepoForTest) ReadObjectTypes(hashes []util.Hash) ([]string, error) {
+	types := make([]string, 0, len(hashes))
+
+	for _, hash := range hashes {
+		objType, _, err := r.ReadRawObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, objType)
+	}
+
+	return types, nil
+}
+
+func (r *mockRepoForTest) StoreRawObject(objType string, data []byte) (util.Hash, error) {
+	switch objType {
+	case "blob":
+		return r.StoreData(data)
+
+	case "tree":
+		entries, err := readTreeEntries(string(data))
+		if err != nil {
+			return "", err
+		}
+		return r.StoreTree(entries)
+
+	case "commit":
+		var treeHash util.Hash
+		var parents []util.Hash
+
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				return "", fmt.Errorf("invalid commit")
+			}
+			switch fields[0] {
+			case "tree":
+				treeHash = util.Hash(fields[1])
+			case "parent":
+				parents = append(parents, util.Hash(fields[1]))
+			}
+		}
+
+		switch len(parents) {
+		case 0:
+			return r.StoreCommit(treeHash)
+		case 1:
+			return r.StoreCommitWithParent(treeHash, parents[0])
+		case 2:
+			return r.StoreMergeCommit(treeHash, parents[0], parents[1])
+		default:
+			return "", fmt.Errorf("invalid commit")
+		}
+
+	default:
+		return "", fmt.Errorf("unsupported git object type %s", objType)
+	}
+}
+
+func (r *mockRepoForTest) reachableObjects() map[util.Hash]bool {
+	reachable := make(map[util.Hash]bool)
+
+	var queue []util.Hash
+	for _, hash := range r.refs {
+		queue = append(queue, hash)
+	}
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if reachable[hash] {
+			continue
+		}
+		reachable[hash] = true
+
+		if c, ok := r.commits[hash]; ok {
+			queue = append(queue, c.treeHash)
+			queue = append(queue, c.parents...)
+			continue
+		}
+
+		if data, ok := r.trees[hash]; ok {
+			entries, err := readTreeEntries(data)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				queue = append(queue, entry.Hash)
+			}
+		}
+	}
+
+	return reachable
+}
+
+func (r *mockRepoForTest) ListUnreachableObjects() ([]util.Hash, error) {
+	reachable := r.reachableObjects()
+
+	var hashes []util.Hash
+	for hash := range r.blobs {
+		if !reachable[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+	for hash := range r.trees {
+		if !reachable[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+	for hash := range r.commits {
+		if !reachable[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	return hashes, nil
 }
 
 func (r *mockRepoForTest) LoadClocks() error {
@@ -213,20 +551,66 @@ func (r *mockRepoForTest) WriteClocks() error {
 	return nil
 }
 
+func (r *mockRepoForTest) getClock(name string) (*util.LamportClock, error) {
+	if err := checkClockName(name); err != nil {
+		return nil, err
+	}
+
+	clock, ok := r.clocks[name]
+	if !ok {
+		newClock := util.NewLamportClock()
+		clock = &newClock
+		r.clocks[name] = clock
+	}
+
+	return clock, nil
+}
+
+func (r *mockRepoForTest) ClockIncrement(name string) (util.LamportTime, error) {
+	clock, err := r.getClock(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return clock.Increment(), nil
+}
+
+func (r *mockRepoForTest) Witness(name string, time util.LamportTime) error {
+	clock, err := r.getClock(name)
+	if err != nil {
+		return err
+	}
+
+	clock.Witness(time)
+	return nil
+}
+
 func (r *mockRepoForTest) CreateTimeIncrement() (util.LamportTime, error) {
-	return r.createClock.Increment(), nil
+	return r.ClockIncrement(CreateClockName)
 }
 
 func (r *mockRepoForTest) EditTimeIncrement() (util.LamportTime, error) {
-	return r.editClock.Increment(), nil
+	return r.ClockIncrement(EditClockName)
 }
 
 func (r *mockRepoForTest) CreateWitness(time util.LamportTime) error {
-	r.createClock.Witness(time)
-	return nil
+	return r.Witness(CreateClockName, time)
 }
 
 func (r *mockRepoForTest) EditWitness(time util.LamportTime) error {
-	r.editClock.Witness(time)
+	return r.Witness(EditClockName, time)
+}
+
+func (r *mockRepoForTest) Lock() error {
+	// nothing can release the lock while waiting, fail right away
+	if r.locked {
+		return ErrLocked
+	}
+	r.locked = true
+	return nil
+}
+
+func (r *mockRepoForTest) Unlock() error {
+	r.locked = false
 	return nil
 }
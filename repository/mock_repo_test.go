@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMockRemotes(t *testing.T) {
+	repo := NewMockRepoForTest().(*mockRepoForTest)
+
+	remotes, err := repo.Remotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 0 {
+		t.Fatal("no remote should be configured yet")
+	}
+
+	repo.AddRemote("origin", "https://example.com/origin.git")
+	repo.AddRemote("upstream", "file:///tmp/upstream")
+
+	remotes, err = repo.Remotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"origin":   "https://example.com/origin.git",
+		"upstream": "file:///tmp/upstream",
+	}
+
+	if !reflect.DeepEqual(remotes, expected) {
+		t.Fatalf("%v different than %v", remotes, expected)
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func testBlobs(t *testing.T, repo Repo) {
+	blob, err := repo.StoreData([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.StoreTree([]TreeEntry{{ObjectType: Blob, Hash: blob, Name: "data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[util.Hash]bool{
+		blob: true,
+		tree: false,
+		"0123456789012345678901234567890123456789": false,
+	}
+
+	for hash, expected := range cases {
+		exist, err := repo.BlobExist(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exist != expected {
+			t.Fatalf("%s: expected %v, got %v", hash, expected, exist)
+		}
+	}
+
+	commit, err := repo.StoreCommit(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types, err := repo.ReadObjectTypes([]util.Hash{blob, tree, commit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 3 || types[0] != "blob" || types[1] != "tree" || types[2] != "commit" {
+		t.Fatalf("unexpected types %v", types)
+	}
+
+	_, err = repo.ReadObjectTypes([]util.Hash{"0123456789012345678901234567890123456789"})
+	if err == nil {
+		t.Fatal("an unknown object should be an error")
+	}
+
+	size, err := repo.BlobSize(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 4 {
+		t.Fatalf("expected a size of 4, got %d", size)
+	}
+}
+
+func TestBlobsMock(t *testing.T) {
+	testBlobs(t, NewMockRepoForTest())
+}
+
+func TestBlobsGit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := InitGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBlobs(t, repo)
+}
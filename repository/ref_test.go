@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testUpdateRefFrom(t *testing.T, repo Repo) {
+	blob, err := repo.StoreData([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.StoreTree([]TreeEntry{{ObjectType: Blob, Hash: blob, Name: "data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.StoreCommit(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.StoreCommitWithParent(tree, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ref = "refs/bugs/test"
+
+	// created only if it doesn't exist
+	if err := repo.UpdateRefFrom(ref, first, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateRefFrom(ref, second, ""); err != ErrRefChanged {
+		t.Fatalf("expected ErrRefChanged, got %v", err)
+	}
+
+	// updated only from the expected hash
+	if err := repo.UpdateRefFrom(ref, first, second); err != ErrRefChanged {
+		t.Fatalf("expected ErrRefChanged, got %v", err)
+	}
+	if err := repo.UpdateRefFrom(ref, second, first); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := repo.ResolveRef(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != second {
+		t.Fatalf("expected the ref at %s, got %s", second, head)
+	}
+}
+
+func TestUpdateRefFromMock(t *testing.T) {
+	testUpdateRefFrom(t, NewMockRepoForTest())
+}
+
+func TestUpdateRefFromGit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := InitGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testUpdateRefFrom(t, repo)
+}
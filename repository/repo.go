@@ -3,13 +3,20 @@ package repository
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/MichaelMure/git-bug/util"
 )
 
-// Repo represents a source code repository.
-type Repo interface {
+// ErrRefChanged is returned by UpdateRefFrom when the reference doesn't point
+// to the expected hash anymore
+var ErrRefChanged = errors.New("the reference has been changed meanwhile")
+
+// RepoCommon represent the common function the we want all the repo to implement
+type RepoCommon interface {
 	// GetPath returns the path to the repo.
 	GetPath() string
 
@@ -19,6 +26,10 @@ type Repo interface {
 	// GetUserEmail returns the email address that the user has used to configure git.
 	GetUserEmail() (string, error)
 
+	// GetUserIdentity returns the name and email address that the user has
+	// used to configure git, as git commit would use them
+	GetUserIdentity() (name string, email string, err error)
+
 	// GetCoreEditor returns the name of the editor that the user has used to configure git.
 	GetCoreEditor() (string, error)
 
@@ -28,6 +39,14 @@ type Repo interface {
 	// PushRefs push git refs to a remote
 	PushRefs(remote string, refSpec string) (string, error)
 
+	// Remotes returns the configured remotes, as a map of name --> URL
+	Remotes() (map[string]string, error)
+}
+
+// RepoStorage is the set of operations needed to persist and read the bugs.
+// Git is the default backend, but anything able to store blobs, trees, commits
+// and references can implement it.
+type RepoStorage interface {
 	// StoreData will store arbitrary data and return the corresponding hash
 	StoreData(data []byte) (util.Hash, error)
 
@@ -43,9 +62,17 @@ type Repo interface {
 	// StoreCommit will store a Git commit with the given Git tree
 	StoreCommitWithParent(treeHash util.Hash, parent util.Hash) (util.Hash, error)
 
+	// StoreMergeCommit will store a Git commit with the given Git tree and two parents
+	StoreMergeCommit(treeHash util.Hash, parent1 util.Hash, parent2 util.Hash) (util.Hash, error)
+
 	// UpdateRef will create or update a Git reference
 	UpdateRef(ref string, hash util.Hash) error
 
+	// UpdateRefFrom will update a Git reference only if it still point to the
+	// expected hash, or create it only if it doesn't exist when the expected
+	// hash is empty. ErrRefChanged is returned otherwise.
+	UpdateRefFrom(ref string, hash util.Hash, expected util.Hash) error
+
 	// ListRefs will return a list of Git ref matching the given refspec
 	ListRefs(refspec string) ([]string, error)
 
@@ -56,12 +83,39 @@ type Repo interface {
 	// RefExist will check if a reference exist in Git
 	RefExist(ref string) (bool, error)
 
+	// CommitExist will check if a commit exist in Git
+	CommitExist(hash util.Hash) (bool, error)
+
+	// BlobExist will check if a blob exist in Git, without reading it
+	BlobExist(hash util.Hash) (bool, error)
+
+	// BlobSize will return the size in bytes of a blob, without reading it
+	BlobSize(hash util.Hash) (int64, error)
+
 	// CopyRef will create a new reference with the same value as another one
 	CopyRef(source string, dest string) error
 
+	// ResolveRef will return the hash of the object a reference point to
+	ResolveRef(ref string) (util.Hash, error)
+
+	// UpdateSymbolicRef will create or update a symbolic reference pointing to
+	// another reference
+	UpdateSymbolicRef(ref string, target string) error
+
+	// ResolveSymbolicRef will return the reference a symbolic reference point
+	// to. A regular reference is returned as is.
+	ResolveSymbolicRef(ref string) (string, error)
+
+	// RemoveRef will delete a Git reference
+	RemoveRef(ref string) error
+
 	// ListCommits will return the list of tree hashes of a ref, in chronological order
 	ListCommits(ref string) ([]util.Hash, error)
 
+	// ListCommitParents will return the parents of every commit reachable from
+	// the given revision
+	ListCommitParents(rev string) (map[util.Hash][]util.Hash, error)
+
 	// ListEntries will return the list of entries in a Git tree
 	ListEntries(hash util.Hash) ([]TreeEntry, error)
 
@@ -71,10 +125,50 @@ type Repo interface {
 	// GetTreeHash return the git tree hash referenced in a commit
 	GetTreeHash(commit util.Hash) (util.Hash, error)
 
+	// GetCommitTime return the time a commit was made at, as recorded by its
+	// committer
+	GetCommitTime(commit util.Hash) (time.Time, error)
+
+	// ReadRawObject will return the type ("blob", "tree" or "commit") and the
+	// raw content of a Git object, to be transferred to another repository
+	ReadRawObject(hash util.Hash) (string, []byte, error)
+
+	// ReadObjectTypes will return the type ("blob", "tree" or "commit") of
+	// each of the given objects, in the same order, without reading them
+	ReadObjectTypes(hashes []util.Hash) ([]string, error)
+
+	// StoreRawObject will store a Git object read with ReadRawObject, keeping
+	// its hash
+	StoreRawObject(objType string, data []byte) (util.Hash, error)
+
+	// ListUnreachableObjects will return the objects not reachable from any
+	// reference anymore
+	ListUnreachableObjects() ([]util.Hash, error)
+}
+
+// The names of the logical clocks of the bugs
+const (
+	CreateClockName = "create"
+	EditClockName   = "edit"
+)
+
+// RepoClock give access to the logical clocks of the repository
+type RepoClock interface {
 	LoadClocks() error
 
 	WriteClocks() error
 
+	// ClockIncrement increment the logical clock with the given name and
+	// return its new value. A clock is created the first time it's used, so
+	// each type of entity can have its own independent clock.
+	ClockIncrement(name string) (util.LamportTime, error)
+
+	// Witness update the logical clock with the given name with a value seen
+	// in the data
+	Witness(name string, time util.LamportTime) error
+
+	// The create and edit clocks, as named clocks
+
 	CreateTimeIncrement() (util.LamportTime, error)
 
 	EditTimeIncrement() (util.LamportTime, error)
@@ -84,6 +178,36 @@ type Repo interface {
 	EditWitness(time util.LamportTime) error
 }
 
+// LocalStorage is implemented by the repositories able to store on disk some
+// local data of git-bug, like a cache, that is never shared
+type LocalStorage interface {
+	// LocalStoragePath return the directory holding the local data
+	LocalStoragePath() string
+}
+
+// Repo represents a source code repository.
+type Repo interface {
+	RepoCommon
+	RepoStorage
+	RepoClock
+	Locker
+}
+
+// checkClockName verify that a clock name can be used as a file name
+func checkClockName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty clock name")
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+			return fmt.Errorf("invalid clock name %q", name)
+		}
+	}
+
+	return nil
+}
+
 func prepareTreeEntries(entries []TreeEntry) bytes.Buffer {
 	var buffer bytes.Buffer
 
@@ -97,8 +221,8 @@ func prepareTreeEntries(entries []TreeEntry) bytes.Buffer {
 func readTreeEntries(s string) ([]TreeEntry, error) {
 	splitted := strings.Split(s, "\n")
 
-	casted := make([]TreeEntry, len(splitted))
-	for i, line := range splitted {
+	casted := make([]TreeEntry, 0, len(splitted))
+	for _, line := range splitted {
 		if line == "" {
 			continue
 		}
@@ -109,7 +233,7 @@ func readTreeEntries(s string) ([]TreeEntry, error) {
 			return nil, err
 		}
 
-		casted[i] = entry
+		casted = append(casted, entry)
 	}
 
 	return casted, nil
@@ -258,6 +258,10 @@ func (bt *bugTable) getTableLength() int {
 func (bt *bugTable) getColumnWidths(maxX int) map[string]int {
 	m := make(map[string]int)
 	m["id"] = 10
+	// the human ids get longer in a large repository
+	for _, b := range bt.bugs {
+		m["id"] = maxInt(m["id"], len(b.Snapshot().HumanId())+2)
+	}
 	m["status"] = 8
 
 	left := maxX - 5 - m["id"] - m["status"]
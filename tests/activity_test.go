@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestTailActivity(t *testing.T) {
+	// the mock repo is not safe for concurrent use
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	defer func(interval time.Duration) { bug.ActivityPollInterval = interval }(bug.ActivityPollInterval)
+	bug.ActivityPollInterval = 10 * time.Millisecond
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	activity, err := bug.TailActivity(repo, ctx)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	select {
+	case entry := <-activity:
+		checkErr(t, entry.Err)
+
+		if entry.BugId != bug1.Id() {
+			t.Fatal("unexpected bug id")
+		}
+
+		comment, ok := entry.Operation.(operations.AddCommentOperation)
+		if !ok || comment.Message != "message2" {
+			t.Fatal("the committed operation should be emitted")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no activity received")
+	}
+
+	cancel()
+
+	// the channel is closed on cancellation
+	for range activity {
+	}
+}
+
+func TestTailActivityMerge(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	defer func(interval time.Duration) { bug.ActivityPollInterval = interval }(bug.ActivityPollInterval)
+	bug.ActivityPollInterval = 10 * time.Millisecond
+
+	bug1, _ := divergedBug(t, repo, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	activity, err := bug.TailActivity(repo, ctx)
+	checkErr(t, err)
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+	_, err = bug.MergeIncremental(repo, bug1.Id(), remote)
+	checkErr(t, err)
+
+	// only the remote operations are new, the rebased local ones are not
+	var entries []bug.ActivityEntry
+	timeout := time.After(500 * time.Millisecond)
+
+loop:
+	for {
+		select {
+		case entry := <-activity:
+			checkErr(t, entry.Err)
+			entries = append(entries, entry)
+		case <-timeout:
+			break loop
+		}
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 remote operations, got %d entries", len(entries))
+	}
+	if entries[0].Operation.OpType() != bug.AddCommentOp || entries[1].Operation.OpType() != bug.SetStatusOp {
+		t.Fatalf("unexpected operations %s and %s", entries[0].Operation.OpType(), entries[1].Operation.OpType())
+	}
+}
+
+func TestActivityEntryError(t *testing.T) {
+	entry := bug.ActivityEntry{BugId: "0123456789", Err: errors.New("failure")}
+
+	if entry.String() != "01234567 error: failure" {
+		t.Fatalf("unexpected entry %q", entry.String())
+	}
+}
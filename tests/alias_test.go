@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// rewrite the history of a bug as a single commit holding all its operations
+func squashBug(t *testing.T, repo repository.Repo, b *bug.Bug) {
+	pack := bug.OperationPack{}
+	it := bug.NewOperationIterator(b)
+	for it.Next() {
+		pack.Append(it.Value())
+	}
+
+	hash, err := pack.Write(repo)
+	checkErr(t, err)
+
+	emptyBlob, err := repo.StoreData([]byte{})
+	checkErr(t, err)
+
+	tree, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: hash, Name: "ops"},
+		{ObjectType: repository.Blob, Hash: hash, Name: "root"},
+		{ObjectType: repository.Blob, Hash: emptyBlob, Name: "create-clock-1"},
+		{ObjectType: repository.Blob, Hash: emptyBlob, Name: "edit-clock-10"},
+	})
+	checkErr(t, err)
+
+	commit, err := repo.StoreCommit(tree)
+	checkErr(t, err)
+
+	err = repo.UpdateRef("refs/bugs/"+b.Id(), commit)
+	checkErr(t, err)
+}
+
+func TestRecomputeId(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	oldId := bug1.Id()
+
+	// nothing to do yet
+	id, err := bug.RecomputeId(repo, oldId)
+	checkErr(t, err)
+	if id != oldId {
+		t.Fatal("the id should not change without history rewrite")
+	}
+
+	squashBug(t, repo, bug1)
+
+	newId, err := bug.RecomputeId(repo, oldId)
+	checkErr(t, err)
+
+	if newId == oldId {
+		t.Fatal("the id should have changed")
+	}
+
+	bug2, err := bug.ReadLocalBug(repo, newId)
+	checkErr(t, err)
+
+	if nbOps(bug2) != 2 {
+		t.Fatal("Unexpected number of operations")
+	}
+
+	// the old id still resolve to the bug
+	bug3, err := bug.FindLocalBug(repo, oldId)
+	checkErr(t, err)
+
+	if bug3.Id() != newId {
+		t.Fatal("the alias should resolve to the new id")
+	}
+
+	ids, err := bug.ListLocalIds(repo)
+	checkErr(t, err)
+
+	if len(ids) != 1 || ids[0] != newId {
+		t.Fatalf("unexpected local ids: %v", ids)
+	}
+}
+
+func TestAuditAliases(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	writeAlias := func(from, to string) {
+		hash, err := repo.StoreData([]byte(to))
+		checkErr(t, err)
+		err = repo.UpdateRef("refs/bugs-alias/"+from, hash)
+		checkErr(t, err)
+	}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// healthy
+	writeAlias("aaaa", bug1.Id())
+	// dangling
+	writeAlias("bbbb", "removed")
+	// cycle
+	writeAlias("cccc", "dddd")
+	writeAlias("dddd", "cccc")
+	// shadowing
+	writeAlias(bug1.Id(), "aaaa")
+
+	issues, err := bug.AuditAliases(repo)
+	checkErr(t, err)
+
+	found := make(map[string]bug.AliasIssueKind)
+	for _, issue := range issues {
+		found[issue.Alias] = issue.Kind
+	}
+
+	expected := map[string]bug.AliasIssueKind{
+		"bbbb":    bug.AliasDangling,
+		"cccc":    bug.AliasCycle,
+		"dddd":    bug.AliasCycle,
+		bug1.Id(): bug.AliasShadowing,
+	}
+
+	if len(found) != len(expected) {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+
+	for alias, kind := range expected {
+		if found[alias] != kind {
+			t.Fatalf("expected a %s issue for %s, got %v", kind, alias, issues)
+		}
+	}
+}
+
+func TestReadSymbolicRef(t *testing.T) {
+	gitRepo := createRepo(false)
+	defer cleanupRepo(gitRepo)
+
+	repos := map[string]repository.Repo{
+		"mock": repository.NewMockRepoForTest(),
+		"git":  gitRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			target, err := operations.Create(rene, "target", "message")
+			checkErr(t, err)
+			err = target.Commit(repo)
+			checkErr(t, err)
+
+			aliasId := "0123456789abcdef0123456789abcdef01234567"
+			err = repo.UpdateSymbolicRef("refs/bugs/"+aliasId, "refs/bugs/"+target.Id())
+			checkErr(t, err)
+
+			resolved, err := repo.ResolveSymbolicRef("refs/bugs/" + aliasId)
+			checkErr(t, err)
+			if resolved != "refs/bugs/"+target.Id() {
+				t.Fatalf("unexpected target: %s", resolved)
+			}
+
+			resolved, err = repo.ResolveSymbolicRef("refs/bugs/" + target.Id())
+			checkErr(t, err)
+			if resolved != "refs/bugs/"+target.Id() {
+				t.Fatal("a regular ref should resolve to itself")
+			}
+
+			aliased, err := bug.ReadLocalBug(repo, aliasId)
+			checkErr(t, err)
+
+			if aliased.Id() != target.Id() {
+				t.Fatalf("the aliased ref should read the target bug, got %s", aliased.Id())
+			}
+			if aliased.Compile().Title != "target" {
+				t.Fatal("unexpected bug content")
+			}
+		})
+	}
+}
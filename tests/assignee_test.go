@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestListAssignedTo(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.Assign(bug1, rene, rene)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	operations.Assign(bug2, rene, blaise)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	bug3, err := operations.Create(rene, "bug3", "message")
+	checkErr(t, err)
+	operations.Assign(bug3, rene, rene)
+	operations.Unassign(bug3, rene)
+	err = bug3.Commit(repo)
+	checkErr(t, err)
+
+	assigned, err := bug.ListAssignedTo(repo, rene.Email)
+	checkErr(t, err)
+	if len(assigned) != 1 || assigned[0].Id() != bug1.Id() {
+		t.Fatal("only bug1 should be assigned to rene")
+	}
+
+	assigned, err = bug.ListAssignedTo(repo, blaise.Name)
+	checkErr(t, err)
+	if len(assigned) != 1 || assigned[0].Id() != bug2.Id() {
+		t.Fatal("only bug2 should be assigned to blaise")
+	}
+
+	assigned, err = bug.ListAssignedTo(repo, "")
+	checkErr(t, err)
+	if len(assigned) != 1 || assigned[0].Id() != bug3.Id() {
+		t.Fatal("only bug3 should be unassigned")
+	}
+}
+
+func TestAssigneeLatestWins(t *testing.T) {
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	operations.Assign(bug1, rene, rene)
+	operations.Assign(bug1, rene, blaise)
+	if bug1.Compile().Assignee != blaise {
+		t.Fatal("the latest assignment should win")
+	}
+
+	operations.Unassign(bug1, rene)
+	if bug1.Compile().Assignee != (bug.Person{}) {
+		t.Fatal("the bug should be unassigned")
+	}
+}
+
+func TestResolvePerson(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.Assign(bug1, rene, blaise)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	person, err := bug.ResolvePerson(repo, blaise.Email)
+	checkErr(t, err)
+	if person != blaise {
+		t.Fatalf("expected %v, got %v", blaise, person)
+	}
+
+	person, err = bug.ResolvePerson(repo, rene.Email)
+	checkErr(t, err)
+	if person != rene {
+		t.Fatalf("expected %v, got %v", rene, person)
+	}
+
+	_, err = bug.ResolvePerson(repo, "nobody@example.com")
+	if err != bug.ErrPersonNotFound {
+		t.Fatalf("expected ErrPersonNotFound, got %v", err)
+	}
+}
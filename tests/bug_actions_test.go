@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -109,7 +110,7 @@ func TestPushPull(t *testing.T) {
 	}
 }
 
-func checkErr(t *testing.T, err error) {
+func checkErr(t testing.TB, err error) {
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -334,3 +335,159 @@ func TestRebaseConflict(t *testing.T) {
 		t.Fatal("Unexpected number of operations")
 	}
 }
+
+func TestRemoveLocalBug(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repoA)
+	checkErr(t, err)
+	err = bug.MarkRead(repoA, bug1.Id())
+	checkErr(t, err)
+
+	err = bug.RemoveLocalBug(repoA, bug1.HumanId(), false)
+	checkErr(t, err)
+
+	ids, err := bug.ListLocalIds(repoA)
+	checkErr(t, err)
+	if len(ids) != 0 {
+		t.Fatal("the bug should be removed")
+	}
+	exist, err := repoA.RefExist("refs/bugs-read/" + bug1.Id())
+	checkErr(t, err)
+	if exist {
+		t.Fatal("the read marker should be removed")
+	}
+
+	// a pushed bug can only be removed by force
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repoA)
+	checkErr(t, err)
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoA, "origin")
+	checkErr(t, err)
+
+	err = bug.RemoveLocalBug(repoA, bug2.Id(), false)
+	if !errors.Is(err, bug.ErrBugOnRemote) {
+		t.Fatalf("the removal should be refused, got %v", err)
+	}
+	if _, err := bug.ReadLocalBug(repoA, bug2.Id()); err != nil {
+		t.Fatal("the bug should still exist")
+	}
+
+	err = bug.RemoveLocalBug(repoA, bug2.Id(), true)
+	checkErr(t, err)
+	if _, err := bug.ReadLocalBug(repoA, bug2.Id()); err == nil {
+		t.Fatal("the bug should be removed")
+	}
+}
+
+func TestRemoveLocalBugMemory(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	err = bug.RemoveLocalBug(repo, bug1.HumanId(), false)
+	checkErr(t, err)
+
+	if _, err := bug.FindLocalBug(repo, bug1.HumanId()); err == nil {
+		t.Fatal("the bug should be removed")
+	}
+
+	// a corrupted bug can be removed as well
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	tree, err := repo.StoreTree(nil)
+	checkErr(t, err)
+	commit, err := repo.StoreCommit(tree)
+	checkErr(t, err)
+	err = repo.UpdateRef("refs/bugs/"+bug2.Id(), commit)
+	checkErr(t, err)
+
+	if _, err := bug.ReadLocalBug(repo, bug2.Id()); err == nil {
+		t.Fatal("the bug should be corrupted")
+	}
+
+	// a remote ref count even if the remote is not configured
+	err = repo.UpdateRef("refs/remotes/gone/bugs/"+bug2.Id(), commit)
+	checkErr(t, err)
+
+	err = bug.RemoveLocalBug(repo, bug2.HumanId(), false)
+	if !errors.Is(err, bug.ErrBugOnRemote) {
+		t.Fatalf("the removal should be refused, got %v", err)
+	}
+
+	err = bug.RemoveLocalBug(repo, bug2.HumanId(), true)
+	checkErr(t, err)
+
+	ids, err := bug.ListLocalIds(repo)
+	checkErr(t, err)
+	if len(ids) != 0 {
+		t.Fatal("the corrupted bug should be removed")
+	}
+}
+
+func TestNeedsPush(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	needsPush := func(b *bug.Bug, repo repository.Repo, expected bool) {
+		t.Helper()
+		result, err := b.NeedsPush(repo, "origin")
+		checkErr(t, err)
+		if result != expected {
+			t.Fatalf("expected NeedsPush to be %v", expected)
+		}
+	}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repoA))
+
+	// never pushed
+	needsPush(bug1, repoA, true)
+
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoA, "origin")
+	checkErr(t, err)
+	needsPush(bug1, repoA, false)
+
+	// ahead
+	checkErr(t, operations.Comment(bug1, rene, "comment"))
+	checkErr(t, bug1.Commit(repoA))
+	needsPush(bug1, repoA, true)
+
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoA, "origin")
+	checkErr(t, err)
+
+	// behind
+	err = bug.Pull(repoB, ioutil.Discard, "origin")
+	checkErr(t, err)
+	bug1B, err := bug.ReadLocalBug(repoB, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, operations.Comment(bug1B, rene, "comment B"))
+	checkErr(t, bug1B.Commit(repoB))
+	_, err = bug.Push(repoB, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoA, "origin")
+	checkErr(t, err)
+	needsPush(bug1, repoA, false)
+
+	// diverged
+	checkErr(t, operations.Comment(bug1, rene, "comment A"))
+	checkErr(t, bug1.Commit(repoA))
+	needsPush(bug1, repoA, true)
+}
@@ -1,8 +1,14 @@
 package tests
 
 import (
-	"github.com/MichaelMure/git-bug/bug"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
 )
 
 func TestBugId(t *testing.T) {
@@ -49,6 +55,238 @@ func TestBugValidity(t *testing.T) {
 	}
 }
 
+func TestBugRootMismatch(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	ref := "refs/bugs/" + bug1.Id()
+
+	// craft a new commit with a tampered root entry
+	pack := bug.OperationPack{}
+	pack.Append(addCommentOp)
+	opsHash, err := pack.Write(repo)
+	checkErr(t, err)
+
+	tree, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: opsHash, Name: "ops"},
+		{ObjectType: repository.Blob, Hash: opsHash, Name: "root"},
+	})
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef(ref)
+	checkErr(t, err)
+
+	commit, err := repo.StoreCommitWithParent(tree, head)
+	checkErr(t, err)
+
+	err = repo.UpdateRef(ref, commit)
+	checkErr(t, err)
+
+	_, err = bug.ReadLocalBug(repo, bug1.Id())
+	if err == nil {
+		t.Fatal("reading a bug with a mismatched root entry should fail")
+	}
+}
+
+func TestBugMalformedMedia(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	ref := "refs/bugs/" + bug1.Id()
+
+	head, err := repo.ResolveRef(ref)
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+
+	// a media entry holding a tree instead of a blob
+	blob, err := repo.StoreData([]byte("data"))
+	checkErr(t, err)
+	subTree, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Blob, Hash: blob, Name: "file0"},
+	})
+	checkErr(t, err)
+	media, err := repo.StoreTree([]repository.TreeEntry{
+		{ObjectType: repository.Tree, Hash: subTree, Name: "file0"},
+	})
+	checkErr(t, err)
+
+	entries = append(entries, repository.TreeEntry{
+		ObjectType: repository.Tree, Hash: media, Name: "media",
+	})
+	tree, err := repo.StoreTree(entries)
+	checkErr(t, err)
+
+	commit, err := repo.StoreCommit(tree)
+	checkErr(t, err)
+	err = repo.UpdateRef(ref, commit)
+	checkErr(t, err)
+
+	_, err = bug.ReadLocalBug(repo, bug1.Id())
+	if !errors.Is(err, bug.ErrMalformedMedia) {
+		t.Fatalf("expected ErrMalformedMedia, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "file0") {
+		t.Fatal("the error should point to the offending entry")
+	}
+}
+
+func TestReadBugFromCommit(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	ref := "refs/bugs/" + bug1.Id()
+
+	head, err := repo.ResolveRef(ref)
+	checkErr(t, err)
+
+	// no ref point to the bug anymore
+	err = repo.RemoveRef(ref)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadBugFromCommit(repo, head)
+	checkErr(t, err)
+
+	if bug2.Id() != bug1.Id() {
+		t.Fatal("the id should be derived from the root commit")
+	}
+
+	if nbOps(bug2) != 2 {
+		t.Fatal("the full history should be read")
+	}
+
+	// a commit that is not a bug commit
+	emptyTree, err := repo.StoreTree(nil)
+	checkErr(t, err)
+	commit, err := repo.StoreCommit(emptyTree)
+	checkErr(t, err)
+
+	_, err = bug.ReadBugFromCommit(repo, commit)
+	if err == nil {
+		t.Fatal("reading a bug from a non-bug commit should fail")
+	}
+}
+
+func TestReadLocalBugAt(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	first, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	operations.SetTitle(bug1, rene, "bug1 renamed")
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	past, err := bug.ReadLocalBugAt(repo, bug1.Id(), first)
+	checkErr(t, err)
+
+	snap := past.Compile()
+	if past.Id() != bug1.Id() || snap.Title != "bug1" || len(snap.Operations) != 1 {
+		t.Fatal("the bug should be read as it was at the first commit")
+	}
+
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	current, err := bug.ReadLocalBugAt(repo, bug1.Id(), head)
+	checkErr(t, err)
+	if nbOps(current) != 3 {
+		t.Fatal("the full history should be read at the tip")
+	}
+
+	// a commit of another bug
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	other, err := repo.ResolveRef("refs/bugs/" + bug2.Id())
+	checkErr(t, err)
+
+	_, err = bug.ReadLocalBugAt(repo, bug1.Id(), other)
+	if err == nil {
+		t.Fatal("a commit outside of the history of the bug should be refused")
+	}
+}
+
+func TestBugOpTypeCounts(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "comment1")
+	operations.Assign(bug1, rene, rene)
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	// staged operations count too
+	operations.Comment(bug1, rene, "comment2")
+	operations.Close(bug1, rene)
+
+	counts := bug1.OpTypeCounts()
+
+	expected := map[bug.OperationType]int{
+		bug.CreateOp:      1,
+		bug.AddCommentOp:  2,
+		bug.SetAssigneeOp: 1,
+		bug.SetStatusOp:   1,
+	}
+
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+
+	if counts[bug.LabelChangeOp] != 0 {
+		t.Fatal("the bug doesn't use labels")
+	}
+}
+
+func TestReadBugNonHexId(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	badId := strings.Repeat("Z", 40)
+
+	err = repo.CopyRef("refs/bugs/"+bug1.Id(), "refs/bugs/"+badId)
+	checkErr(t, err)
+
+	_, err = bug.ReadLocalBug(repo, badId)
+	if err == nil {
+		t.Fatal("reading a bug with a non-hexadecimal id should fail")
+	}
+
+	_, err = bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+}
+
 //func TestBugSerialisation(t *testing.T) {
 //	bug1, err := bug.NewBug()
 //	if err != nil {
@@ -73,3 +311,210 @@ func TestBugValidity(t *testing.T) {
 //		t.Fatalf("%v different than %v", bug1, bug2)
 //	}
 //}
+
+func TestHumanIdLength(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	length, err := bug.HumanIdLength(repo)
+	checkErr(t, err)
+	if length != 7 {
+		t.Fatalf("an empty repository should use the default length, got %d", length)
+	}
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	// only the refs matter to tell the ids apart
+	for _, id := range []string{
+		"0123456789abcdef0123456789abcdef01234567",
+		"0123456789ab0000000000000000000000000000",
+		"fedcba9876543210fedcba9876543210fedcba98",
+	} {
+		checkErr(t, repo.UpdateRef("refs/bugs/"+id, head))
+	}
+
+	length, err = bug.HumanIdLength(repo)
+	checkErr(t, err)
+	if length != 13 {
+		t.Fatalf("the length should avoid the collision, got %d", length)
+	}
+
+	if bug.FormatHumanId("0123456789ab0000000000000000000000000000", length) != "0123456789ab0" {
+		t.Fatal("unexpected human id")
+	}
+
+	ids, err := bug.ListLocalIds(repo)
+	checkErr(t, err)
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		human := bug.FormatHumanId(id, length)
+		if seen[human] {
+			t.Fatalf("the human id %s is ambiguous", human)
+		}
+		seen[human] = true
+	}
+
+	// the bugs read from the repository are displayed the same way
+	read, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	if read.HumanId() != bug1.Id()[:13] || read.Compile().HumanId() != bug1.Id()[:13] {
+		t.Fatalf("unexpected human id %s", read.HumanId())
+	}
+}
+
+func TestResolveIdentifier(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	const id1 = "0123456789abcdef0123456789abcdef01234567"
+	const id2 = "0123456789ab0000000000000000000000000000"
+	const id3 = "fedcba9876543210fedcba9876543210fedcba98"
+
+	for _, id := range []string{id1, id2, id3} {
+		checkErr(t, repo.UpdateRef("refs/bugs/"+id, head))
+	}
+
+	cases := map[string]string{
+		// full id
+		id1: id1,
+		id2: id2,
+		// human id
+		"fedcba9": id3,
+		// longer prefix
+		"0123456789abc": id1,
+		"0123456789ab0": id2,
+	}
+
+	for input, expected := range cases {
+		id, err := bug.ResolveIdentifier(repo, input)
+		checkErr(t, err)
+		if id != expected {
+			t.Fatalf("%s should resolve to %s, got %s", input, expected, id)
+		}
+	}
+
+	// the human id of both id1 and id2
+	_, err = bug.ResolveIdentifier(repo, "0123456")
+	if !errors.Is(err, bug.ErrMultipleBugs) || !strings.Contains(err.Error(), id1) || !strings.Contains(err.Error(), id2) {
+		t.Fatalf("expected an ambiguity listing the matching ids, got %v", err)
+	}
+
+	_, err = bug.ResolveIdentifier(repo, "abcdef")
+	if !errors.Is(err, bug.ErrBugNotFound) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	_, err = bug.FindLocalBug(repo, "abcdef")
+	if !errors.Is(err, bug.ErrBugNotFound) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestBugValidate(t *testing.T) {
+	bug1 := bug.NewBug()
+
+	err := bug1.Validate()
+	if !errors.Is(err, bug.ErrInvalidBug) || !strings.Contains(err.Error(), "no operation") {
+		t.Fatalf("unexpected error for an empty bug: %v", err)
+	}
+
+	bug1.Append(operations.NewSetTitleOp(rene, "title", ""))
+	err = bug1.Validate()
+	if !errors.Is(err, bug.ErrInvalidBug) || !strings.Contains(err.Error(), "first operation") {
+		t.Fatalf("unexpected error for a misplaced create: %v", err)
+	}
+
+	bug2 := bug.NewBug()
+	bug2.Append(createOp)
+	checkErr(t, bug2.Validate())
+
+	bug2.Append(operations.NewSetTitleOp(rene, "title", ""))
+	bug2.Append(createOp)
+	err = bug2.Validate()
+	if !errors.Is(err, bug.ErrInvalidBug) || !strings.Contains(err.Error(), "operation 2") {
+		t.Fatalf("unexpected error for a second create: %v", err)
+	}
+}
+
+func TestBugClone(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "staged")
+
+	clone := bug1.Clone()
+
+	if clone.Id() != bug1.Id() || !reflect.DeepEqual(clone.Compile(), bug1.Compile()) {
+		t.Fatal("the clone should be identical")
+	}
+
+	operations.SetTitle(clone, rene, "experiment")
+	operations.Close(clone, rene)
+
+	snap := bug1.Compile()
+	if snap.Title != "bug1" || snap.Status != bug.OpenStatus || len(snap.Operations) != 2 {
+		t.Fatal("the original should not be affected by the clone")
+	}
+
+	if clone.Compile().Title != "experiment" {
+		t.Fatal("the clone should have the new operations")
+	}
+
+	// the original can still be committed on its own
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	if len(clone.Compile().Comments) != 2 || !clone.HasPendingOp() {
+		t.Fatal("the clone should not be affected by the original")
+	}
+}
+
+func TestLocalBugExists(t *testing.T) {
+	gitRepo := createRepo(false)
+	defer cleanupRepo(gitRepo)
+
+	repos := map[string]repository.Repo{
+		"mock": repository.NewMockRepoForTest(),
+		"git":  gitRepo,
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			bug1, err := operations.Create(rene, "bug1", "message")
+			checkErr(t, err)
+			err = bug1.Commit(repo)
+			checkErr(t, err)
+
+			exist, err := bug.LocalBugExists(repo, bug1.Id())
+			checkErr(t, err)
+			if !exist {
+				t.Fatal("the bug should exist")
+			}
+
+			exist, err = bug.LocalBugExists(repo, "0123456789abcdef0123456789abcdef01234567")
+			checkErr(t, err)
+			if exist {
+				t.Fatal("the bug should not exist")
+			}
+
+			_, err = bug.LocalBugExists(repo, bug1.HumanId())
+			if err == nil {
+				t.Fatal("a prefix should be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestBugCache(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "comment")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	cache, err := bug.NewCache(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	if _, ok := cache.Get(bug1.Id(), head); ok {
+		t.Fatal("the bug should not be cached yet")
+	}
+
+	// the first read fill the cache
+	read1, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	cached, ok := cache.Get(bug1.Id(), head)
+	if !ok {
+		t.Fatal("the bug should be cached")
+	}
+
+	if cached.Id() != read1.Id() || cached.Compile().Title != "bug1" ||
+		len(cached.Compile().Comments) != 2 {
+		t.Fatal("the cached bug doesn't match the stored one")
+	}
+
+	// a cached bug can be modified and committed as usual
+	read2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.SetTitle(read2, rene, "bug1 edited")
+	err = read2.Commit(repo)
+	checkErr(t, err)
+
+	// the entry is stale once the ref moved
+	newHead, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	if _, ok := cache.Get(bug1.Id(), newHead); ok {
+		t.Fatal("the cache entry should be stale")
+	}
+	if _, ok := cache.Get(bug1.Id(), head); ok {
+		t.Fatal("the stale entry should be removed")
+	}
+
+	read3, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	if read3.Compile().Title != "bug1 edited" {
+		t.Fatal("a stale entry should not be used")
+	}
+	if _, ok := cache.Get(bug1.Id(), newHead); !ok {
+		t.Fatal("the cache entry should be refreshed")
+	}
+
+	for streamed := range bug.ReadAllLocalBugs(repo) {
+		checkErr(t, streamed.Err)
+		if streamed.Bug.Compile().Title != "bug1 edited" {
+			t.Fatal("unexpected bug")
+		}
+	}
+
+	// the entry of a removed bug is removed too
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	checkErr(t, bug2.Commit(repo))
+	_, err = bug.ReadLocalBug(repo, bug2.Id())
+	checkErr(t, err)
+	checkErr(t, bug.RemoveLocalBug(repo, bug2.Id(), false))
+	if _, err := os.Stat(path.Join(repo.GetPath(), ".git", "git-bug", "cache", bug2.Id())); !os.IsNotExist(err) {
+		t.Fatal("the entry of the removed bug should be removed")
+	}
+
+	err = cache.Clear()
+	checkErr(t, err)
+
+	if _, err := os.Stat(path.Join(repo.GetPath(), ".git", "git-bug", "cache")); !os.IsNotExist(err) {
+		t.Fatal("the cache should be wiped")
+	}
+	if _, ok := cache.Get(bug1.Id(), newHead); ok {
+		t.Fatal("the cache should be empty")
+	}
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestClockStorage(t *testing.T) {
+	defer bug.SetClockStorage(bug.ClockInEntryName)
+
+	bug.SetClockStorage(bug.ClockInEntryName)
+	testClockStorage(t, "edit-clock-2")
+
+	bug.SetClockStorage(bug.ClockInBlob)
+	testClockStorage(t, "edit-clock")
+}
+
+// run with the race detector
+func TestClockStorageConcurrent(t *testing.T) {
+	defer bug.SetClockStorage(bug.ClockInEntryName)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			bug.SetClockStorage(bug.ClockStorage(i % 2))
+		}
+	}()
+
+	repo := repository.NewMockRepoForTest()
+	for i := 0; i < 10; i++ {
+		b, err := operations.Create(rene, "title", "message")
+		checkErr(t, err)
+		checkErr(t, b.Commit(repo))
+	}
+
+	<-done
+}
+
+func testClockStorage(t *testing.T, expectedEntry string) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+
+	found := false
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name, "edit-clock") {
+			found = entry.Name == expectedEntry
+		}
+	}
+	if !found {
+		t.Fatalf("the edit clock should be stored as %s", expectedEntry)
+	}
+
+	read1, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	read2, err := bug.ReadLocalBug(repo, bug2.Id())
+	checkErr(t, err)
+
+	snap := read1.Compile()
+	if snap.OperationEditTime(0) != 1 || snap.OperationEditTime(1) != 2 {
+		t.Fatal("unexpected edit time")
+	}
+
+	bugs := bug.BugsByCreationTime{read1, read2}
+	if !bugs.Less(0, 1) || bugs.Less(1, 0) {
+		t.Fatal("unexpected create time")
+	}
+}
+
+func TestReadBugClocks(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		b, err := operations.Create(rene, "bug", "message")
+		checkErr(t, err)
+		err = b.Commit(repo)
+		checkErr(t, err)
+		ids = append(ids, b.Id())
+	}
+
+	// edit the bugs in the reverse order
+	for i := len(ids) - 1; i >= 0; i-- {
+		b, err := bug.ReadLocalBug(repo, ids[i])
+		checkErr(t, err)
+		operations.Comment(b, rene, "comment")
+		err = b.Commit(repo)
+		checkErr(t, err)
+	}
+
+	var lastEdit util.LamportTime
+
+	for i, id := range ids {
+		create, edit, err := bug.ReadBugClocks(repo, id)
+		checkErr(t, err)
+
+		if create != util.LamportTime(i+1) {
+			t.Fatalf("unexpected create time %d for bug %d", create, i)
+		}
+
+		full, err := bug.ReadLocalBug(repo, id)
+		checkErr(t, err)
+		snap := full.Compile()
+
+		if edit != snap.OperationEditTime(len(snap.Operations)-1) {
+			t.Fatalf("the edit time of bug %d doesn't match a full read", i)
+		}
+
+		if i > 0 && edit >= lastEdit {
+			t.Fatal("the bugs were edited in the reverse order")
+		}
+		lastEdit = edit
+	}
+}
+
+func TestBugLamportTimes(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	if bug1.CreateLamportTime() != 0 || bug1.EditLamportTime() != 0 {
+		t.Fatal("a new bug should have no logical time")
+	}
+
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	if bug1.CreateLamportTime() == 0 || bug1.CreateLamportTime() >= bug2.CreateLamportTime() {
+		t.Fatal("the create times should follow the order of creation")
+	}
+
+	operations.Comment(bug1, rene, "comment")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	if bug1.EditLamportTime() <= bug2.EditLamportTime() {
+		t.Fatal("the edit time should be updated by a commit")
+	}
+
+	read1, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if read1.CreateLamportTime() != bug1.CreateLamportTime() || read1.EditLamportTime() != bug1.EditLamportTime() {
+		t.Fatal("the read bug should have the same logical times")
+	}
+}
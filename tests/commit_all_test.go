@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestCommitAll(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	var bugs []*bug.Bug
+	for _, title := range []string{"bug1", "bug2", "bug3"} {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		bugs = append(bugs, b)
+	}
+
+	err := bug.CommitAll(repo, bugs)
+	checkErr(t, err)
+
+	for _, b := range bugs {
+		if b.HasPendingOp() || b.Id() == "" {
+			t.Fatal("the bug should be committed")
+		}
+
+		read, err := bug.ReadLocalBug(repo, b.Id())
+		checkErr(t, err)
+		if read.Compile().Title != b.Compile().Title {
+			t.Fatal("the committed bug should be readable")
+		}
+	}
+
+	// a failure in the middle keep the bugs committed before it
+	operations.Comment(bugs[0], rene, "comment")
+	err = bugs[1].Append(operations.NewEditCommentOp(rene, "0000000000000000000000000000000000000000", "edit"))
+	checkErr(t, err)
+	operations.Comment(bugs[2], rene, "comment")
+
+	err = bug.CommitAll(repo, bugs)
+	if !errors.Is(err, bug.ErrUnresolvedReference) {
+		t.Fatalf("expected the failure of the second bug, got %v", err)
+	}
+
+	if bugs[0].HasPendingOp() {
+		t.Fatal("the first bug should be committed")
+	}
+	if !bugs[1].HasPendingOp() || !bugs[2].HasPendingOp() {
+		t.Fatal("the bugs from the failing one should not be committed")
+	}
+
+	read, err := bug.ReadLocalBug(repo, bugs[0].Id())
+	checkErr(t, err)
+	if len(read.Compile().Comments) != 2 {
+		t.Fatal("the first bug should have been written")
+	}
+
+	// the failure of a bug never committed is reported too
+	newBug := bug.NewBug()
+	err = newBug.Append(operations.NewEditCommentOp(rene, "0000000000000000000000000000000000000000", "edit"))
+	checkErr(t, err)
+
+	err = bug.CommitAll(repo, []*bug.Bug{newBug})
+	if err == nil {
+		t.Fatal("the invalid new bug should not be committed")
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestCompact(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+	checkErr(t, b.Commit(repo))
+
+	for i := 0; i < 5; i++ {
+		checkErr(t, operations.Comment(b, rene, "comment"))
+		checkErr(t, b.Commit(repo))
+	}
+	checkErr(t, operations.ChangeLabels(nil, b, rene, []string{"bug"}, nil))
+	operations.Close(b, rene)
+	checkErr(t, b.Commit(repo))
+
+	before, err := bug.ReadLocalBug(repo, b.Id())
+	checkErr(t, err)
+	createBefore, editBefore, err := bug.ReadBugClocks(repo, b.Id())
+	checkErr(t, err)
+
+	err = before.Compact(repo)
+	checkErr(t, err)
+
+	commits, err := repo.ListCommits("refs/bugs/" + b.Id())
+	checkErr(t, err)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits after compaction, got %d", len(commits))
+	}
+
+	after, err := bug.ReadLocalBug(repo, b.Id())
+	checkErr(t, err)
+
+	if after.Id() != b.Id() {
+		t.Fatal("the id should be preserved")
+	}
+	if after.FirstOp().OpType() != bug.CreateOp {
+		t.Fatal("the CreateOp should still be first")
+	}
+
+	createAfter, editAfter, err := bug.ReadBugClocks(repo, b.Id())
+	checkErr(t, err)
+	if createAfter != createBefore || editAfter != editBefore {
+		t.Fatal("the clocks should be preserved")
+	}
+
+	// the operation ids are the only difference, as the commits holding the
+	// operations changed
+	snapAfter := after.Compile()
+	for _, snapBefore := range []bug.Snapshot{b.Compile(), before.Compile()} {
+		if !reflect.DeepEqual(snapBefore.Operations, snapAfter.Operations) ||
+			!reflect.DeepEqual(snapBefore.Comments, snapAfter.Comments) ||
+			!reflect.DeepEqual(snapBefore.Labels, snapAfter.Labels) ||
+			snapBefore.Status != snapAfter.Status || snapBefore.Title != snapAfter.Title {
+			t.Fatal("the compiled bug should be the same")
+		}
+
+		for i := range snapBefore.Operations {
+			if snapBefore.OperationEditTime(i) != snapAfter.OperationEditTime(i) {
+				t.Fatalf("the edit time of operation %d should be preserved", i)
+			}
+		}
+	}
+
+	// the bug can still be edited as usual
+	checkErr(t, operations.Comment(after, rene, "after compaction"))
+	checkErr(t, after.Commit(repo))
+}
+
+func TestCompactRefused(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+	checkErr(t, b.Commit(repoA))
+	for i := 0; i < 3; i++ {
+		checkErr(t, operations.Comment(b, rene, "comment"))
+		checkErr(t, b.Commit(repoA))
+	}
+
+	checkErr(t, operations.Comment(b, rene, "pending"))
+	if err := b.Compact(repoA); err == nil {
+		t.Fatal("a bug with pending operations should not be compacted")
+	}
+	checkErr(t, b.Commit(repoA))
+
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoA, "origin")
+	checkErr(t, err)
+
+	err = b.Compact(repoA)
+	if !errors.Is(err, bug.ErrBugOnRemote) {
+		t.Fatalf("a shared bug should not be compacted, got %v", err)
+	}
+}
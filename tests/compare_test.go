@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestCompareRepos(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repoA)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repoA)
+	checkErr(t, err)
+
+	// A --> remote --> B
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	err = bug.Pull(repoB, os.Stdout, "origin")
+	checkErr(t, err)
+
+	diff, err := bug.CompareRepos(repoA, repoB)
+	checkErr(t, err)
+
+	if !diff.InSync() {
+		t.Fatalf("the repos should be in sync: %+v", diff)
+	}
+
+	// a bug only in A
+	bug3, err := operations.Create(rene, "bug3", "message")
+	checkErr(t, err)
+	err = bug3.Commit(repoA)
+	checkErr(t, err)
+
+	// a bug modified only in B
+	bug2B, err := bug.ReadLocalBug(repoB, bug2.Id())
+	checkErr(t, err)
+	operations.Comment(bug2B, rene, "message2")
+	err = bug2B.Commit(repoB)
+	checkErr(t, err)
+
+	diff, err = bug.CompareRepos(repoA, repoB)
+	checkErr(t, err)
+
+	if len(diff.OnlyInA) != 1 || diff.OnlyInA[0] != bug3.Id() {
+		t.Fatalf("bug3 should only be in A: %+v", diff)
+	}
+
+	if len(diff.OnlyInB) != 0 {
+		t.Fatalf("no bug should be only in B: %+v", diff)
+	}
+
+	if len(diff.Divergent) != 1 || diff.Divergent[0] != bug2.Id() {
+		t.Fatalf("bug2 should be divergent: %+v", diff)
+	}
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestMergeDAG(t *testing.T) {
+	gitRepo := createRepo(false)
+	defer cleanupRepo(gitRepo)
+
+	checkErr(t, bug.SetHistoryFormatVersion(bug.HistoryFormatDAG))
+	defer bug.SetHistoryFormatVersion(bug.HistoryFormatLinear)
+
+	testMergeDAG(t, gitRepo)
+	testMergeDAG(t, repository.NewMockRepoForTest())
+}
+
+func testMergeDAG(t *testing.T, repo repository.Repo) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the remote side add two comments
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.Comment(bug2, rene, "remote1")
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+	operations.Comment(bug2, rene, "remote2")
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+	err = repo.CopyRef(localRef, remoteRef)
+	checkErr(t, err)
+
+	// the local side diverge with another comment
+	err = repo.UpdateRef(localRef, root)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "local1")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localHead, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	remoteBug, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	// the clients not aware of merge commits would reject the bug
+	checkErr(t, bug.SetHistoryFormatVersion(bug.HistoryFormatLinear))
+	_, err = bug1.MergeDAG(repo, remoteBug)
+	if err != bug.ErrLinearHistory {
+		t.Fatalf("expected ErrLinearHistory, got %v", err)
+	}
+	checkErr(t, bug.SetHistoryFormatVersion(bug.HistoryFormatDAG))
+
+	updated, err := bug1.MergeDAG(repo, remoteBug)
+	checkErr(t, err)
+
+	if !updated {
+		t.Fatal("the bug should have been updated")
+	}
+
+	expected := []string{"message", "remote1", "remote2", "local1"}
+	assertComments(t, bug1.Compile(), expected)
+
+	// the history is now a DAG that preserve our commit
+	parents, err := repo.ListCommitParents(localRef)
+	checkErr(t, err)
+
+	if _, ok := parents[localHead]; !ok {
+		t.Fatal("the local commit should have been preserved")
+	}
+
+	bug3, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if !bug3.IsValid() {
+		t.Fatal("merged bug should be valid")
+	}
+
+	assertComments(t, bug3.Compile(), expected)
+
+	// merging again is a no-op
+	updated, err = bug3.MergeDAG(repo, remoteBug)
+	checkErr(t, err)
+
+	if updated {
+		t.Fatal("the bug should not have been updated")
+	}
+}
+
+func assertComments(t *testing.T, snap bug.Snapshot, expected []string) {
+	if len(snap.Comments) != len(expected) {
+		t.Fatalf("unexpected number of comments: %d", len(snap.Comments))
+	}
+
+	for i, comment := range snap.Comments {
+		if comment.Message != expected[i] {
+			t.Fatalf("unexpected comment %d: %s instead of %s", i, comment.Message, expected[i])
+		}
+	}
+}
+
+func TestLocalOnlyOps(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the remote get a comment
+	remote, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.Comment(remote, rene, "remote comment")
+	err = remote.Commit(repo)
+	checkErr(t, err)
+	err = repo.CopyRef(localRef, remoteRef)
+	checkErr(t, err)
+
+	// local get two operations beyond the common ancestor
+	err = repo.UpdateRef(localRef, root)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "local comment")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+	operations.SetTitle(bug1, rene, "local title")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	remote, err = bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	ops, err := bug.LocalOnlyOps(repo, local, remote)
+	checkErr(t, err)
+
+	if len(ops) != 2 {
+		t.Fatalf("unexpected number of local operations: %d", len(ops))
+	}
+
+	if ops[0].OpType() != bug.AddCommentOp || ops[1].OpType() != bug.SetTitleOp {
+		t.Fatal("unexpected local operations")
+	}
+
+	// nothing local on the other side
+	ops, err = bug.LocalOnlyOps(repo, remote, remote)
+	checkErr(t, err)
+
+	if len(ops) != 0 {
+		t.Fatal("a bug has no operation unique to itself")
+	}
+}
+
+// run with the race detector
+func TestHistoryFormatVersionConcurrent(t *testing.T) {
+	defer bug.SetHistoryFormatVersion(bug.HistoryFormatLinear)
+
+	repo := repository.NewMockRepoForTest()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := bug.SetHistoryFormatVersion(bug.HistoryFormatLinear + i%2); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		bug1, _ := divergedBug(t, repo, 1)
+
+		remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+		checkErr(t, err)
+
+		_, err = bug1.MergeDAG(repo, remote)
+		if err != nil && err != bug.ErrLinearHistory {
+			t.Fatal(err)
+		}
+	}
+
+	<-done
+}
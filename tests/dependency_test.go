@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	var bugs []*bug.Bug
+	for _, title := range []string{"bug1", "bug2", "bug3", "bug4"} {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		err = b.Commit(repo)
+		checkErr(t, err)
+		bugs = append(bugs, b)
+	}
+
+	// bug1 -> bug2 -> bug3 -> bug1, and bug4 -> bug1
+	for i, target := range []int{1, 2, 0} {
+		operations.DependsOn(bugs[i], rene, bugs[target].Id())
+	}
+	operations.DependsOn(bugs[3], rene, bugs[0].Id())
+	// a removed dependency doesn't count
+	operations.DependsOn(bugs[3], rene, bugs[2].Id())
+	operations.RemoveDependency(bugs[3], rene, bugs[2].Id())
+
+	for _, b := range bugs {
+		err := b.Commit(repo)
+		checkErr(t, err)
+	}
+
+	graph, err := bug.DependencyGraph(repo)
+	checkErr(t, err)
+
+	blocking := graph.DependsOn(bugs[3].Id())
+	if len(blocking) != 1 || blocking[0] != bugs[0].Id() {
+		t.Fatalf("unexpected dependencies: %v", blocking)
+	}
+
+	if len(graph.Blocks(bugs[0].Id())) != 2 {
+		t.Fatal("bug1 should block bug3 and bug4")
+	}
+
+	cycles := graph.Cycles()
+	if len(cycles) != 1 || len(cycles[0]) != 3 {
+		t.Fatalf("unexpected cycles: %v", cycles)
+	}
+
+	// break the cycle
+	operations.RemoveDependency(bugs[2], rene, bugs[0].Id())
+	err = bugs[2].Commit(repo)
+	checkErr(t, err)
+
+	graph, err = bug.DependencyGraph(repo)
+	checkErr(t, err)
+
+	if len(graph.Cycles()) != 0 {
+		t.Fatal("the cycle should be gone")
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestReadLocalBugHeader(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	checkExcerpt := func(title string, status bug.Status) {
+		excerpt, err := bug.ReadLocalBugHeader(repo, b.Id())
+		checkErr(t, err)
+
+		snap := b.Compile()
+
+		if excerpt.Id != b.Id() || excerpt.Author != rene || !excerpt.CreatedAt.Equal(snap.CreatedAt) {
+			t.Fatalf("unexpected excerpt %+v", excerpt)
+		}
+		if excerpt.Title != title || excerpt.Status != status {
+			t.Fatalf("expected %s %s, got %s %s", title, status, excerpt.Title, excerpt.Status)
+		}
+		if excerpt.Title != snap.Title || excerpt.Status != snap.Status {
+			t.Fatal("the excerpt should match the compiled bug")
+		}
+		head, err := bug.ReadBugHead(repo, b.Id())
+		checkErr(t, err)
+		if excerpt.CreateTime != head.CreateTime || excerpt.EditTime != head.EditTime {
+			t.Fatal("unexpected logical times")
+		}
+	}
+
+	checkExcerpt("title", bug.OpenStatus)
+
+	operations.SetTitle(b, rene, "title2")
+	operations.Close(b, rene)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	checkExcerpt("title2", bug.ClosedStatus)
+
+	// the latest title is in an older pack than the latest status
+	err = operations.Comment(b, rene, "comment")
+	checkErr(t, err)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	operations.Open(b, rene)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	checkExcerpt("title2", bug.OpenStatus)
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestVerifyExternalRefs(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	testVerifyExternalRefs(t, repo)
+	testVerifyExternalRefs(t, repository.NewMockRepoForTest())
+}
+
+func testVerifyExternalRefs(t *testing.T, repo repository.Repo) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	commit, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	operations.ReferenceCommit(bug1, rene, string(commit))
+	operations.ReferenceCommit(bug1, rene, "0123456789abcdef0123456789abcdef01234567")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	snap := bug2.Compile()
+
+	if len(snap.ExternalRefs) != 2 {
+		t.Fatalf("unexpected number of references: %d", len(snap.ExternalRefs))
+	}
+
+	if snap.ExternalRefs[0].Status != bug.ExternalRefUnverified {
+		t.Fatal("the references should not be verified by default")
+	}
+
+	err = snap.VerifyExternalRefs(repo)
+	checkErr(t, err)
+
+	if snap.ExternalRefs[0].Status != bug.ExternalRefResolved {
+		t.Fatal("an existing commit should be resolved")
+	}
+
+	if snap.ExternalRefs[1].Status != bug.ExternalRefUnresolved {
+		t.Fatal("an absent commit should be unresolved")
+	}
+}
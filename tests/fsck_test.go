@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestFsck(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	// the media limits would prevent committing a missing file
+	bug.SetMediaLimits(0, 0)
+	defer bug.SetMediaLimits(100, 50*1024*1024)
+
+	file, err := repo.StoreData([]byte("file"))
+	checkErr(t, err)
+
+	valid, err := operations.CreateWithFiles(rene, "valid", "message", []util.Hash{file})
+	checkErr(t, err)
+	checkErr(t, valid.Commit(repo))
+
+	problems, err := bug.Fsck(repo)
+	checkErr(t, err)
+	if len(problems) != 0 {
+		t.Fatalf("expected no problem, got %v", problems)
+	}
+
+	missingFile := util.Hash("0123456789abcdef0123456789abcdef01234567")
+	missing, err := operations.CreateWithFiles(rene, "missing", "message", []util.Hash{missingFile})
+	checkErr(t, err)
+	checkErr(t, missing.Commit(repo))
+
+	// a ref pointing to something that is not a bug
+	corruptId := "89abcdef0123456789abcdef0123456789abcdef"
+	checkErr(t, repo.UpdateRef("refs/bugs/"+corruptId, file))
+
+	problems, err = bug.Fsck(repo)
+	checkErr(t, err)
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+
+	found := make(map[string]bug.FsckProblem)
+	for _, problem := range problems {
+		found[problem.Id] = problem
+	}
+
+	if !strings.Contains(found[missing.Id()].Description, "media") {
+		t.Fatalf("the missing media should be reported, got %v", problems)
+	}
+	if _, ok := found[corruptId]; !ok {
+		t.Fatalf("the corrupt bug should be reported, got %v", problems)
+	}
+	if _, ok := found[valid.Id()]; ok {
+		t.Fatalf("the valid bug should not be reported, got %v", problems)
+	}
+}
+
+func TestFsckBypassCache(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	b, err := operations.Create(rene, "bug", "message")
+	checkErr(t, err)
+	checkErr(t, b.Commit(repo))
+
+	// fill the cache
+	_, err = bug.ReadLocalBug(repo, b.Id())
+	checkErr(t, err)
+
+	// lose the operation pack
+	head, err := repo.ResolveRef("refs/bugs/" + b.Id())
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+	for _, entry := range entries {
+		if entry.Name == "ops" {
+			object := path.Join(repo.GetPath(), ".git", "objects", string(entry.Hash[:2]), string(entry.Hash[2:]))
+			checkErr(t, os.Remove(object))
+		}
+	}
+
+	problems, err := bug.Fsck(repo)
+	checkErr(t, err)
+	if len(problems) != 1 || problems[0].Id != b.Id() {
+		t.Fatalf("the lost pack should be reported, got %v", problems)
+	}
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestReadBugHead(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "message2")
+	operations.Close(bug1, rene)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	head, err := bug.ReadBugHead(repo, bug1.Id())
+	checkErr(t, err)
+
+	full, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	snap := full.Compile()
+
+	if head.Id != full.Id() {
+		t.Fatal("unexpected id")
+	}
+
+	if head.Status != snap.Status {
+		t.Fatalf("unexpected status: %s", head.Status)
+	}
+
+	if head.CreateTime != 1 || head.EditTime != 2 {
+		t.Fatalf("unexpected clocks: %d %d", head.CreateTime, head.EditTime)
+	}
+
+	if head.EditTime != snap.OperationEditTime(len(snap.Operations)-1) {
+		t.Fatal("the edit time should match the last commit")
+	}
+}
+
+func TestReadBugHeadAlias(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.Close(bug1, rene)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// the id of an alias is not the hash of a commit
+	aliasId := "0123456789abcdef0123456789abcdef01234567"
+	err = repo.UpdateSymbolicRef("refs/bugs/"+aliasId, "refs/bugs/"+bug1.Id())
+	checkErr(t, err)
+
+	head, err := bug.ReadBugHead(repo, aliasId)
+	checkErr(t, err)
+
+	if head.Id != bug1.Id() {
+		t.Fatalf("the alias should read the target bug, got %s", head.Id)
+	}
+
+	if head.Status != bug.ClosedStatus {
+		t.Fatalf("unexpected status: %s", head.Status)
+	}
+
+	create, edit, err := bug.ReadBugClocks(repo, aliasId)
+	checkErr(t, err)
+
+	if create != 1 || edit != 2 {
+		t.Fatalf("unexpected clocks: %d %d", create, edit)
+	}
+}
+
+func TestStatusEntryName(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.Close(bug1, rene)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+
+	found := false
+	for _, entry := range entries {
+		if entry.Name == "status-closed" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("the status should be stored by name, got %v", entries)
+	}
+}
+
+func BenchmarkReadBugHead(b *testing.B) {
+	repo, id := benchmarkBug(b)
+	defer cleanupRepo(repo)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := bug.ReadBugHead(repo, id)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadLocalBug(b *testing.B) {
+	repo, id := benchmarkBug(b)
+	defer cleanupRepo(repo)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := bug.ReadLocalBug(repo, id)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// create a git repo with a bug having a few commits
+func benchmarkBug(b *testing.B) (*repository.GitRepo, string) {
+	repo := createRepo(false)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		operations.Comment(bug1, rene, fmt.Sprintf("comment %d", i))
+		err = bug1.Commit(repo)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return repo, bug1.Id()
+}
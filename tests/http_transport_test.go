@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestPushHTTP(t *testing.T) {
+	client := createRepo(false)
+	defer cleanupRepo(client)
+	server := createRepo(false)
+	defer cleanupRepo(server)
+
+	ts := httptest.NewServer(bug.NewHTTPReceiver(server))
+	defer ts.Close()
+
+	media, err := client.StoreData([]byte("a screenshot"))
+	checkErr(t, err)
+
+	bug1, err := operations.CreateWithFiles(rene, "bug1", "message", []util.Hash{media})
+	checkErr(t, err)
+	err = bug1.Commit(client)
+	checkErr(t, err)
+
+	err = bug.PushHTTP(client, ts.URL, bug1.Id())
+	checkErr(t, err)
+
+	pushed, err := bug.ReadLocalBug(server, bug1.Id())
+	checkErr(t, err)
+	if pushed.Compile().Title != "bug1" {
+		t.Fatal("unexpected bug on the server")
+	}
+
+	data, err := server.ReadData(media)
+	checkErr(t, err)
+	if string(data) != "a screenshot" {
+		t.Fatal("the media should be transferred")
+	}
+
+	// a new version is fast-forwarded
+	operations.Comment(bug1, rene, "comment")
+	err = bug1.Commit(client)
+	checkErr(t, err)
+
+	err = bug.PushHTTP(client, ts.URL, bug1.Id())
+	checkErr(t, err)
+
+	pushed, err = bug.ReadLocalBug(server, bug1.Id())
+	checkErr(t, err)
+	if len(pushed.Compile().Comments) != 2 {
+		t.Fatal("the new comment should be pushed")
+	}
+
+	// pushing again is a no-op
+	err = bug.PushHTTP(client, ts.URL, bug1.Id())
+	checkErr(t, err)
+
+	// a diverged history is refused
+	operations.Comment(pushed, rene, "server side")
+	err = pushed.Commit(server)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "client side")
+	err = bug1.Commit(client)
+	checkErr(t, err)
+
+	err = bug.PushHTTP(client, ts.URL, bug1.Id())
+	if err == nil {
+		t.Fatal("a diverged history should be refused")
+	}
+
+	// a form posted by a web page is refused
+	resp, err := http.Post(ts.URL, "text/plain", strings.NewReader("{}"))
+	checkErr(t, err)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected the content type to be refused, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestConcurrentCommits(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	noWitness := func(repo *repository.GitRepo) error { return nil }
+
+	// two instances on the same repo, like two processes
+	repoA, err := repository.NewGitRepo(repo.GetPath(), noWitness)
+	checkErr(t, err)
+	repoB, err := repository.NewGitRepo(repo.GetPath(), noWitness)
+	checkErr(t, err)
+
+	const nbCommits = 5
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*nbCommits)
+
+	for _, r := range []*repository.GitRepo{repoA, repoB} {
+		wg.Add(1)
+		go func(r *repository.GitRepo) {
+			defer wg.Done()
+			for i := 0; i < nbCommits; i++ {
+				b, err := operations.Create(rene, "bug", "message")
+				if err == nil {
+					err = b.Commit(r)
+				}
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(r)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		checkErr(t, err)
+	}
+
+	// no increment has been lost
+	repoC, err := repository.NewGitRepo(repo.GetPath(), noWitness)
+	checkErr(t, err)
+
+	editTime, err := repoC.EditTimeIncrement()
+	checkErr(t, err)
+	if editTime != 2*nbCommits+1 {
+		t.Fatalf("the edit clock collided: %d", editTime)
+	}
+
+	createTime, err := repoC.CreateTimeIncrement()
+	checkErr(t, err)
+	if createTime != 2*nbCommits+1 {
+		t.Fatalf("the create clock collided: %d", createTime)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	err := repo.Lock()
+	checkErr(t, err)
+	defer repo.Unlock()
+
+	timeout := repository.LockTimeout
+	repository.LockTimeout = 0
+	defer func() { repository.LockTimeout = timeout }()
+
+	b, err := operations.Create(rene, "bug", "message")
+	checkErr(t, err)
+
+	err = b.Commit(repo)
+	if err != repository.ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}
+
+func TestMockRepoLock(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	err := repo.Lock()
+	checkErr(t, err)
+
+	b, err := operations.Create(rene, "bug", "message")
+	checkErr(t, err)
+
+	err = b.Commit(repo)
+	if err != repository.ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	err = repo.Unlock()
+	checkErr(t, err)
+
+	err = b.Commit(repo)
+	checkErr(t, err)
+}
+
+func TestStaleLock(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	// a process that has terminated
+	cmd := exec.Command("git", "--version")
+	checkErr(t, cmd.Run())
+	deadPid := cmd.Process.Pid
+
+	lockPath := path.Join(repo.GetPath(), ".git", "git-bug", "lock")
+	checkErr(t, os.MkdirAll(path.Dir(lockPath), 0777))
+	checkErr(t, ioutil.WriteFile(lockPath, []byte(fmt.Sprintf("%d", deadPid)), 0644))
+
+	timeout := repository.LockTimeout
+	repository.LockTimeout = 0
+	defer func() { repository.LockTimeout = timeout }()
+
+	b, err := operations.Create(rene, "bug", "message")
+	checkErr(t, err)
+
+	err = b.Commit(repo)
+	checkErr(t, err)
+}
+
+func TestStaleBug(t *testing.T) {
+	repos := map[string]repository.Repo{
+		"mock": repository.NewMockRepoForTest(),
+		"git":  createRepo(false),
+	}
+	defer cleanupRepo(repos["git"].(*repository.GitRepo))
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			b, err := operations.Create(rene, "bug", "message")
+			checkErr(t, err)
+			checkErr(t, b.Commit(repo))
+
+			// two copies read at the same version
+			first, err := bug.ReadLocalBug(repo, b.Id())
+			checkErr(t, err)
+			second, err := bug.ReadLocalBug(repo, b.Id())
+			checkErr(t, err)
+
+			checkErr(t, operations.Comment(first, rene, "first"))
+			checkErr(t, first.Commit(repo))
+
+			checkErr(t, operations.Comment(second, rene, "second"))
+			err = second.Commit(repo)
+			if !errors.Is(err, bug.ErrStaleBug) {
+				t.Fatalf("expected ErrStaleBug, got %v", err)
+			}
+
+			// the first commit has not been lost
+			read, err := bug.ReadLocalBug(repo, b.Id())
+			checkErr(t, err)
+			snap := read.Compile()
+			if len(snap.Comments) != 2 || snap.Comments[1].Message != "first" {
+				t.Fatalf("the first comment has been overwritten: %v", snap.Comments)
+			}
+		})
+	}
+}
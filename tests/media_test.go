@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestMediaLimits(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug.SetMediaLimits(2, 1024)
+	defer bug.SetMediaLimits(100, 50*1024*1024)
+
+	var files []util.Hash
+	for i := 0; i < 3; i++ {
+		hash, err := repo.StoreData([]byte(fmt.Sprintf("file %d", i)))
+		checkErr(t, err)
+		files = append(files, hash)
+	}
+
+	bug1, err := operations.CreateWithFiles(rene, "bug1", "message", files)
+	checkErr(t, err)
+
+	err = bug1.Commit(repo)
+	if !errors.Is(err, bug.ErrMediaLimitExceeded) {
+		t.Fatalf("expected ErrMediaLimitExceeded, got %v", err)
+	}
+
+	// nothing has been written
+	ids, err := bug.ListLocalIds(repo)
+	checkErr(t, err)
+	if len(ids) != 0 || !bug1.HasPendingOp() {
+		t.Fatal("the commit should have been refused before any write")
+	}
+
+	// too big
+	big, err := repo.StoreData(make([]byte, 2048))
+	checkErr(t, err)
+
+	bug2, err := operations.CreateWithFiles(rene, "bug2", "message", []util.Hash{big})
+	checkErr(t, err)
+
+	err = bug2.Commit(repo)
+	if !errors.Is(err, bug.ErrMediaLimitExceeded) {
+		t.Fatalf("expected ErrMediaLimitExceeded, got %v", err)
+	}
+
+	// within the limits
+	bug3, err := operations.CreateWithFiles(rene, "bug3", "message", files[:2])
+	checkErr(t, err)
+
+	err = bug3.Commit(repo)
+	checkErr(t, err)
+}
+
+// run with the race detector
+func TestMediaLimitsConcurrent(t *testing.T) {
+	defer bug.SetMediaLimits(100, 50*1024*1024)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			bug.SetMediaLimits(10+i, 1024*1024)
+		}
+	}()
+
+	repo := repository.NewMockRepoForTest()
+	for i := 0; i < 10; i++ {
+		b, err := operations.Create(rene, "title", "message")
+		checkErr(t, err)
+		checkErr(t, b.Commit(repo))
+	}
+
+	<-done
+}
+
+func TestAttachments(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	file1, err := repo.StoreData([]byte("file 1"))
+	checkErr(t, err)
+	file2, err := repo.StoreData([]byte("second file"))
+	checkErr(t, err)
+
+	bug1, err := operations.CreateWithFiles(rene, "bug1", "message", []util.Hash{file1})
+	checkErr(t, err)
+	err = operations.CommentWithFiles(bug1, rene, "comment", []util.Hash{file1, file2})
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	snap := bug1.Compile()
+	attachments := snap.Attachments()
+	if len(attachments) != 3 {
+		t.Fatalf("expected 3 attachments, got %d", len(attachments))
+	}
+	if attachments[0].Hash != file1 || attachments[0].Operation.OpType() != bug.CreateOp {
+		t.Fatal("the first attachment should be the file of the create operation")
+	}
+	if attachments[2].Hash != file2 || attachments[2].Operation.OpType() != bug.AddCommentOp {
+		t.Fatal("the last attachment should be the second file of the comment")
+	}
+	if attachments[0].Size != -1 {
+		t.Fatal("the size should not be known yet")
+	}
+
+	err = bug.ReadAttachmentSizes(repo, attachments)
+	checkErr(t, err)
+	if attachments[1].Size != 6 || attachments[2].Size != 11 {
+		t.Fatalf("unexpected sizes %d and %d", attachments[1].Size, attachments[2].Size)
+	}
+
+	data, err := bug.ReadAttachment(repo, file2)
+	checkErr(t, err)
+	if string(data) != "second file" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	// a file that has not been fetched
+	_, err = bug.ReadAttachment(repository.NewMockRepoForTest(), file2)
+	if !errors.Is(err, bug.ErrAttachmentNotFound) {
+		t.Fatalf("expected ErrAttachmentNotFound, got %v", err)
+	}
+	err = bug.ReadAttachmentSizes(repository.NewMockRepoForTest(), attachments)
+	if !errors.Is(err, bug.ErrAttachmentNotFound) {
+		t.Fatalf("expected ErrAttachmentNotFound, got %v", err)
+	}
+}
+
+func TestAttachmentsRead(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	file, err := repo.StoreData([]byte("file"))
+	checkErr(t, err)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = operations.CommentWithFiles(bug1, rene, "comment", []util.Hash{file})
+	checkErr(t, err)
+	// stored as a separate blob, but not an attachment
+	err = operations.Comment(bug1, rene, strings.Repeat("a", 70*1024))
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	attachments := bug2.Compile().Attachments()
+	if len(attachments) != 1 || attachments[0].Hash != file {
+		t.Fatalf("the attached file should be read back, got %d attachments", len(attachments))
+	}
+}
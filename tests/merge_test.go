@@ -0,0 +1,435 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// create a bug whose local and remote versions have diverged, and return the
+// local head before the merge
+func divergedBug(t testing.TB, repo repository.Repo, nbLocal int) (*bug.Bug, util.Hash) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	remote, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.Comment(remote, rene, "remote comment")
+	operations.Close(remote, rene)
+	err = remote.Commit(repo)
+	checkErr(t, err)
+	err = repo.CopyRef(localRef, remoteRef)
+	checkErr(t, err)
+
+	err = repo.UpdateRef(localRef, root)
+	checkErr(t, err)
+	for i := 0; i < nbLocal; i++ {
+		operations.Comment(bug1, rene, fmt.Sprintf("local comment %d", i))
+		err = bug1.Commit(repo)
+		checkErr(t, err)
+	}
+	operations.Open(bug1, rene)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	return bug1, head
+}
+
+func TestMergeIncremental(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, before := divergedBug(t, repo, 3)
+	localRef := "refs/bugs/" + bug1.Id()
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	// the current merge
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	updated, err := local.Merge(repo, remote)
+	checkErr(t, err)
+	if !updated {
+		t.Fatal("the bug should be updated")
+	}
+	expected, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the incremental one, from the same state
+	err = repo.UpdateRef(localRef, before)
+	checkErr(t, err)
+	updated, err = bug.MergeIncremental(repo, bug1.Id(), remote)
+	checkErr(t, err)
+	if !updated {
+		t.Fatal("the bug should be updated")
+	}
+	result, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	if result != expected {
+		t.Fatal("both merges should produce the same history")
+	}
+
+	head, err := bug.ReadBugHead(repo, bug1.Id())
+	checkErr(t, err)
+	if head.Status != bug.OpenStatus {
+		t.Fatal("the head should hold the merged status")
+	}
+
+	// nothing left to merge
+	updated, err = bug.MergeIncremental(repo, bug1.Id(), remote)
+	checkErr(t, err)
+	if updated {
+		t.Fatal("a second merge should do nothing")
+	}
+}
+
+func TestMergeIncrementalDAG(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	checkErr(t, bug.SetHistoryFormatVersion(bug.HistoryFormatDAG))
+	defer bug.SetHistoryFormatVersion(bug.HistoryFormatLinear)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repo))
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	remote, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, operations.Comment(remote, rene, "remote1"))
+	checkErr(t, remote.Commit(repo))
+	checkErr(t, repo.CopyRef(localRef, remoteRef))
+	remoteHead, err := repo.ResolveRef(remoteRef)
+	checkErr(t, err)
+
+	// our comment end up on the second parent side of a merge commit
+	checkErr(t, repo.UpdateRef(localRef, root))
+	checkErr(t, operations.Comment(bug1, rene, "local1"))
+	checkErr(t, bug1.Commit(repo))
+	_, err = bug1.MergeDAG(repo, remote)
+	checkErr(t, err)
+	mergeHead, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the remote move on
+	checkErr(t, repo.UpdateRef(localRef, remoteHead))
+	checkErr(t, operations.Comment(remote, rene, "remote2"))
+	checkErr(t, remote.Commit(repo))
+	checkErr(t, repo.CopyRef(localRef, remoteRef))
+	checkErr(t, repo.UpdateRef(localRef, mergeHead))
+
+	remote, err = bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	updated, err := bug.MergeIncremental(repo, bug1.Id(), remote)
+	checkErr(t, err)
+	if !updated {
+		t.Fatal("the bug should be updated")
+	}
+
+	merged, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	assertComments(t, merged.Compile(), []string{"message", "remote1", "remote2", "local1"})
+}
+
+func TestMergeConflicts(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	// the remote closed the bug while we reopened it
+	bug1, before := divergedBug(t, repo, 1)
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	_, conflicts, err := local.MergeWithConflicts(repo, remote)
+	checkErr(t, err)
+
+	if len(conflicts) != 1 || conflicts[0].Ours.OpType() != bug.SetStatusOp || !conflicts[0].AutoResolved {
+		t.Fatalf("expected a resolved status conflict, got %v", conflicts)
+	}
+	if local.Compile().Status != bug.OpenStatus {
+		t.Fatal("our operation should win")
+	}
+
+	err = repo.UpdateRef("refs/bugs/"+bug1.Id(), before)
+	checkErr(t, err)
+	_, conflicts, err = bug.MergeIncrementalWithConflicts(repo, bug1.Id(), remote)
+	checkErr(t, err)
+	if len(conflicts) != 1 || conflicts[0].Theirs.OpType() != bug.SetStatusOp {
+		t.Fatalf("the incremental merge should find the same conflict, got %v", conflicts)
+	}
+
+	// setting the same value on both sides is not a conflict
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	checkErr(t, bug2.Commit(repo))
+	root, err := repo.ResolveRef("refs/bugs/" + bug2.Id())
+	checkErr(t, err)
+
+	operations.SetTitle(bug2, rene, "same title")
+	checkErr(t, operations.Comment(bug2, rene, "remote comment"))
+	checkErr(t, bug2.Commit(repo))
+	checkErr(t, repo.CopyRef("refs/bugs/"+bug2.Id(), "refs/remotes/origin/bugs/"+bug2.Id()))
+
+	checkErr(t, repo.UpdateRef("refs/bugs/"+bug2.Id(), root))
+	local2, err := bug.ReadLocalBug(repo, bug2.Id())
+	checkErr(t, err)
+	operations.SetTitle(local2, rene, "same title")
+	checkErr(t, local2.Commit(repo))
+
+	remote2, err := bug.ReadRemoteBug(repo, "origin", bug2.Id())
+	checkErr(t, err)
+	updated, conflicts, err := local2.MergeWithConflicts(repo, remote2)
+	checkErr(t, err)
+	if !updated || len(conflicts) != 0 {
+		t.Fatalf("expected a merge without conflict, got %v", conflicts)
+	}
+}
+
+func TestMergeDedup(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repo))
+	localRef := "refs/bugs/" + bug1.Id()
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the same operation is made on both sides, at a different time
+	same := operations.NewAddCommentOp(rene, "same comment", nil)
+	later := same
+	later.UnixTime += 10
+
+	remote, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, remote.Append(same))
+	checkErr(t, remote.Commit(repo))
+	checkErr(t, repo.CopyRef(localRef, "refs/remotes/origin/bugs/"+bug1.Id()))
+
+	checkErr(t, repo.UpdateRef(localRef, root))
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, local.Append(later))
+	checkErr(t, operations.Comment(local, rene, "local comment"))
+	checkErr(t, local.Commit(repo))
+
+	hash1, err := same.Hash()
+	checkErr(t, err)
+	hash2, err := same.Hash()
+	checkErr(t, err)
+	if hash1 != hash2 {
+		t.Fatal("the hash of an operation should be stable")
+	}
+
+	remote, err = bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	// the preview tell the same as the merge
+	stats, err := local.MergePreview(repo, remote)
+	checkErr(t, err)
+	if stats.Pulled != 1 || stats.Rebased != 1 || stats.Deduplicated != 1 {
+		t.Fatalf("unexpected preview %+v", stats)
+	}
+
+	_, err = local.Merge(repo, remote)
+	checkErr(t, err)
+
+	merged, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	comments := merged.Compile().Comments
+	if len(comments) != 3 {
+		t.Fatalf("expected 3 comments, got %d", len(comments))
+	}
+	if comments[1].Message != "same comment" || comments[2].Message != "local comment" {
+		t.Fatalf("unexpected comments %v", comments)
+	}
+}
+
+func TestMergePreview(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, before := divergedBug(t, repo, 2)
+	localRef := "refs/bugs/" + bug1.Id()
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	stats, err := local.MergePreview(repo, remote)
+	checkErr(t, err)
+
+	after, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+	if after != before {
+		t.Fatal("a preview should not write anything")
+	}
+
+	// a comment and a close from the remote, two comments and an open locally
+	if stats.Id != bug1.Id() || stats.Pulled != 2 || stats.Rebased != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if len(stats.RebasedCommits) != 3 || len(stats.Conflicts) != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	_, err = local.Merge(repo, remote)
+	checkErr(t, err)
+
+	merged, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	if merged.Compile().Status != stats.Status {
+		t.Fatal("the preview should predict the merged status")
+	}
+
+	// once merged, there is nothing left to do
+	stats, err = merged.MergePreview(repo, remote)
+	checkErr(t, err)
+	if stats.Pulled != 0 || stats.Rebased != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	repo := repository.NewMockRepoForTest()
+	bug1, before := divergedBug(b, repo, 200)
+	localRef := "refs/bugs/" + bug1.Id()
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(b, err)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		local, err := bug.ReadLocalBug(repo, bug1.Id())
+		checkErr(b, err)
+		_, err = local.Merge(repo, remote)
+		checkErr(b, err)
+		err = repo.UpdateRef(localRef, before)
+		checkErr(b, err)
+	}
+}
+
+func BenchmarkMergeIncremental(b *testing.B) {
+	repo := repository.NewMockRepoForTest()
+	bug1, before := divergedBug(b, repo, 200)
+	localRef := "refs/bugs/" + bug1.Id()
+
+	remote, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(b, err)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := bug.MergeIncremental(repo, bug1.Id(), remote)
+		checkErr(b, err)
+		err = repo.UpdateRef(localRef, before)
+		checkErr(b, err)
+	}
+}
+
+func TestMergeFastForwardOnly(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repo))
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// the remote has new operations, we have none
+	remote, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, operations.Comment(remote, rene, "remote comment"))
+	checkErr(t, remote.Commit(repo))
+	checkErr(t, repo.CopyRef(localRef, remoteRef))
+	checkErr(t, repo.UpdateRef(localRef, root))
+
+	local, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	remote, err = bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	updated, err := local.MergeFastForwardOnly(repo, remote)
+	checkErr(t, err)
+	if !updated {
+		t.Fatal("the bug should be fast-forwarded")
+	}
+	head, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+	remoteHead, err := repo.ResolveRef(remoteRef)
+	checkErr(t, err)
+	if head != remoteHead {
+		t.Fatal("the local ref should point to the remote head")
+	}
+	if len(local.Compile().Comments) != 2 {
+		t.Fatal("the merged bug should have the remote comment")
+	}
+
+	updated, err = local.MergeFastForwardOnly(repo, remote)
+	checkErr(t, err)
+	if updated {
+		t.Fatal("nothing new should be merged")
+	}
+
+	// we diverge
+	checkErr(t, operations.Comment(local, rene, "local comment"))
+	checkErr(t, local.Commit(repo))
+	localHead, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+	checkErr(t, repo.UpdateRef(localRef, remoteHead))
+	checkErr(t, operations.Comment(remote, rene, "other remote comment"))
+	checkErr(t, remote.Commit(repo))
+	checkErr(t, repo.CopyRef(localRef, remoteRef))
+	checkErr(t, repo.UpdateRef(localRef, localHead))
+	remote, err = bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	_, err = local.MergeFastForwardOnly(repo, remote)
+	if err != bug.ErrNotFastForward {
+		t.Fatalf("expected ErrNotFastForward, got %v", err)
+	}
+	head, err = repo.ResolveRef(localRef)
+	checkErr(t, err)
+	if head != localHead {
+		t.Fatal("nothing should be written")
+	}
+
+	// another process changed the bug since it was read
+	concurrent, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	checkErr(t, operations.Comment(concurrent, rene, "concurrent comment"))
+	checkErr(t, concurrent.Commit(repo))
+
+	_, err = local.MergeFastForwardOnly(repo, remote)
+	if !errors.Is(err, bug.ErrStaleBug) {
+		t.Fatalf("expected ErrStaleBug, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/gob"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+// an operation type as it could be retired in a future version
+const legacyTitleOp bug.OperationType = 1000
+
+type legacyTitleOperation struct {
+	bug.OpBase
+	NewTitle string
+}
+
+// the old logic, that should never run once migrated
+func (op legacyTitleOperation) Apply(snapshot bug.Snapshot) bug.Snapshot {
+	return snapshot
+}
+
+func (op legacyTitleOperation) Hash() (util.Hash, error) {
+	return bug.HashOperation(op)
+}
+
+func init() {
+	gob.Register(legacyTitleOperation{})
+}
+
+func TestOperationMigration(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+
+	legacy := legacyTitleOperation{
+		OpBase:   bug.NewOpBase(legacyTitleOp, rene),
+		NewTitle: "migrated title",
+	}
+	checkErr(t, bug1.Append(legacy))
+	checkErr(t, bug1.Commit(repo))
+
+	bug.RegisterMigration(legacyTitleOp, func(op bug.Operation) (bug.Operation, error) {
+		legacy := op.(legacyTitleOperation)
+
+		current := operations.NewSetTitleOp(legacy.Author, legacy.NewTitle, "")
+		current.UnixTime = legacy.UnixTime
+
+		return current, nil
+	})
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	snap := bug2.Compile()
+
+	if snap.Title != "migrated title" {
+		t.Fatalf("the migrated operation should be applied, got title %s", snap.Title)
+	}
+
+	if len(snap.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(snap.Operations))
+	}
+
+	for _, op := range snap.Operations {
+		if op.OpType() == legacyTitleOp {
+			t.Fatal("the deprecated operation should not be seen")
+		}
+	}
+
+	if snap.Operations[1].Time() != legacy.Time() {
+		t.Fatal("the time of the operation should be kept")
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestMultiRepo(t *testing.T) {
+	code := repository.NewMockRepoForTest()
+	issues := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug in code", "message")
+	checkErr(t, err)
+	err = bug1.Commit(code)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug in issues", "message")
+	checkErr(t, err)
+	err = bug2.Commit(issues)
+	checkErr(t, err)
+
+	multi := bug.NewMultiRepo()
+	checkErr(t, multi.Add("code", code))
+	checkErr(t, multi.Add("issues", issues))
+
+	if multi.Add("code", code) == nil {
+		t.Fatal("a repository name should be unique")
+	}
+	if multi.Add("a/b", code) == nil {
+		t.Fatal("a repository name should not contain the separator")
+	}
+
+	sources := make(map[string]string)
+	for streamed := range multi.ReadAllLocalBugs() {
+		checkErr(t, streamed.Err)
+		sources[streamed.Bug.Id()] = streamed.Repo
+	}
+
+	if len(sources) != 2 || sources[bug1.Id()] != "code" || sources[bug2.Id()] != "issues" {
+		t.Fatalf("unexpected bugs %v", sources)
+	}
+
+	found, name, err := multi.FindLocalBug(bug2.HumanId())
+	checkErr(t, err)
+	if found.Id() != bug2.Id() || name != "issues" {
+		t.Fatal("the bug should be resolved in its repository")
+	}
+
+	found, name, err = multi.FindLocalBug(bug.NamespacedId("code", bug1.Id()))
+	checkErr(t, err)
+	if found.Id() != bug1.Id() || name != "code" {
+		t.Fatal("the namespaced bug should be resolved in its repository")
+	}
+
+	// the bug exist, but not in this repository
+	_, _, err = multi.FindLocalBug(bug.NamespacedId("code", bug2.Id()))
+	if err == nil {
+		t.Fatal("the bug should not be found in the other repository")
+	}
+
+	_, _, err = multi.FindLocalBug(bug.NamespacedId("unknown", bug1.Id()))
+	if err == nil {
+		t.Fatal("the repository should be unknown")
+	}
+}
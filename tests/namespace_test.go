@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestNewNamespace(t *testing.T) {
+	for _, name := range []string{"", "Tasks", "a/b", "remotes", "heads", "tags", "notes", "tasks-read", "-tasks"} {
+		if _, err := bug.NewNamespace(name); err == nil {
+			t.Fatalf("namespace name \"%s\" should be rejected", name)
+		}
+	}
+
+	ns, err := bug.NewNamespace("tasks")
+	checkErr(t, err)
+	if ns.Name() != "tasks" {
+		t.Fatal("unexpected name")
+	}
+	if bug.DefaultNamespace.Name() != "bugs" {
+		t.Fatal("the default namespace should be the historical one")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	gitRepo := createRepo(false)
+	defer cleanupRepo(gitRepo)
+
+	repos := map[string]repository.Repo{
+		"mock": repository.NewMockRepoForTest(),
+		"git":  gitRepo,
+	}
+
+	tasks, err := bug.NewNamespace("tasks")
+	checkErr(t, err)
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			tasksRepo := tasks.Repo(repo)
+
+			bug1, err := operations.Create(rene, "bug", "message")
+			checkErr(t, err)
+			checkErr(t, bug1.Commit(repo))
+
+			task1, err := operations.Create(rene, "task", "message")
+			checkErr(t, err)
+			checkErr(t, task1.Commit(tasksRepo))
+
+			exist, err := repo.RefExist("refs/tasks/" + task1.Id())
+			checkErr(t, err)
+			if !exist {
+				t.Fatal("the task should be stored under refs/tasks/")
+			}
+
+			ids, err := bug.ListLocalIds(repo)
+			checkErr(t, err)
+			if len(ids) != 1 || ids[0] != bug1.Id() {
+				t.Fatalf("unexpected bugs %v", ids)
+			}
+
+			ids, err = bug.ListLocalIds(tasksRepo)
+			checkErr(t, err)
+			if len(ids) != 1 || ids[0] != task1.Id() {
+				t.Fatalf("unexpected tasks %v", ids)
+			}
+
+			_, err = bug.ReadLocalBug(repo, task1.Id())
+			if err == nil {
+				t.Fatal("the task should not be visible in the default namespace")
+			}
+
+			read, err := bug.ReadLocalBug(tasksRepo, task1.Id())
+			checkErr(t, err)
+			if read.Compile().Title != "task" {
+				t.Fatal("unexpected task")
+			}
+
+			found, err := bug.FindLocalBug(bug.DefaultNamespace.Repo(repo), bug1.HumanId())
+			checkErr(t, err)
+			if found.Id() != bug1.Id() {
+				t.Fatal("unexpected bug")
+			}
+		})
+	}
+}
+
+func TestNamespacePushPull(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	tasks, err := bug.NewNamespace("tasks")
+	checkErr(t, err)
+
+	task1, err := operations.Create(rene, "task", "message")
+	checkErr(t, err)
+	checkErr(t, task1.Commit(tasks.Repo(repoA)))
+
+	_, err = bug.Push(tasks.Repo(repoA), "origin")
+	checkErr(t, err)
+
+	err = bug.Pull(tasks.Repo(repoB), ioutil.Discard, "origin")
+	checkErr(t, err)
+
+	ids, err := bug.ListLocalIds(tasks.Repo(repoB))
+	checkErr(t, err)
+	if len(ids) != 1 || ids[0] != task1.Id() {
+		t.Fatalf("unexpected tasks %v", ids)
+	}
+
+	ids, err = bug.ListLocalIds(repoB)
+	checkErr(t, err)
+	if len(ids) != 0 {
+		t.Fatalf("the default namespace should be empty, got %v", ids)
+	}
+}
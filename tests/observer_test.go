@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+type recordingObserver struct {
+	commits [][]bug.Operation
+}
+
+func (o *recordingObserver) OnCommit(b *bug.Bug, newOps []bug.Operation) {
+	o.commits = append(o.commits, newOps)
+}
+
+type panickingObserver struct{}
+
+func (panickingObserver) OnCommit(b *bug.Bug, newOps []bug.Operation) {
+	panic("observer failure")
+}
+
+func TestCommitObserver(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	recorder := &recordingObserver{}
+	// a panicking observer doesn't prevent the commit nor the next observers
+	bug1.RegisterObserver(panickingObserver{})
+	bug1.RegisterObserver(recorder)
+
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.SetTitle(bug1, rene, "title2")
+	err = operations.Comment(bug1, rene, "comment")
+	checkErr(t, err)
+
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// nothing to commit, no notification
+	if bug1.Commit(repo) == nil {
+		t.Fatal("an empty commit should fail")
+	}
+
+	if len(recorder.commits) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(recorder.commits))
+	}
+
+	if len(recorder.commits[0]) != 1 || recorder.commits[0][0].OpType() != bug.CreateOp {
+		t.Fatal("the first notification should hold the create operation")
+	}
+
+	second := recorder.commits[1]
+	if len(second) != 2 || second[0].OpType() != bug.SetTitleOp || second[1].OpType() != bug.AddCommentOp {
+		t.Fatal("the second notification should hold the new operations")
+	}
+
+	if bug1.HasPendingOp() {
+		t.Fatal("the operations should be committed")
+	}
+}
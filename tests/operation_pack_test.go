@@ -1,30 +1,165 @@
 package tests
 
 import (
-	"github.com/MichaelMure/git-bug/bug"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"hash/crc32"
 	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
 )
 
-func TestOperationPackSerialize(t *testing.T) {
-	opp := bug.OperationPack{}
+func TestParseTruncatedPack(t *testing.T) {
+	pack := bug.OperationPack{}
+	pack.Append(createOp)
+	pack.Append(addCommentOp)
 
-	opp.Append(createOp)
-	opp.Append(setTitleOp)
-	opp.Append(addCommentOp)
+	data, err := pack.Serialize()
+	checkErr(t, err)
 
-	data, err := opp.Serialize()
+	parsed, err := bug.ParseOperationPack(data)
+	checkErr(t, err)
+	if len(parsed.Operations) != 2 {
+		t.Fatal("the pack should round-trip")
+	}
 
-	if err != nil {
-		t.Fatal(err)
+	for _, size := range []int{3, 10, len(data) / 2, len(data) - 1} {
+		_, err = bug.ParseOperationPack(data[:size])
+		if !errors.Is(err, bug.ErrTruncatedPack) {
+			t.Fatalf("size %d: expected ErrTruncatedPack, got %v", size, err)
+		}
 	}
 
-	if len(data) == 0 {
-		t.Fatal("empty serialized data")
+	// a flipped bit in the payload
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = bug.ParseOperationPack(corrupted)
+	if !errors.Is(err, bug.ErrCorruptedPack) {
+		t.Fatalf("expected ErrCorruptedPack, got %v", err)
 	}
 
-	_, err = bug.ParseOperationPack(data)
+	// a pack serialized before the header existed is still readable
+	var legacy bytes.Buffer
+	err = gob.NewEncoder(&legacy).Encode(pack)
+	checkErr(t, err)
+
+	parsed, err = bug.ParseOperationPack(legacy.Bytes())
+	checkErr(t, err)
+	if len(parsed.Operations) != 2 {
+		t.Fatal("a legacy pack should be readable")
+	}
+
+	_, err = bug.ParseOperationPack(legacy.Bytes()[:legacy.Len()/2])
+	if !errors.Is(err, bug.ErrTruncatedPack) {
+		t.Fatalf("expected ErrTruncatedPack for a legacy pack, got %v", err)
+	}
+}
+
+func TestPackCompression(t *testing.T) {
+	defer bug.SetPackCompressionThreshold(1024)
+	bug.SetPackCompressionThreshold(1024)
+
+	makePack := func(comments int) bug.OperationPack {
+		pack := bug.OperationPack{}
+		pack.Append(createOp)
+		for i := 0; i < comments; i++ {
+			pack.Append(operations.NewAddCommentOp(rene, "the same comment, again and again", nil))
+		}
+		return pack
+	}
+
+	// the flags byte follow the magic and the version
+	const flagsOffset = 5
 
-	if err != nil {
-		t.Fatal(err)
+	small := makePack(0)
+	data, err := small.Serialize()
+	checkErr(t, err)
+	if data[flagsOffset] != 0 {
+		t.Fatal("a small pack should not be compressed")
+	}
+	parsed, err := bug.ParseOperationPack(data)
+	checkErr(t, err)
+	if len(parsed.Operations) != 1 {
+		t.Fatal("the small pack should round-trip")
+	}
+
+	large := makePack(100)
+	data, err = large.Serialize()
+	checkErr(t, err)
+	if data[flagsOffset] != 1 {
+		t.Fatal("a large pack should be compressed")
+	}
+	parsed, err = bug.ParseOperationPack(data)
+	checkErr(t, err)
+	if len(parsed.Operations) != 101 {
+		t.Fatal("the large pack should round-trip")
+	}
+
+	bug.SetPackCompressionThreshold(0)
+	uncompressed, err := large.Serialize()
+	checkErr(t, err)
+	if uncompressed[flagsOffset] != 0 || len(uncompressed) <= len(data) {
+		t.Fatal("the compression should be disabled")
+	}
+
+	// an unknown flag can't be read
+	unknown := append([]byte{}, data...)
+	unknown[flagsOffset] |= 0x80
+	_, err = bug.ParseOperationPack(unknown)
+	if !errors.Is(err, bug.ErrUnsupportedFormatVersion) {
+		t.Fatalf("expected ErrUnsupportedFormatVersion, got %v", err)
+	}
+}
+
+// run with the race detector
+func TestPackCompressionConcurrent(t *testing.T) {
+	defer bug.SetPackCompressionThreshold(1024)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			bug.SetPackCompressionThreshold(i * 10)
+		}
+	}()
+
+	pack := bug.OperationPack{}
+	pack.Append(createOp)
+	for i := 0; i < 10; i++ {
+		data, err := pack.Serialize()
+		checkErr(t, err)
+		_, err = bug.ParseOperationPack(data)
+		checkErr(t, err)
+	}
+
+	<-done
+}
+
+func TestParsePackBomb(t *testing.T) {
+	// a small payload decompressing to more than the limit
+	var payload bytes.Buffer
+	writer, err := flate.NewWriter(&payload, flate.BestCompression)
+	checkErr(t, err)
+	zeros := make([]byte, 1024*1024)
+	for written := 0; written <= bug.MaxPackSize; written += len(zeros) {
+		_, err = writer.Write(zeros)
+		checkErr(t, err)
+	}
+	checkErr(t, writer.Close())
+
+	// magic, version, flags, payload length, payload checksum
+	data := []byte("\x00gbp\x01\x01")
+	data = append(data, make([]byte, 8)...)
+	binary.BigEndian.PutUint32(data[6:10], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(data[10:14], crc32.ChecksumIEEE(payload.Bytes()))
+	data = append(data, payload.Bytes()...)
+
+	_, err = bug.ParseOperationPack(data)
+	if !errors.Is(err, bug.ErrCorruptedPack) {
+		t.Fatalf("expected ErrCorruptedPack, got %v", err)
 	}
 }
@@ -0,0 +1,530 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestOperationReply(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "question")
+	operations.Comment(bug1, rene, "unrelated")
+
+	question := bug1.Compile().Operations[1]
+	parent, err := bug.HashOperation(question)
+	checkErr(t, err)
+
+	operations.Reply(bug1, rene, parent, "answer")
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	snap := bug2.Compile()
+	replies := snap.Replies(parent)
+
+	if len(replies) != 1 {
+		t.Fatalf("Unexpected number of replies: %d", len(replies))
+	}
+
+	if replies[0].(operations.AddCommentOperation).Message != "answer" {
+		t.Fatal("Unexpected reply")
+	}
+
+	if replies[0].Parent() != parent {
+		t.Fatal("The reply should reference its parent")
+	}
+}
+
+func TestCommentCodeBlock(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	code := "func main() {\n\tfmt.Println(\"hello\")\n}\n"
+	operations.CommentWithCode(bug1, rene, "this fail", " Go", code)
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	comment := bug2.Compile().Comments[1]
+
+	if len(comment.CodeBlocks) != 1 {
+		t.Fatal("the code block should be present")
+	}
+
+	if comment.CodeBlocks[0].Language != "go" {
+		t.Fatalf("unexpected language: %s", comment.CodeBlocks[0].Language)
+	}
+
+	if comment.CodeBlocks[0].Content != code {
+		t.Fatal("the code should round-trip unchanged")
+	}
+}
+
+func TestCommentExternalPayload(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	large := strings.Repeat("a very long log line\n", 10000)
+	operations.Comment(bug1, rene, large)
+
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// the ops blob should not hold the message
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+
+	for _, entry := range entries {
+		if entry.Name != "ops" {
+			continue
+		}
+
+		data, err := repo.ReadData(entry.Hash)
+		checkErr(t, err)
+
+		if len(data) >= len(large) {
+			t.Fatal("the large payload should be stored outside of the ops blob")
+		}
+	}
+
+	payload, err := repo.StoreData([]byte(large))
+	checkErr(t, err)
+
+	counting := &readCountingRepo{Repo: repo, hash: payload}
+
+	bug2, err := bug.ReadLocalBug(counting, bug1.Id())
+	checkErr(t, err)
+
+	if counting.reads != 0 {
+		t.Fatal("the large payload should not be read with the bug")
+	}
+
+	if bug2.Compile().Comments[1].Message != large {
+		t.Fatal("the large payload should be loaded back")
+	}
+
+	if counting.reads != 1 {
+		t.Fatalf("the large payload should be read once by Compile, got %d", counting.reads)
+	}
+}
+
+// readCountingRepo count the reads of a blob
+type readCountingRepo struct {
+	repository.Repo
+	hash  util.Hash
+	reads int
+}
+
+func (r *readCountingRepo) ReadData(hash util.Hash) ([]byte, error) {
+	if hash == r.hash {
+		r.reads++
+	}
+	return r.Repo.ReadData(hash)
+}
+
+func TestCommentExternalPayloadIdentity(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	large := strings.Repeat("a", 70*1024)
+	operations.Comment(bug1, rene, large)
+
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// the id of the comment doesn't change once stored externally
+	id := bug1.Compile().Comments[1].Id
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if bug2.Compile().Comments[1].Id != id {
+		t.Fatal("the comment id should be the same after a read")
+	}
+
+	err = operations.EditComment(bug1, rene, id, "edited")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug3, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if bug3.Compile().Comments[1].Message != "edited" {
+		t.Fatal("the edit should apply to the stored comment")
+	}
+}
+
+func TestCreateInitialStatus(t *testing.T) {
+	bug1, err := operations.CreateWithStatus(rene, "bug1", "message", bug.ClosedStatus)
+	checkErr(t, err)
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	if bug2.Compile().Status != bug.ClosedStatus {
+		t.Fatal("the initial status should come from the create operation")
+	}
+
+	bug3, err := operations.Create(rene, "bug3", "message")
+	checkErr(t, err)
+
+	if bug3.Compile().Status != bug.OpenStatus {
+		t.Fatal("a bug should be open by default")
+	}
+}
+
+func TestCommentEditHistory(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "frist")
+	target := bug1.Compile().Comments[1].Id
+
+	operations.EditComment(bug1, rene, target, "firts")
+	operations.EditComment(bug1, rene, target, "first")
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	snap := bug2.Compile()
+
+	if len(snap.Comments) != 2 {
+		t.Fatal("an edit should not add a comment")
+	}
+
+	comment := snap.Comments[1]
+
+	if comment.Message != "first" {
+		t.Fatalf("unexpected message: %s", comment.Message)
+	}
+
+	if len(comment.EditHistory) != 3 {
+		t.Fatalf("unexpected number of versions: %d", len(comment.EditHistory))
+	}
+
+	for i, expected := range []string{"frist", "firts", "first"} {
+		if comment.EditHistory[i].Message != expected {
+			t.Fatalf("unexpected version %d: %s", i, comment.EditHistory[i].Message)
+		}
+	}
+
+	if len(snap.Comments[0].EditHistory) != 0 {
+		t.Fatal("a comment never edited has no history")
+	}
+}
+
+func TestCommitInvalidCommentEdit(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	unknown := util.Hash("0123456789abcdef0123456789abcdef01234567")
+
+	operations.Comment(bug1, rene, "comment")
+
+	// the convenience function reject the edit right away
+	err = operations.EditComment(bug1, rene, unknown, "edited")
+	if !errors.Is(err, bug.ErrUnresolvedReference) {
+		t.Fatalf("expected an unresolved reference, got %v", err)
+	}
+
+	bug1.Append(operations.NewEditCommentOp(rene, unknown, "edited"))
+
+	err = bug1.ValidateStaging()
+	if !errors.Is(err, bug.ErrUnresolvedReference) {
+		t.Fatalf("expected an unresolved reference, got %v", err)
+	}
+
+	err = bug1.Commit(repo)
+	if !errors.Is(err, bug.ErrUnresolvedReference) {
+		t.Fatalf("the commit should be rejected, got %v", err)
+	}
+
+	after, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	if after != head {
+		t.Fatal("nothing should have been written")
+	}
+
+	// a comment staged before the edit can be targeted
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.Comment(bug2, rene, "comment")
+	target := bug2.Compile().Comments[1].Id
+	err = operations.EditComment(bug2, rene, target, "edited")
+	checkErr(t, err)
+
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+}
+
+func TestSignoffs(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	operations.Signoff(bug1, rene, bug.SignoffReview, "looks good")
+	operations.Signoff(bug1, rene, bug.SignoffQA, "")
+	operations.Signoff(bug1, rene, bug.SignoffQA, "verified on staging")
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	signoffs := bug2.Compile().Signoffs
+
+	if len(signoffs) != 2 {
+		t.Fatalf("unexpected number of sign-offs: %d", len(signoffs))
+	}
+
+	if signoffs[0].Role != bug.SignoffReview || signoffs[0].Note != "looks good" {
+		t.Fatal("the review sign-off should be kept")
+	}
+
+	if signoffs[1].Role != bug.SignoffQA || signoffs[1].Note != "verified on staging" {
+		t.Fatal("the QA sign-off should be superseded")
+	}
+}
+
+func TestBuildStatuses(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	checkErr(t, operations.SetBuildStatus(bug1, rene, "ci/lint", bug.BuildPending, ""))
+	checkErr(t, operations.SetBuildStatus(bug1, rene, "ci/test", bug.BuildFailure, "https://ci.example.com/2"))
+	checkErr(t, operations.SetBuildStatus(bug1, rene, "ci/lint", bug.BuildSuccess, "https://ci.example.com/1"))
+
+	if err := operations.SetBuildStatus(bug1, rene, "ci/lint", "broken", ""); err == nil {
+		t.Fatal("an unknown state should be rejected")
+	}
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	statuses := bug2.Compile().BuildStatuses
+
+	if len(statuses) != 2 {
+		t.Fatalf("unexpected number of build statuses: %d", len(statuses))
+	}
+
+	lint := statuses["ci/lint"]
+	if lint.State != bug.BuildSuccess || lint.TargetURL != "https://ci.example.com/1" {
+		t.Fatalf("only the latest lint status should be kept, got %+v", lint)
+	}
+
+	if statuses["ci/test"].State != bug.BuildFailure {
+		t.Fatal("the test status should be kept")
+	}
+}
+
+func TestFixVersion(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	if err := operations.SetFixVersion(bug1, rene, "1.0"); err == nil {
+		t.Fatal("the fix version of an open bug should be rejected")
+	}
+
+	// forced anyway, the operation is ignored with a warning
+	checkErr(t, bug1.Append(operations.NewSetFixVersionOp(rene, "1.0")))
+	snap := bug1.Compile()
+	if snap.FixVersion != "" || len(snap.Warnings) != 1 {
+		t.Fatal("the fix version of an open bug should be ignored")
+	}
+
+	operations.Close(bug1, rene)
+	checkErr(t, operations.SetFixVersion(bug1, rene, "1.1"))
+	checkErr(t, bug1.Commit(repo))
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	operations.Close(bug2, rene)
+	checkErr(t, operations.SetFixVersion(bug2, rene, "1.1"))
+	checkErr(t, bug2.Commit(repo))
+
+	bug3, err := operations.Create(rene, "bug3", "message")
+	checkErr(t, err)
+	operations.Close(bug3, rene)
+	checkErr(t, operations.SetFixVersion(bug3, rene, "1.2"))
+	checkErr(t, bug3.Commit(repo))
+
+	read, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	if read.Compile().FixVersion != "1.1" {
+		t.Fatal("the fix version should be set")
+	}
+
+	ids, err := bug.ListFixedInVersion(repo, "1.1")
+	checkErr(t, err)
+	if len(ids) != 2 || ids[0] != bug1.Id() || ids[1] != bug2.Id() {
+		t.Fatalf("unexpected bugs fixed in 1.1: %v", ids)
+	}
+
+	// reopening clear the fix version
+	operations.Open(bug2, rene)
+	checkErr(t, bug2.Commit(repo))
+
+	if bug2.Compile().FixVersion != "" {
+		t.Fatal("the fix version should be cleared on reopen")
+	}
+
+	ids, err = bug.ListFixedInVersion(repo, "1.1")
+	checkErr(t, err)
+	if len(ids) != 1 || ids[0] != bug1.Id() {
+		t.Fatalf("unexpected bugs fixed in 1.1: %v", ids)
+	}
+}
+
+func TestCommentLengthLimit(t *testing.T) {
+	defer bug.SetCommentLengthLimit(0, false)
+
+	long := strings.Repeat("a", 101)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	// strict, the comment is rejected
+	bug.SetCommentLengthLimit(100, true)
+
+	err = operations.Comment(bug1, rene, long)
+	if !errors.Is(err, bug.ErrCommentTooLong) {
+		t.Fatalf("the comment should be rejected, got %v", err)
+	}
+	if len(bug1.Compile().Comments) != 1 {
+		t.Fatal("the comment should not be staged")
+	}
+
+	_, err = operations.Create(rene, "bug2", long)
+	if !errors.Is(err, bug.ErrCommentTooLong) {
+		t.Fatalf("the bug should be rejected, got %v", err)
+	}
+
+	err = operations.Comment(bug1, rene, strings.Repeat("a", 100))
+	checkErr(t, err)
+
+	// lenient, the comment is allowed with a warning
+	bug.SetCommentLengthLimit(100, false)
+
+	err = operations.Comment(bug1, rene, long)
+	checkErr(t, err)
+
+	snap := bug1.Compile()
+	if len(snap.Comments) != 3 {
+		t.Fatal("the comment should be staged")
+	}
+	if len(snap.Warnings) != 1 || !strings.Contains(snap.Warnings[0], "comment too long") {
+		t.Fatalf("a warning should be reported, got %v", snap.Warnings)
+	}
+}
+
+// run with the race detector
+func TestCommentLengthLimitConcurrent(t *testing.T) {
+	defer bug.SetCommentLengthLimit(0, false)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			bug.SetCommentLengthLimit(1000+i, i%2 == 0)
+		}
+	}()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	for i := 0; i < 10; i++ {
+		checkErr(t, operations.Comment(bug1, rene, "comment"))
+		bug1.Compile()
+	}
+
+	<-done
+}
+
+func TestOperationId(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repo))
+	checkErr(t, operations.Comment(bug1, rene, "comment"))
+	operations.SetTitle(bug1, rene, "title2")
+	checkErr(t, bug1.Commit(repo))
+	checkErr(t, operations.Comment(bug1, rene, "staged"))
+
+	snap := bug1.Compile()
+	ids := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		id := snap.OperationId(i)
+		if id == "" || ids[id] {
+			t.Fatalf("operation %d should have a unique id, got \"%s\"", i, id)
+		}
+		ids[id] = true
+
+		if !reflect.DeepEqual(bug1.OperationById(id), snap.Operations[i]) {
+			t.Fatalf("operation %d should be found by its id", i)
+		}
+	}
+	if snap.OperationId(3) != "" {
+		t.Fatal("a staged operation should not have an id")
+	}
+
+	// stable across reads
+	read, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	readSnap := read.Compile()
+	timeline, err := read.Timeline(repo)
+	checkErr(t, err)
+	for i := 0; i < 3; i++ {
+		if readSnap.OperationId(i) != snap.OperationId(i) || timeline[i].Id != snap.OperationId(i) {
+			t.Fatalf("the id of operation %d should be stable", i)
+		}
+	}
+
+	if bug1.OperationById("invalid") != nil {
+		t.Fatal("an invalid id should not match")
+	}
+	commit, index, err := bug.ParseOperationId(snap.OperationId(2))
+	checkErr(t, err)
+	if bug1.OperationById(bug.OperationId(commit, index+1)) != nil {
+		t.Fatal("an index out of the pack should not match")
+	}
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestCommitDefaultAuthor(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(bug.Person{}, "bug1", "message")
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "explicit author")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	snap := bug2.Compile()
+
+	name, email, err := repo.GetUserIdentity()
+	checkErr(t, err)
+
+	if snap.Author.Name != name || snap.Author.Email != email {
+		t.Fatalf("unexpected default author: %v", snap.Author)
+	}
+
+	if snap.Comments[1].Author != rene {
+		t.Fatal("an explicit author should be kept")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+	"github.com/MichaelMure/git-bug/util"
+)
+
+func TestListPrunableBugObjects(t *testing.T) {
+	gitRepo := createRepo(false)
+	defer cleanupRepo(gitRepo)
+
+	repos := map[string]repository.Repo{
+		"git":  gitRepo,
+		"mock": repository.NewMockRepoForTest(),
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			testListPrunableBugObjects(t, repo)
+		})
+	}
+}
+
+func testListPrunableBugObjects(t *testing.T, repo repository.Repo) {
+	kept, err := operations.Create(rene, "kept", "message")
+	checkErr(t, err)
+	err = kept.Commit(repo)
+	checkErr(t, err)
+
+	removed, err := operations.Create(rene, "removed", "another message")
+	checkErr(t, err)
+	err = removed.Commit(repo)
+	checkErr(t, err)
+
+	created, err := repo.ResolveRef("refs/bugs/" + removed.Id())
+	checkErr(t, err)
+
+	// a touch commit has no ops entry
+	err = removed.Touch(repo)
+	checkErr(t, err)
+
+	head, err := repo.ResolveRef("refs/bugs/" + removed.Id())
+	checkErr(t, err)
+
+	// an unreachable object that is not part of a bug
+	foreign, err := repo.StoreData([]byte("not a bug"))
+	checkErr(t, err)
+
+	prunable, err := bug.ListPrunableBugObjects(repo)
+	checkErr(t, err)
+	if len(prunable) != 0 {
+		t.Fatalf("nothing should be prunable yet, got %v", prunable)
+	}
+
+	err = bug.RemoveLocalBug(repo, removed.Id(), false)
+	checkErr(t, err)
+
+	prunable, err = bug.ListPrunableBugObjects(repo)
+	checkErr(t, err)
+	if !containsHash(prunable, head) || !containsHash(prunable, created) {
+		t.Fatalf("the commits of the removed bug should be prunable, got %v", prunable)
+	}
+	if containsHash(prunable, foreign) {
+		t.Fatal("only the bug objects should be listed")
+	}
+
+	// nothing is deleted
+	exist, err := repo.CommitExist(head)
+	checkErr(t, err)
+	if !exist {
+		t.Fatal("the commit should still exist")
+	}
+
+	// still found once no reference is left at all
+	err = bug.RemoveLocalBug(repo, kept.Id(), false)
+	checkErr(t, err)
+
+	prunable, err = bug.ListPrunableBugObjects(repo)
+	checkErr(t, err)
+	if !containsHash(prunable, head) {
+		t.Fatalf("the commit of the removed bug should still be prunable, got %v", prunable)
+	}
+}
+
+func containsHash(hashes []util.Hash, hash util.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
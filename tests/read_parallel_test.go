@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func createBugs(t testing.TB, repo repository.Repo, count int) []string {
+	ids := make([]string, count)
+
+	for i := range ids {
+		b, err := operations.Create(rene, fmt.Sprintf("bug%d", i), "message")
+		checkErr(t, err)
+		checkErr(t, operations.Comment(b, rene, "comment"))
+		checkErr(t, b.Commit(repo))
+		ids[i] = b.Id()
+	}
+
+	return ids
+}
+
+func TestReadAllLocalBugsParallel(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	ids := createBugs(t, repo, 20)
+	sort.Strings(ids)
+
+	for _, workers := range []int{0, 1, 4, 50} {
+		var read []string
+
+		for streamed := range bug.ReadAllLocalBugsParallel(repo, workers) {
+			checkErr(t, streamed.Err)
+			read = append(read, streamed.Bug.Id())
+		}
+
+		sort.Strings(read)
+		if fmt.Sprint(read) != fmt.Sprint(ids) {
+			t.Fatalf("%d workers: every bug should be read once, got %v", workers, read)
+		}
+	}
+
+	// a broken bug end the stream with its error
+	checkErr(t, repo.UpdateRef("refs/bugs/0123456789abcdef0123456789abcdef01234567", "0123456789abcdef0123456789abcdef01234567"))
+
+	var errs int
+	var afterError bool
+	for streamed := range bug.ReadAllLocalBugsParallel(repo, 4) {
+		if errs > 0 {
+			afterError = true
+		}
+		if streamed.Err != nil {
+			errs++
+		}
+	}
+
+	if errs != 1 || afterError {
+		t.Fatalf("expected a single error ending the stream, got %d errors", errs)
+	}
+}
+
+func benchmarkReadAllLocalBugs(b *testing.B, read func(repo repository.Repo) <-chan bug.StreamedBug) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	createBugs(b, repo, 50)
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		for streamed := range read(repo) {
+			checkErr(b, streamed.Err)
+		}
+	}
+}
+
+func BenchmarkReadAllLocalBugs(b *testing.B) {
+	benchmarkReadAllLocalBugs(b, bug.ReadAllLocalBugs)
+}
+
+func BenchmarkReadAllLocalBugsParallel(b *testing.B) {
+	benchmarkReadAllLocalBugs(b, func(repo repository.Repo) <-chan bug.StreamedBug {
+		return bug.ReadAllLocalBugsParallel(repo, runtime.NumCPU())
+	})
+}
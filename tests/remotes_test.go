@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestGitRemotes(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	remotes, err := repoA.Remotes()
+	checkErr(t, err)
+
+	if len(remotes) != 1 || remotes["origin"] != "file://"+remote.GetPath() {
+		t.Fatalf("unexpected remotes: %v", remotes)
+	}
+
+	remotes, err = remote.Remotes()
+	checkErr(t, err)
+
+	if len(remotes) != 0 {
+		t.Fatalf("unexpected remotes: %v", remotes)
+	}
+	// a local path can contain spaces
+	err = repoA.AddRemote("local", "/home/me/My Repos/x")
+	checkErr(t, err)
+
+	remotes, err = repoA.Remotes()
+	checkErr(t, err)
+
+	if len(remotes) != 2 || remotes["local"] != "/home/me/My Repos/x" {
+		t.Fatalf("unexpected remotes: %v", remotes)
+	}
+}
+
+func TestRenameRemoteBugs(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repoA)
+	checkErr(t, err)
+
+	_, err = bug.Push(repoA, "origin")
+	checkErr(t, err)
+	_, err = bug.Fetch(repoB, "origin")
+	checkErr(t, err)
+
+	err = bug.RenameRemoteBugs(repoB, "origin", "upstream")
+	checkErr(t, err)
+
+	bugs := allBugs(t, bug.ReadAllRemoteBugs(repoB, "upstream"))
+	if len(bugs) != 1 || bugs[0].Id() != bug1.Id() {
+		t.Fatal("the bug should be readable under the new remote name")
+	}
+
+	bugs = allBugs(t, bug.ReadAllRemoteBugs(repoB, "origin"))
+	if len(bugs) != 0 {
+		t.Fatal("the old remote refs should be removed")
+	}
+
+	// refuse to overwrite
+	_, err = bug.Fetch(repoB, "origin")
+	checkErr(t, err)
+	err = bug.RenameRemoteBugs(repoB, "origin", "upstream")
+	if err == nil {
+		t.Fatal("renaming onto a remote with bugs should fail")
+	}
+}
+
+func TestReadAllRemoteBugsAllRemotes(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	// store a bug as if fetched from a remote
+	fetched := func(remote string, title string) string {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		err = b.Commit(repo)
+		checkErr(t, err)
+		err = repo.CopyRef("refs/bugs/"+b.Id(), "refs/remotes/"+remote+"/bugs/"+b.Id())
+		checkErr(t, err)
+		err = repo.RemoveRef("refs/bugs/" + b.Id())
+		checkErr(t, err)
+		return b.Id()
+	}
+
+	for _, remote := range []string{"origin", "upstream", "broken"} {
+		checkErr(t, repo.AddRemote(remote, "https://example.com/"+remote))
+	}
+
+	originBug := fetched("origin", "bug1")
+	upstreamBug1 := fetched("upstream", "bug2")
+	upstreamBug2 := fetched("upstream", "bug3")
+
+	// a bug with an invalid id can't be read
+	head, err := repo.ResolveRef("refs/remotes/origin/bugs/" + originBug)
+	checkErr(t, err)
+	err = repo.UpdateRef("refs/remotes/broken/bugs/invalid", head)
+	checkErr(t, err)
+
+	found := make(map[string]string)
+	var errs []error
+
+	for streamed := range bug.ReadAllRemoteBugsAllRemotes(repo) {
+		if streamed.Err != nil {
+			errs = append(errs, streamed.Err)
+			continue
+		}
+		found[streamed.Bug.Id()] = streamed.Remote
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("expected a single error, got %v", errs)
+	}
+
+	expected := map[string]string{
+		originBug:    "origin",
+		upstreamBug1: "upstream",
+		upstreamBug2: "upstream",
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("unexpected bugs: %v", found)
+	}
+	for id, remote := range expected {
+		if found[id] != remote {
+			t.Fatalf("bug %s should come from %s, got %s", id, remote, found[id])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestSearch(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	crash, err := operations.Create(rene, "Crash on startup", "it crashes")
+	checkErr(t, err)
+	checkErr(t, operations.ChangeLabels(nil, crash, rene, []string{"bug"}, nil))
+	checkErr(t, crash.Commit(repo))
+
+	typo, err := operations.Create(rene, "Typo in the README", "message")
+	checkErr(t, err)
+	checkErr(t, operations.Comment(typo, rene, "Also a CRASH of the doc build"))
+	operations.Close(typo, rene)
+	checkErr(t, typo.Commit(repo))
+
+	feature, err := operations.Create(rene, "Dark mode", "please")
+	checkErr(t, err)
+	checkErr(t, operations.ChangeLabels(nil, feature, rene, []string{"enhancement"}, nil))
+	checkErr(t, feature.Commit(repo))
+
+	cases := map[string][]string{
+		"crash":                   {crash.Id(), typo.Id()},
+		"README":                  {typo.Id()},
+		"status:open":             {crash.Id(), feature.Id()},
+		"status:closed crash":     {typo.Id()},
+		"label:bug":               {crash.Id()},
+		"label:bug label:missing": nil,
+		"status:in-progress":      nil,
+		"nothing like this":       nil,
+	}
+
+	for query, expected := range cases {
+		snaps, err := bug.Search(repo, query)
+		checkErr(t, err)
+
+		var ids []string
+		for _, snap := range snaps {
+			ids = append(ids, snap.Id())
+		}
+		sort.Strings(ids)
+		sort.Strings(expected)
+
+		if len(ids) != len(expected) {
+			t.Fatalf("query %q: expected %v, got %v", query, expected, ids)
+		}
+		for i := range ids {
+			if ids[i] != expected[i] {
+				t.Fatalf("query %q: expected %v, got %v", query, expected, ids)
+			}
+		}
+	}
+
+	// an unreadable bug is skipped
+	checkErr(t, repo.UpdateRef("refs/bugs/0123456789012345678901234567890123456789", "0123456789012345678901234567890123456789"))
+
+	snaps, err := bug.Search(repo, "crash")
+	searchErr, ok := err.(*bug.SearchError)
+	if !ok || len(searchErr.Failed) != 1 {
+		t.Fatalf("expected the unreadable bug to be reported, got %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("the readable bugs should still be searched, got %d", len(snaps))
+	}
+
+	_, err = bug.Search(repo, "status:unknown")
+	if err == nil {
+		t.Fatal("an unknown status should be rejected")
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestSnapshotCache(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	var ids []string
+	for _, title := range []string{"bug1", "bug2", "bug3"} {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		checkErr(t, b.Commit(repo))
+		ids = append(ids, b.Id())
+	}
+
+	cache := bug.NewSnapshotCache(2)
+
+	snap, err := cache.Get(repo, ids[0])
+	checkErr(t, err)
+	if snap.Title != "bug1" {
+		t.Fatalf("unexpected title %s", snap.Title)
+	}
+	_, err = cache.Get(repo, ids[0])
+	checkErr(t, err)
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// the least recently used is evicted
+	_, err = cache.Get(repo, ids[1])
+	checkErr(t, err)
+	_, err = cache.Get(repo, ids[2])
+	checkErr(t, err)
+	if cache.Len() != 2 || cache.Stats().Evictions != 1 {
+		t.Fatalf("unexpected size %d and stats %+v", cache.Len(), cache.Stats())
+	}
+	_, err = cache.Get(repo, ids[0])
+	checkErr(t, err)
+	if cache.Stats().Misses != 4 {
+		t.Fatalf("the evicted bug should be compiled again, got %+v", cache.Stats())
+	}
+}
+
+func TestSnapshotCacheConcurrent(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	var bugs []*bug.Bug
+	for _, title := range []string{"bug1", "bug2", "bug3"} {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		checkErr(t, b.Commit(repo))
+		bugs = append(bugs, b)
+	}
+
+	cache := bug.NewSnapshotCache(10)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				for _, b := range bugs {
+					if _, err := cache.Get(repo, b.Id()); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	// a commit in the middle invalidate the entry of its bug
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := operations.Comment(bugs[0], rene, "new comment"); err != nil {
+			errs <- err
+			return
+		}
+		if err := bugs[0].Commit(repo); err != nil {
+			errs <- err
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	snap, err := cache.Get(repo, bugs[0].Id())
+	checkErr(t, err)
+	if len(snap.Comments) != 2 {
+		t.Fatalf("the cache should have seen the new commit, got %d comments", len(snap.Comments))
+	}
+
+	stats := cache.Stats()
+	if stats.Hits == 0 || stats.Misses < 4 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestSnapshotAge(t *testing.T) {
+	now := time.Date(2018, 8, 20, 12, 0, 0, 0, time.UTC)
+
+	create := operations.NewCreateOp(rene, "title", "message", nil)
+	create.UnixTime = now.Add(-10 * 24 * time.Hour).Unix()
+
+	comment := operations.NewAddCommentOp(rene, "message2", nil)
+	comment.UnixTime = now.Add(-3 * 24 * time.Hour).Unix()
+
+	bug1 := bug.NewBug()
+	bug1.Append(create)
+	bug1.Append(comment)
+
+	snap := bug1.Compile()
+
+	if snap.Age(now) != 10*24*time.Hour {
+		t.Fatalf("unexpected age: %v", snap.Age(now))
+	}
+
+	if snap.InactiveFor(now) != 3*24*time.Hour {
+		t.Fatalf("unexpected inactivity: %v", snap.InactiveFor(now))
+	}
+}
+
+func TestSnapshotCommentTree(t *testing.T) {
+	bug1, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "question")
+	question := bug1.Compile().Comments[1].Id
+
+	operations.Reply(bug1, rene, question, "answer")
+	answer := bug1.Compile().Comments[2].Id
+
+	operations.Reply(bug1, rene, answer, "thanks")
+	operations.Reply(bug1, rene, "0123456789012345678901234567890123456789", "orphan")
+
+	snap := bug1.Compile()
+
+	if len(snap.Comments) != 5 {
+		t.Fatal("the flat list of comments should be preserved")
+	}
+
+	// message, question and orphan at the top-level
+	if len(snap.CommentTree) != 3 {
+		t.Fatalf("unexpected number of top-level comments: %d", len(snap.CommentTree))
+	}
+
+	questionNode := snap.CommentTree[1]
+	if questionNode.Comment.Message != "question" || len(questionNode.Replies) != 1 {
+		t.Fatal("the question should have one reply")
+	}
+
+	answerNode := questionNode.Replies[0]
+	if answerNode.Comment.Message != "answer" || len(answerNode.Replies) != 1 {
+		t.Fatal("the answer should have one reply")
+	}
+
+	if answerNode.Replies[0].Comment.Message != "thanks" {
+		t.Fatal("unexpected second level reply")
+	}
+
+	if snap.CommentTree[2].Comment.Message != "orphan" {
+		t.Fatal("a reply to an unknown comment should fall back to the top-level")
+	}
+
+	if len(snap.Warnings) != 1 {
+		t.Fatalf("unexpected number of warnings: %d", len(snap.Warnings))
+	}
+}
+
+func TestSnapshotCounts(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "comment1")
+	operations.Close(bug1, rene)
+	operations.Comment(bug1, rene, "comment2")
+	err = operations.ChangeLabels(ioutil.Discard, bug1, rene, []string{"bug"}, nil)
+	checkErr(t, err)
+	operations.SetTitle(bug1, rene, "new title")
+	err = operations.ChangeLabels(ioutil.Discard, bug1, rene, nil, []string{"bug"})
+	checkErr(t, err)
+	operations.Open(bug1, rene)
+	operations.Comment(bug1, rene, "comment3")
+
+	snap := bug1.Compile()
+
+	if snap.CommentCount() != 3 {
+		t.Fatalf("unexpected comment count: %d", snap.CommentCount())
+	}
+	if snap.LabelChangeCount() != 2 {
+		t.Fatalf("unexpected label change count: %d", snap.LabelChangeCount())
+	}
+	if snap.StatusChangeCount() != 2 {
+		t.Fatalf("unexpected status change count: %d", snap.StatusChangeCount())
+	}
+	if snap.TitleChangeCount() != 1 {
+		t.Fatalf("unexpected title change count: %d", snap.TitleChangeCount())
+	}
+}
+
+func TestActivityScore(t *testing.T) {
+	now := time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	makeBug := func(lastActivity time.Time, comments int) bug.Snapshot {
+		b := bug.NewBug()
+		create := operations.NewCreateOp(rene, "title", "message", nil)
+		create.UnixTime = lastActivity.Add(-time.Hour).Unix()
+		b.Append(create)
+		for i := 0; i < comments; i++ {
+			comment := operations.NewAddCommentOp(rene, "comment", nil)
+			comment.UnixTime = lastActivity.Unix()
+			b.Append(comment)
+		}
+		return b.Compile()
+	}
+
+	// a dormant bug with a lot of past activity
+	dormant := makeBug(now.Add(-90*24*time.Hour), 20)
+	// a recent bug with a few comments
+	recent := makeBug(now.Add(-2*time.Hour), 3)
+
+	dormantScore := bug.ActivityScore(dormant, now)
+	recentScore := bug.ActivityScore(recent, now)
+
+	if recentScore <= dormantScore {
+		t.Fatalf("the recent bug should score higher: %f <= %f", recentScore, dormantScore)
+	}
+
+	// deterministic for a given time
+	if bug.ActivityScore(recent, now) != recentScore {
+		t.Fatal("the score should be deterministic")
+	}
+
+	// an operation loses half its weight after a half-life
+	single := makeBug(now.Add(time.Hour), 0)
+	fresh := bug.ActivityScore(single, now)
+	halved := bug.ActivityScore(single, now.Add(bug.ActivityHalfLife))
+	if math.Abs(halved-fresh/2) > 1e-9 {
+		t.Fatalf("unexpected decay: %f then %f", fresh, halved)
+	}
+}
+
+func TestCompileUntil(t *testing.T) {
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+	operations.Comment(b, rene, "comment")
+	err = operations.ChangeLabels(nil, b, rene, []string{"bug"}, nil)
+	checkErr(t, err)
+	operations.Comment(b, rene, "comment")
+	operations.Close(b, rene)
+
+	hasLabel := func(label bug.Label) func(bug.Snapshot) bool {
+		return func(snap bug.Snapshot) bool {
+			for _, l := range snap.Labels {
+				if l == label {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	snap, ok := bug.CompileUntil(b, hasLabel("bug"))
+	if !ok {
+		t.Fatal("the predicate should match")
+	}
+	if len(snap.Operations) != 3 {
+		t.Fatalf("the replay should stop at the label change, %d operations replayed", len(snap.Operations))
+	}
+	if snap.Status != bug.OpenStatus {
+		t.Fatal("the later operations should not be applied")
+	}
+
+	snap, ok = bug.CompileUntil(b, hasLabel("ui"))
+	if ok {
+		t.Fatal("the predicate should not match")
+	}
+	if len(snap.Operations) != 5 || snap.Status != bug.ClosedStatus {
+		t.Fatal("every operation should be replayed without a match")
+	}
+}
+
+func TestCompileStream(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	operations.SetTitle(bug1, rene, "title2")
+	checkErr(t, operations.Comment(bug1, rene, "comment"))
+	operations.Close(bug1, rene)
+
+	var deltas []bug.SnapshotDelta
+	for delta := range bug1.CompileStream(context.Background()) {
+		deltas = append(deltas, delta)
+	}
+
+	if len(deltas) != 5 {
+		t.Fatalf("expected 5 steps, got %d", len(deltas))
+	}
+	if deltas[1].Snapshot.Title != "title2" || deltas[1].Snapshot.TitleChangeCount() != 1 {
+		t.Fatal("the second step should have the new title")
+	}
+	if deltas[0].Snapshot.TitleChangeCount() != 0 {
+		t.Fatal("a step should not be altered by the following ones")
+	}
+
+	final := deltas[4]
+	if !final.Final || final.Operation != nil {
+		t.Fatal("the last step should be final")
+	}
+	if !reflect.DeepEqual(final.Snapshot, bug1.Compile()) {
+		t.Fatal("the final snapshot should be identical to the compiled one")
+	}
+
+	// stop early
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := bug1.CompileStream(ctx)
+	<-stream
+	cancel()
+
+	count := 0
+	for range stream {
+		count++
+	}
+	if count > 1 {
+		t.Fatalf("the stream should stop once cancelled, got %d more steps", count)
+	}
+}
+
+func TestTitleHistory(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	bug1, err := operations.Create(rene, "title1", "message")
+	checkErr(t, err)
+	operations.SetTitle(bug1, rene, "title2")
+	checkErr(t, bug1.Commit(repo))
+	operations.SetTitle(bug1, blaise, "title3")
+
+	snap := bug1.Compile()
+	if snap.Title != "title3" {
+		t.Fatal("the title should be the last one")
+	}
+
+	history := snap.TitleHistory
+	if len(history) != 2 {
+		t.Fatalf("expected 2 title edits, got %d", len(history))
+	}
+	if history[0].Old != "title1" || history[0].New != "title2" || history[0].Author != rene || history[0].EditTime == 0 {
+		t.Fatalf("unexpected first edit %+v", history[0])
+	}
+	if history[1].Old != "title2" || history[1].New != "title3" || history[1].Author != blaise || history[1].EditTime != 0 {
+		t.Fatalf("unexpected second edit %+v", history[1])
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestCanonicalOrder(t *testing.T) {
+	repoA, repoB, remote := setupRepos(t)
+	defer cleanupRepos(repoA, repoB, remote)
+
+	// created concurrently, both bugs have the same create time
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repoA)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "bug2", "message")
+	checkErr(t, err)
+	err = bug2.Commit(repoB)
+	checkErr(t, err)
+
+	_, err = bug.Push(repoB, "origin")
+	checkErr(t, err)
+	err = bug.Pull(repoA, os.Stdout, "origin")
+	checkErr(t, err)
+
+	read1, err := bug.ReadLocalBug(repoA, bug1.Id())
+	checkErr(t, err)
+	read2, err := bug.ReadLocalBug(repoA, bug2.Id())
+	checkErr(t, err)
+
+	first, second := read1, read2
+	if read2.Id() < read1.Id() {
+		first, second = read2, read1
+	}
+
+	for _, bugs := range []bug.BugsByCanonicalOrder{{read1, read2}, {read2, read1}} {
+		sort.Sort(bugs)
+		if bugs[0] != first || bugs[1] != second {
+			t.Fatal("the order of bugs with the same create time should not depend on the input")
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		ids, err := bug.SortedLocalIds(repoA)
+		checkErr(t, err)
+
+		if len(ids) != 2 || ids[0] != first.Id() || ids[1] != second.Id() {
+			t.Fatalf("unexpected order %v", ids)
+		}
+	}
+}
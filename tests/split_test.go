@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestSplitBug(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "two issues", "the first issue")
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "a second issue\nwith more details")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := operations.SplitBug(repo, bug1.Id(), 1)
+	checkErr(t, err)
+
+	original, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	origSnap := original.Compile()
+
+	split, err := bug.ReadLocalBug(repo, bug2.Id())
+	checkErr(t, err)
+	splitSnap := split.Compile()
+
+	if splitSnap.Title != "a second issue" {
+		t.Fatalf("unexpected title: %s", splitSnap.Title)
+	}
+
+	if splitSnap.Comments[0].Message != "a second issue\nwith more details" {
+		t.Fatal("the new bug should be seeded with the comment")
+	}
+
+	if len(origSnap.Comments) != 2 {
+		t.Fatal("the original bug should keep its history")
+	}
+
+	if len(origSnap.Links) != 1 || origSnap.Links[0] != bug2.Id() {
+		t.Fatal("the original bug should reference the new one")
+	}
+
+	if len(splitSnap.Links) != 1 || splitSnap.Links[0] != bug1.Id() {
+		t.Fatal("the new bug should reference the original one")
+	}
+
+	_, err = operations.SplitBug(repo, bug1.Id(), 5)
+	if err == nil {
+		t.Fatal("an invalid comment index should fail")
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestRecoverStaging(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	operations.Comment(bug1, rene, "message2")
+	operations.Comment(bug1, rene, "message3")
+	err = bug1.PersistStaging(repo)
+	checkErr(t, err)
+
+	// simulate a crash by dropping the in-memory bug
+	id := bug1.Id()
+	bug1 = nil
+
+	bug2, err := bug.RecoverStaging(repo, id)
+	checkErr(t, err)
+
+	if !bug2.HasPendingOp() {
+		t.Fatal("staging area should have been recovered")
+	}
+
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	bug3, err := bug.ReadLocalBug(repo, id)
+	checkErr(t, err)
+
+	if nbOps(bug3) != 3 {
+		t.Fatal("Unexpected number of operations")
+	}
+
+	// once committed, the persisted staging area is gone
+	bug4, err := bug.RecoverStaging(repo, id)
+	checkErr(t, err)
+
+	if bug4.HasPendingOp() {
+		t.Fatal("staging area should have been cleared by the commit")
+	}
+}
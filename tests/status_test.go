@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	steps := []struct {
+		apply    func(b *bug.Bug, author bug.Person)
+		expected bug.Status
+	}{
+		{operations.StartProgress, bug.InProgressStatus},
+		{operations.Close, bug.ClosedStatus},
+		{operations.StartProgress, bug.InProgressStatus},
+		{operations.Open, bug.OpenStatus},
+		{operations.Close, bug.ClosedStatus},
+		{operations.Open, bug.OpenStatus},
+	}
+
+	for i, step := range steps {
+		step.apply(bug1, rene)
+
+		status := bug1.Compile().Status
+		if status != step.expected {
+			t.Fatalf("step %d: expected %s, got %s", i, step.expected, status)
+		}
+	}
+
+	err = bug1.Commit(mockRepo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(mockRepo, bug1.Id())
+	checkErr(t, err)
+
+	if bug2.Compile().Status != bug.OpenStatus {
+		t.Fatal("the status should survive the storage")
+	}
+}
+
+func TestStatusHelpers(t *testing.T) {
+	cases := []struct {
+		status bug.Status
+		open   bool
+		closed bool
+	}{
+		{bug.OpenStatus, true, false},
+		{bug.InProgressStatus, true, false},
+		{bug.ClosedStatus, false, true},
+	}
+
+	for _, c := range cases {
+		if c.status.IsOpen() != c.open {
+			t.Fatalf("%s: unexpected IsOpen", c.status)
+		}
+		if c.status.IsClosed() != c.closed {
+			t.Fatalf("%s: unexpected IsClosed", c.status)
+		}
+	}
+}
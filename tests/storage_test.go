@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+// Run the full lifecycle of a bug against the in-memory storage, to make
+// sure that the bug logic doesn't depend on git itself
+func TestBugLifecycleMemoryStorage(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	root, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	// someone else add a comment, that we fetch
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	operations.Comment(bug2, rene, "remote comment")
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+	err = repo.CopyRef(localRef, remoteRef)
+	checkErr(t, err)
+
+	// meanwhile, we add a comment locally
+	err = repo.UpdateRef(localRef, root)
+	checkErr(t, err)
+	operations.Comment(bug1, rene, "local comment")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	for merge := range bug.MergeAll(repo, "origin") {
+		checkErr(t, merge.Err)
+
+		if merge.Status != bug.MsgMergeUpdated {
+			t.Fatalf("unexpected merge status: %s", merge.Status)
+		}
+	}
+
+	bug3, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if !bug3.IsValid() {
+		t.Fatal("merged bug should be valid")
+	}
+
+	snap := bug3.Compile()
+
+	if len(snap.Comments) != 3 {
+		t.Fatalf("unexpected number of comments: %d", len(snap.Comments))
+	}
+
+	if snap.Comments[1].Message != "remote comment" || snap.Comments[2].Message != "local comment" {
+		t.Fatal("local operations should be rebased on top of the remote ones")
+	}
+}
+
+func TestMergeDivergentRoot(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := operations.Create(rene, "another bug", "another message")
+	checkErr(t, err)
+	err = bug2.Commit(repo)
+	checkErr(t, err)
+
+	// a remote bug with the same id but a different creation
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+	err = repo.CopyRef("refs/bugs/"+bug2.Id(), remoteRef)
+	checkErr(t, err)
+
+	remoteBug, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	localBug, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	_, err = localBug.Merge(repo, remoteBug)
+	if err != bug.ErrDivergentRoot {
+		t.Fatalf("expected ErrDivergentRoot, got %v", err)
+	}
+
+	_, err = localBug.MergeDAG(repo, remoteBug)
+	if err != bug.ErrDivergentRoot {
+		t.Fatalf("expected ErrDivergentRoot, got %v", err)
+	}
+}
+
+func TestMergeUnsupportedFormat(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	localRef := "refs/bugs/" + bug1.Id()
+	remoteRef := "refs/remotes/origin/bugs/" + bug1.Id()
+
+	// the remote get a commit from a newer client
+	head, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+	entries, err := repo.ListEntries(head)
+	checkErr(t, err)
+
+	newer, err := repo.StoreData([]byte("\x00gbp\x02\x00some future format"))
+	checkErr(t, err)
+
+	var tree []repository.TreeEntry
+	for _, entry := range entries {
+		if entry.Name == "ops" {
+			entry.Hash = newer
+		}
+		if strings.HasPrefix(entry.Name, "create-clock") {
+			continue
+		}
+		tree = append(tree, entry)
+	}
+
+	treeHash, err := repo.StoreTree(tree)
+	checkErr(t, err)
+	commit, err := repo.StoreCommitWithParent(treeHash, head)
+	checkErr(t, err)
+	err = repo.UpdateRef(remoteRef, commit)
+	checkErr(t, err)
+
+	// meanwhile, a local change
+	operations.Comment(bug1, rene, "local comment")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	// the remote bug can still be inspected
+	remoteBug, err := bug.ReadRemoteBug(repo, "origin", bug1.Id())
+	checkErr(t, err)
+
+	if !remoteBug.ReadOnly() {
+		t.Fatal("the remote bug should be read-only")
+	}
+
+	if remoteBug.Compile().Title != "bug1" {
+		t.Fatal("the supported parts should be readable")
+	}
+
+	localBug, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	before, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+
+	_, err = localBug.Merge(repo, remoteBug)
+	if err != bug.ErrUnsupportedFormatVersion {
+		t.Fatalf("expected ErrUnsupportedFormatVersion, got %v", err)
+	}
+
+	// the local bug is untouched
+	after, err := repo.ResolveRef(localRef)
+	checkErr(t, err)
+	if after != before {
+		t.Fatal("the local bug should not be modified")
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestCommentThumbnails(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		img.Set(x, x%200, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	checkErr(t, png.Encode(&buf, img))
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	attachments := [][]byte{buf.Bytes(), []byte("not an image")}
+	err = operations.CommentWithAttachments(repo, bug1, rene, "screenshot", attachments, true)
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	bug2, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+	comment := bug2.Compile().Comments[1]
+
+	if len(comment.Thumbnails) != 1 {
+		t.Fatalf("expected a single thumbnail, got %d", len(comment.Thumbnails))
+	}
+
+	imageHash, err := repo.StoreData(buf.Bytes())
+	checkErr(t, err)
+	thumbHash, ok := comment.Thumbnails[imageHash]
+	if !ok {
+		t.Fatal("the image should have a thumbnail")
+	}
+
+	// the thumbnail is referenced in the media tree
+	head, err := repo.ResolveRef("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+	tree, err := repo.GetTreeHash(head)
+	checkErr(t, err)
+	entries, err := repo.ListEntries(tree)
+	checkErr(t, err)
+
+	referenced := false
+	for _, entry := range entries {
+		if entry.Name != "media" {
+			continue
+		}
+		media, err := repo.ListEntries(entry.Hash)
+		checkErr(t, err)
+		for _, file := range media {
+			if file.Hash == thumbHash {
+				referenced = true
+			}
+		}
+	}
+	if !referenced {
+		t.Fatal("the thumbnail should be referenced in the media tree")
+	}
+
+	// and readable
+	data, err := repo.ReadData(thumbHash)
+	checkErr(t, err)
+	thumb, err := png.Decode(bytes.NewReader(data))
+	checkErr(t, err)
+	if thumb.Bounds().Dx() != bug.ThumbnailSize || thumb.Bounds().Dy() != bug.ThumbnailSize/2 {
+		t.Fatalf("unexpected thumbnail size: %v", thumb.Bounds())
+	}
+}
+
+func TestCommentWithoutThumbnails(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+	checkErr(t, png.Encode(&buf, img))
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+
+	err = operations.CommentWithAttachments(repo, bug1, rene, "screenshot", [][]byte{buf.Bytes()}, false)
+	checkErr(t, err)
+
+	if len(bug1.Compile().Comments[1].Thumbnails) != 0 {
+		t.Fatal("no thumbnail should be generated when not requested")
+	}
+}
+
+func TestThumbnailPixelBomb(t *testing.T) {
+	var buf bytes.Buffer
+	checkErr(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+
+	// declare a huge image in the IHDR chunk, following the signature
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], 100000)
+	binary.BigEndian.PutUint32(data[20:24], 100000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	_, ok, err := bug.MakeThumbnail(data)
+	checkErr(t, err)
+	if ok {
+		t.Fatal("a huge image should not be decoded")
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+)
+
+func TestTimeline(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	other := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	err = operations.Comment(b, other, "comment")
+	checkErr(t, err)
+	operations.Close(b, rene)
+	err = b.Commit(repo)
+	checkErr(t, err)
+
+	// not committed yet
+	operations.SetTitle(b, other, "title2")
+
+	timeline, err := b.Timeline(repo)
+	checkErr(t, err)
+
+	snap := b.Compile()
+
+	if len(timeline) != len(snap.Operations) {
+		t.Fatalf("expected %d items, got %d", len(snap.Operations), len(timeline))
+	}
+
+	expectedAuthors := []bug.Person{rene, other, rene, other}
+
+	for i, item := range timeline {
+		if !reflect.DeepEqual(item.Operation, snap.Operations[i]) {
+			t.Fatalf("item %d is not in the compile order", i)
+		}
+		if item.Author != expectedAuthors[i] {
+			t.Fatalf("unexpected author of item %d: %v", i, item.Author)
+		}
+		if item.EditTime != snap.OperationEditTime(i) {
+			t.Fatalf("unexpected edit time of item %d", i)
+		}
+	}
+
+	for i, item := range timeline[:3] {
+		if item.CommitTime.IsZero() || item.EditTime == 0 {
+			t.Fatalf("the committed item %d should have a commit time", i)
+		}
+	}
+
+	if timeline[1].CommitTime != timeline[2].CommitTime {
+		t.Fatal("the operations of the same commit should have the same commit time")
+	}
+
+	if !timeline[3].CommitTime.IsZero() || timeline[3].EditTime != 0 {
+		t.Fatal("a staged operation should have no commit time")
+	}
+}
+
+func TestTimelineNoOp(t *testing.T) {
+	b, err := operations.Create(rene, "title", "message")
+	checkErr(t, err)
+
+	operations.SetTitle(b, rene, "title")
+	operations.SetTitle(b, rene, "title2")
+	operations.Open(b, rene)
+	operations.Close(b, rene)
+	operations.Close(b, rene)
+
+	err = b.Commit(mockRepo)
+	checkErr(t, err)
+
+	timeline, err := b.Timeline(mockRepo)
+	checkErr(t, err)
+
+	expected := []bool{false, true, false, true, false, true}
+
+	if len(timeline) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(timeline))
+	}
+
+	for i, item := range timeline {
+		if item.NoOp != expected[i] {
+			t.Fatalf("unexpected NoOp for item %d (%s)", i, item.Operation.OpType())
+		}
+	}
+
+	// the history is kept as is
+	if len(b.Compile().Operations) != len(expected) {
+		t.Fatal("the no-op operations should stay in the history")
+	}
+}
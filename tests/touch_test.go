@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestCompactTouches(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = bug1.Touch(repo)
+		checkErr(t, err)
+	}
+
+	operations.Comment(bug1, rene, "message2")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = bug1.Touch(repo)
+		checkErr(t, err)
+	}
+
+	before, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	commitsBefore, err := repo.ListCommits("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	removed, err := bug.CompactTouches(repo, bug1.Id())
+	checkErr(t, err)
+
+	if removed != 3 {
+		t.Fatalf("unexpected number of removed commits: %d", removed)
+	}
+
+	commitsAfter, err := repo.ListCommits("refs/bugs/" + bug1.Id())
+	checkErr(t, err)
+
+	if len(commitsAfter) != len(commitsBefore)-removed {
+		t.Fatal("the history should be shorter")
+	}
+
+	after, err := bug.ReadLocalBug(repo, bug1.Id())
+	checkErr(t, err)
+
+	if after.Id() != bug1.Id() {
+		t.Fatal("the id should be preserved")
+	}
+
+	snapBefore := before.Compile()
+	snapAfter := after.Compile()
+
+	if !reflect.DeepEqual(snapBefore.Comments, snapAfter.Comments) || snapBefore.Title != snapAfter.Title {
+		t.Fatal("the bug should compile identically")
+	}
+
+	// the last touch commit, with the highest edit clock, is kept
+	treeBefore, err := repo.GetTreeHash(commitsBefore[len(commitsBefore)-1])
+	checkErr(t, err)
+	treeAfter, err := repo.GetTreeHash(commitsAfter[len(commitsAfter)-1])
+	checkErr(t, err)
+
+	if treeBefore != treeAfter {
+		t.Fatal("the highest edit clock should be preserved")
+	}
+
+	// nothing left to compact
+	removed, err = bug.CompactTouches(repo, bug1.Id())
+	checkErr(t, err)
+
+	if removed != 0 {
+		t.Fatal("a second compaction should do nothing")
+	}
+}
+
+func TestCompactTouchesOnRemote(t *testing.T) {
+	repo := createRepo(false)
+	defer cleanupRepo(repo)
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	checkErr(t, bug1.Commit(repo))
+	checkErr(t, bug1.Touch(repo))
+	checkErr(t, bug1.Touch(repo))
+
+	// the bug has been shared
+	checkErr(t, repo.AddRemote("origin", "https://example.com/origin"))
+	checkErr(t, repo.CopyRef("refs/bugs/"+bug1.Id(), "refs/remotes/origin/bugs/"+bug1.Id()))
+
+	_, err = bug.CompactTouches(repo, bug1.Id())
+	if !errors.Is(err, bug.ErrBugOnRemote) {
+		t.Fatalf("expected ErrBugOnRemote, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestTriage(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	blaise := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	fresh, err := operations.Create(rene, "fresh", "message")
+	checkErr(t, err)
+	err = fresh.Commit(repo)
+	checkErr(t, err)
+
+	snap := fresh.Compile()
+	if snap.Triaged || snap.TriagedBy != (bug.Person{}) || !snap.TriagedAt.IsZero() {
+		t.Fatal("a fresh bug should be untriaged")
+	}
+
+	triaged, err := operations.Create(rene, "triaged", "message")
+	checkErr(t, err)
+	operations.Triage(triaged, blaise)
+	err = triaged.Commit(repo)
+	checkErr(t, err)
+
+	read, err := bug.ReadLocalBug(repo, triaged.Id())
+	checkErr(t, err)
+	snap = read.Compile()
+
+	if !snap.Triaged {
+		t.Fatal("the bug should be triaged")
+	}
+	if snap.TriagedBy != blaise {
+		t.Fatalf("unexpected triager: %v", snap.TriagedBy)
+	}
+	if snap.TriagedAt != snap.Operations[1].Time() {
+		t.Fatal("unexpected triage time")
+	}
+
+	untriaged, err := bug.ListUntriaged(repo)
+	checkErr(t, err)
+	if len(untriaged) != 1 || untriaged[0].Id() != fresh.Id() {
+		t.Fatal("only the fresh bug should be untriaged")
+	}
+}
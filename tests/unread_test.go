@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestUnreadOps(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+
+	bug1, err := operations.Create(rene, "bug1", "message")
+	checkErr(t, err)
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	unread, err := bug.UnreadOps(repo, bug1.Id())
+	checkErr(t, err)
+	if len(unread) != 1 {
+		t.Fatalf("a bug never marked should be entirely unread, got %d", len(unread))
+	}
+
+	err = bug.MarkRead(repo, bug1.Id())
+	checkErr(t, err)
+
+	unread, err = bug.UnreadOps(repo, bug1.Id())
+	checkErr(t, err)
+	if len(unread) != 0 {
+		t.Fatalf("unexpected unread operations: %d", len(unread))
+	}
+
+	operations.Comment(bug1, rene, "message2")
+	operations.Comment(bug1, rene, "message3")
+	err = bug1.Commit(repo)
+	checkErr(t, err)
+
+	unread, err = bug.UnreadOps(repo, bug1.Id())
+	checkErr(t, err)
+	if len(unread) != 2 {
+		t.Fatalf("unexpected unread operations: %d", len(unread))
+	}
+
+	err = bug.MarkRead(repo, bug1.Id())
+	checkErr(t, err)
+
+	unread, err = bug.UnreadOps(repo, bug1.Id())
+	checkErr(t, err)
+	if len(unread) != 0 {
+		t.Fatalf("unexpected unread operations: %d", len(unread))
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MichaelMure/git-bug/bug"
+	"github.com/MichaelMure/git-bug/bug/operations"
+	"github.com/MichaelMure/git-bug/repository"
+)
+
+func TestExpireWaiting(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	triager := bug.Person{Name: "Blaise Pascal", Email: "blaise@pascal.fr"}
+
+	newWaitingBug := func(title string) *bug.Bug {
+		b, err := operations.Create(rene, title, "message")
+		checkErr(t, err)
+		err = operations.WaitForReporter(b, triager)
+		checkErr(t, err)
+		return b
+	}
+
+	silent := newWaitingBug("silent")
+	checkErr(t, silent.Commit(repo))
+
+	answered := newWaitingBug("answered")
+	operations.Comment(answered, rene, "here is the info")
+	checkErr(t, answered.Commit(repo))
+
+	policy := operations.WaitingPolicy{
+		Timeout:      14 * 24 * time.Hour,
+		Close:        true,
+		ExpiredLabel: "stale",
+	}
+
+	// nothing expire before the timeout, but the answered bug is not
+	// waiting anymore
+	expired, err := operations.ExpireWaiting(repo, triager, policy, time.Now())
+	checkErr(t, err)
+	if len(expired) != 0 {
+		t.Fatalf("no bug should expire yet, got %v", expired)
+	}
+
+	snap := readSnapshot(t, repo, answered.Id())
+	if _, waiting := operations.WaitingSince(snap); waiting {
+		t.Fatal("the answered bug should not be waiting anymore")
+	}
+
+	snap = readSnapshot(t, repo, silent.Id())
+	if _, waiting := operations.WaitingSince(snap); !waiting {
+		t.Fatal("the silent bug should still be waiting")
+	}
+	if snap.Status != bug.OpenStatus {
+		t.Fatal("the silent bug should still be open")
+	}
+
+	// the waiting period elapse
+	later := time.Now().Add(policy.Timeout + time.Hour)
+	expired, err = operations.ExpireWaiting(repo, triager, policy, later)
+	checkErr(t, err)
+	if len(expired) != 1 || expired[0] != silent.Id() {
+		t.Fatalf("only the silent bug should expire, got %v", expired)
+	}
+
+	snap = readSnapshot(t, repo, silent.Id())
+	if _, waiting := operations.WaitingSince(snap); waiting {
+		t.Fatal("the expired bug should not be waiting anymore")
+	}
+	if snap.Status != bug.ClosedStatus {
+		t.Fatal("the expired bug should be closed")
+	}
+	if len(snap.Labels) != 1 || snap.Labels[0] != "stale" {
+		t.Fatalf("the expired bug should be relabeled, got %v", snap.Labels)
+	}
+
+	snap = readSnapshot(t, repo, answered.Id())
+	if snap.Status != bug.OpenStatus {
+		t.Fatal("the answered bug should still be open")
+	}
+}
+
+func readSnapshot(t *testing.T, repo repository.Repo, id string) bug.Snapshot {
+	b, err := bug.ReadLocalBug(repo, id)
+	checkErr(t, err)
+	return b.Compile()
+}
@@ -33,13 +33,13 @@ func (h Hash) MarshalGQL(w io.Writer) {
 	w.Write([]byte(`"` + h.String() + `"`))
 }
 
-// IsValid tell if the hash is valid
+// IsValid tell if the hash is valid, that is 40 lowercase hexadecimal characters
 func (h *Hash) IsValid() bool {
 	if len(*h) != 40 {
 		return false
 	}
 	for _, r := range *h {
-		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+		if (r < 'a' || r > 'f') && (r < '0' || r > '9') {
 			return false
 		}
 	}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashIsValid(t *testing.T) {
+	cases := []struct {
+		Input Hash
+		Valid bool
+	}{
+		{"0123456789abcdef0123456789abcdef01234567", true},
+		{Hash(strings.Repeat("z", 40)), false},
+		{Hash(strings.Repeat("A", 40)), false},
+		{"0123456789abcdef", false},
+		{"", false},
+	}
+
+	for _, tc := range cases {
+		if tc.Input.IsValid() != tc.Valid {
+			t.Fatalf("%s: expected valid=%v", tc.Input, tc.Valid)
+		}
+	}
+}